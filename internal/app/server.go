@@ -12,6 +12,9 @@ import (
 	"time"
 )
 
+// backgroundShutdownTimeout Не меньше времени жизни фоновой задачи usecase пользователей
+const backgroundShutdownTimeout = 30 * time.Second
+
 func RunApp() error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -51,6 +54,24 @@ func RunApp() error {
 		return err
 	}
 
+	// Фоновые задачи пишут в БД, Redis и аудит, поэтому ждем их первыми.
+	// Задача живет не дольше 30 секунд, поэтому и ждем столько же, а не shutdownCtx
+	if app.Users != nil {
+		backgroundCtx, cancelBackground := context.WithTimeout(context.Background(), backgroundShutdownTimeout)
+		err = app.Users.Close(backgroundCtx)
+		cancelBackground()
+		if err != nil {
+			app.Logger.Error("Background tasks did not finish", slog.Any("error", err))
+		}
+	}
+
+	// Журнал аудита дописываем до закрытия БД
+	if app.Audit != nil {
+		if err = app.Audit.Close(shutdownCtx); err != nil {
+			app.Logger.Error("Audit log flush failed", slog.Any("error", err))
+		}
+	}
+
 	// Закрываем БД
 	if app.DB != nil {
 		err = app.DB.Close()
@@ -2,17 +2,34 @@ package bootstrap
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"database/sql"
+	"encoding/base64"
 	"encoding/pem"
 	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/cache"
 	"github.com/Elaman1/full-project-mock/internal/config"
 	"github.com/Elaman1/full-project-mock/internal/database"
 	"github.com/Elaman1/full-project-mock/internal/delivery/rest"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	domailer "github.com/Elaman1/full-project-mock/internal/domain/mailer"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
 	"github.com/Elaman1/full-project-mock/internal/logger"
+	"github.com/Elaman1/full-project-mock/internal/mailer"
 	"github.com/Elaman1/full-project-mock/internal/module"
+	"github.com/Elaman1/full-project-mock/internal/module/account"
+	"github.com/Elaman1/full-project-mock/internal/module/audit"
+	"github.com/Elaman1/full-project-mock/internal/module/mfa"
+	"github.com/Elaman1/full-project-mock/internal/module/rbac"
 	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/crypter"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"github.com/redis/go-redis/v9"
 	"log/slog"
 	"net/http"
@@ -20,11 +37,16 @@ import (
 	"time"
 )
 
+const permissionsCacheTTL = 5 * time.Minute
+
 type App struct {
 	Server  *http.Server
 	DB      *sql.DB
 	Logger  *slog.Logger
 	RedisDB *redis.Client
+	Audit   *audit.AsyncLogger
+	// Users Письма отправляются в фоне, их нужно дождаться до закрытия БД и Redis
+	Users usecase.UserUsecase
 }
 
 func InitApp(ctx context.Context, cfg *config.Config) (*App, error) {
@@ -42,34 +64,66 @@ func InitApp(ctx context.Context, cfg *config.Config) (*App, error) {
 		return nil, err
 	}
 
-	publicKey, err := LoadRSAPublicKey(cfg.JWT.PublicKeyPath)
+	ttl, err := time.ParseDuration(cfg.JWT.AccessTTL)
+	// По идее дополнительно сверху проверяется
 	if err != nil {
-		logs.Error("error loading public key", "error", err)
+		logs.Error("error parsing access ttl", "error", err)
 		return nil, err
 	}
 
-	privateKey, err := LoadRSAPrivateKey(cfg.JWT.PrivateKeyPath)
+	keyRing, err := LoadKeyRing(&cfg.JWT, ttl)
 	if err != nil {
-		logs.Error("error loading private key", "error", err)
+		logs.Error("error loading jwt keys", "error", err)
 		return nil, err
 	}
 
-	ttl, err := time.ParseDuration(cfg.JWT.AccessTTL)
-	// По идее дополнительно сверху проверяется
+	tokenService := service.NewTokenServiceWithKeyRing(keyRing, ttl, service.TokenOptions{
+		Issuer:   cfg.JWT.Issuer,
+		Audience: cfg.JWT.Audience,
+		Leeway:   cfg.JWT.Leeway,
+	})
+	accessDenylist := cache.NewAccessDenylistRedis(redisDB, ttl)
+	mfaCrypter, err := InitMFACrypter(&cfg.MFA)
 	if err != nil {
-		logs.Error("error parsing access ttl", "error", err)
+		logs.Error("error initializing mfa encryption", "error", err)
 		return nil, err
 	}
 
-	tokenService := service.NewTokenService(publicKey, privateKey, ttl)
-	allModules := module.InitAllModule(db, redisDB, tokenService)
+	passwordPolicy, err := InitPasswordPolicy(&cfg.PasswordPolicy)
+	if err != nil {
+		logs.Error("error loading breached passwords list", "error", err)
+		return nil, err
+	}
+
+	clientIPResolver, err := req.NewClientIPResolver(cfg.Server.TrustedProxies)
+	if err != nil {
+		logs.Error("error parsing trusted proxies", "error", err)
+		return nil, err
+	}
+
+	// Пишет в фоне, при остановке сервиса дописывает накопленные события
+	auditLogger := audit.NewAsyncLogger(audit.NewAuditRepository(db), cfg.Audit, logs)
+
+	allModules := module.InitAllModule(db, redisDB, tokenService, accessDenylist, InitMailer(&cfg.Mail, logs), mfaCrypter, passwordPolicy, auditLogger, cfg)
+
+	// Права ролей кешируем в памяти, чтобы не ходить в БД на каждый запрос
+	permissionRepo := rbac.NewCachedPermissionRepository(rbac.NewPermissionRepository(db), permissionsCacheTTL)
 
 	routeApp := &rest.RouteApp{
-		Logs:         logs,
-		TokenService: tokenService,
+		Logs:             logs,
+		TokenService:     tokenService,
+		AccessDenylist:   accessDenylist,
+		Permissions:      permissionRepo,
+		APIKeys:          allModules.APIKeyHandler.Usecase,
+		RateLimiter:      InitRateLimiter(&cfg.RateLimit, redisDB),
+		RateLimits:       rest.RateLimits(&cfg.RateLimit),
+		ClientIPResolver: clientIPResolver,
 	}
 	routeHandler := rest.InitRouter(ctx, routeApp, allModules)
 
+	// Останавливается вместе с ctx при завершении сервиса
+	go account.NewPurger(allModules.AccountHandler.Usecase, cfg.Account, logs).Run(ctx)
+
 	srv := &http.Server{
 		Addr:         cfg.Server.Port,
 		WriteTimeout: cfg.Server.WriteTimeout,
@@ -82,10 +136,140 @@ func InitApp(ctx context.Context, cfg *config.Config) (*App, error) {
 		DB:      db, // Передаем, чтобы закрыть соединение при отключении сервера
 		Logger:  logs,
 		RedisDB: redisDB, // То же самое
+		Audit:   auditLogger,
+		Users:   allModules.UserHandler.Usecase,
 	}, nil
 }
 
-func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+// InitMailer smtp для прода, во всех остальных случаях письма пишутся в файл или лог
+func InitMailer(cfg *config.Mail, logs *slog.Logger) domailer.Mailer {
+	if cfg.Driver == "smtp" {
+		return mailer.NewSMTPMailer(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From)
+	}
+
+	return mailer.NewFileMailer(cfg.FilePath, logs)
+}
+
+// InitRateLimiter memory годится только для одного инстанса: у каждого процесса свои счетчики
+func InitRateLimiter(cfg *config.RateLimit, redisDB *redis.Client) domcache.RateLimiter {
+	if cfg.Store == "memory" {
+		return cache.NewRateLimiterMemory()
+	}
+
+	return cache.NewRateLimiterRedis(redisDB)
+}
+
+// InitMFACrypter Без ключа возвращает nil: логин работает, но включить MFA нельзя
+func InitMFACrypter(cfg *config.MFA) (mfa.SecretCrypter, error) {
+	if cfg.EncryptionKey == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode mfa encryption key: %w", err)
+	}
+
+	return crypter.NewAESGCM(key)
+}
+
+// InitPasswordPolicy Список утекших паролей читается в память один раз при старте
+func InitPasswordPolicy(cfg *config.PasswordPolicy) (validator.PasswordPolicy, error) {
+	policy := validator.PasswordPolicy{
+		MinLength:        cfg.MinLength,
+		MaxLength:        cfg.MaxLength,
+		RequireLowercase: cfg.RequireLowercase,
+		RequireUppercase: cfg.RequireUppercase,
+		RequireDigit:     cfg.RequireDigit,
+		RequireSymbol:    cfg.RequireSymbol,
+	}
+
+	if cfg.BreachedListPath == "" {
+		return policy, nil
+	}
+
+	breached, err := validator.LoadBreachedPasswords(cfg.BreachedListPath)
+	if err != nil {
+		return validator.PasswordPolicy{}, fmt.Errorf("load breached passwords: %w", err)
+	}
+
+	policy.Breached = breached
+	return policy, nil
+}
+
+// LoadKeyRing Собирает связку ключей: активный ключ для подписи и старые ключи только для проверки.
+// Старый ключ живет еще accessTTL после ротации, чтобы выпущенные им токены успели истечь
+func LoadKeyRing(cfg *config.JWTConfig, accessTTL time.Duration) (*service.KeyRing, error) {
+	algorithm := cfg.Algorithm
+	if algorithm == "" {
+		algorithm = service.AlgorithmRS256
+	}
+
+	publicKey, err := LoadPublicKey(cfg.PublicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("load public key: %w", err)
+	}
+
+	privateKey, err := LoadPrivateKey(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("load private key: %w", err)
+	}
+
+	if err = service.ValidateKeyAlgorithm(algorithm, publicKey); err != nil {
+		return nil, fmt.Errorf("active key: %w", err)
+	}
+
+	if !publicKeysEqual(privateKey.Public(), publicKey) {
+		return nil, errors.New("public key does not match private key")
+	}
+
+	activeID := cfg.KeyID
+	if activeID == "" {
+		activeID = service.KeyThumbprint(publicKey)
+	}
+
+	keyRing, err := service.NewKeyRing(&service.SigningKey{
+		ID:         activeID,
+		Algorithm:  algorithm,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+	}, accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, retired := range cfg.RetiredKeys {
+		retiredPub, loadErr := LoadPublicKey(retired.PublicKeyPath)
+		if loadErr != nil {
+			return nil, fmt.Errorf("load retired key %s: %w", retired.PublicKeyPath, loadErr)
+		}
+
+		retiredID := retired.KeyID
+		if retiredID == "" {
+			retiredID = service.KeyThumbprint(retiredPub)
+		}
+
+		// При смене алгоритма у старого ключа указывается свой
+		retiredAlg := retired.Algorithm
+		if retiredAlg == "" {
+			retiredAlg = algorithm
+		}
+
+		if err = keyRing.AddRetired(&service.SigningKey{
+			ID:        retiredID,
+			Algorithm: retiredAlg,
+			PublicKey: retiredPub,
+			RetiredAt: retired.RetiredAt,
+		}); err != nil {
+			return nil, fmt.Errorf("retired key %s: %w", retired.PublicKeyPath, err)
+		}
+	}
+
+	return keyRing, nil
+}
+
+// LoadPrivateKey Тип ключа (RSA, ECDSA, Ed25519) определяется по содержимому PEM
+func LoadPrivateKey(path string) (crypto.Signer, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -96,20 +280,33 @@ func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
 		return nil, errors.New("invalid PEM format for private key")
 	}
 
-	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	var parsedKey any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsedKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	rsaKey, ok := parsedKey.(*rsa.PrivateKey)
-	if !ok {
-		return nil, errors.New("parsed key is not an RSA private key")
+	switch key := parsedKey.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", parsedKey)
 	}
-
-	return rsaKey, nil
 }
 
-func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -120,15 +317,26 @@ func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
 		return nil, errors.New("invalid PEM format for public key")
 	}
 
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	var pub any
+	if block.Type == "RSA PUBLIC KEY" {
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	rsaPub, ok := pub.(*rsa.PublicKey)
-	if !ok {
-		return nil, errors.New("parsed key is not an RSA public key")
+	switch key := pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", pub)
 	}
+}
 
-	return rsaPub, nil
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
 }
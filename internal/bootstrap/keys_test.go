@@ -0,0 +1,87 @@
+package bootstrap
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKeyPair Сохраняет ключи в PEM (PKCS8 + PKIX) и возвращает пути
+func writeKeyPair(t *testing.T, signer crypto.Signer) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(signer)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	require.NoError(t, err)
+
+	privPath := filepath.Join(dir, "private.pem")
+	pubPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o600))
+	return privPath, pubPath
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		signer    crypto.Signer
+		algorithm string
+		expectErr bool
+	}{
+		{name: "rsa default algorithm", signer: rsaKey, algorithm: ""},
+		{name: "ecdsa", signer: ecKey, algorithm: service.AlgorithmES256},
+		{name: "ed25519", signer: edKey, algorithm: service.AlgorithmEdDSA},
+		{name: "algorithm does not match key", signer: ecKey, algorithm: service.AlgorithmRS256, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privPath, pubPath := writeKeyPair(t, tt.signer)
+			cfg := &config.JWTConfig{PrivateKeyPath: privPath, PublicKeyPath: pubPath, Algorithm: tt.algorithm}
+
+			ring, loadErr := LoadKeyRing(cfg, time.Minute)
+			if tt.expectErr {
+				assert.Error(t, loadErr)
+				return
+			}
+
+			require.NoError(t, loadErr)
+			assert.Equal(t, service.KeyThumbprint(tt.signer.Public()), ring.Active().ID)
+		})
+	}
+}
+
+func TestLoadKeyRing_MismatchedPair(t *testing.T) {
+	first, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privPath, _ := writeKeyPair(t, first)
+	_, pubPath := writeKeyPair(t, second)
+
+	_, err = LoadKeyRing(&config.JWTConfig{PrivateKeyPath: privPath, PublicKeyPath: pubPath, Algorithm: service.AlgorithmES256}, time.Minute)
+	assert.EqualError(t, err, "public key does not match private key")
+}
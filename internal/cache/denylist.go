@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// legacySecondsLimit Отметки "отозвать все" меньше этого числа записаны в секундах, а не в миллисекундах
+const legacySecondsLimit = 1e11
+
+type accessDenylist struct {
+	redis     *redis.Client
+	accessTTL time.Duration
+}
+
+// NewAccessDenylistRedis accessTTL нужен, чтобы отметка "отозвать все" жила не дольше любого выпущенного токена
+func NewAccessDenylistRedis(redis *redis.Client, accessTTL time.Duration) cache.AccessTokenDenylist {
+	return &accessDenylist{redis: redis, accessTTL: accessTTL}
+}
+
+func (d *accessDenylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return fmt.Errorf("token has no jti")
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// Токен уже истек, отзывать нечего
+		return nil
+	}
+
+	return d.redis.Set(ctx, buildDenylistTokenKey(jti), 1, ttl).Err()
+}
+
+func (d *accessDenylist) RevokeAllUserTokens(ctx context.Context, userID int64) error {
+	return d.redis.Set(ctx, buildDenylistUserKey(userID), time.Now().UnixMilli(), d.accessTTL).Err()
+}
+
+func (d *accessDenylist) RevokeSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return fmt.Errorf("empty session id")
+	}
+
+	return d.redis.Set(ctx, buildDenylistSessionKey(sessionID), 1, d.accessTTL).Err()
+}
+
+func (d *accessDenylist) IsRevoked(ctx context.Context, jti, sessionID string, userID int64, issuedAt time.Time) (bool, error) {
+	values, err := d.redis.MGet(ctx, buildDenylistTokenKey(jti), buildDenylistSessionKey(sessionID), buildDenylistUserKey(userID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if jti != "" && values[0] != nil {
+		return true, nil
+	}
+
+	if sessionID != "" && values[1] != nil {
+		return true, nil
+	}
+
+	revokedBefore, ok := values[2].(string)
+	if !ok {
+		return false, nil
+	}
+
+	revokedAt, err := strconv.ParseInt(revokedBefore, 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	return issuedBefore(issuedAt, revokedAt), nil
+}
+
+// issuedBefore Токен, выпущенный в ту же миллисекунду, что и отзыв, считаем выпущенным после него:
+// смена пароля сразу выдает новый токен. Токены без iat выпущены до появления отзыва, считаем их отозванными
+func issuedBefore(issuedAt time.Time, revokedAt int64) bool {
+	// Отметка прежнего формата в секундах живет не дольше accessTTL
+	if revokedAt < legacySecondsLimit {
+		revokedAt *= 1000
+	}
+
+	return issuedAt.UnixMilli() < revokedAt
+}
+
+// Формат ключа: auth:access_denylist:jti:<jti>
+func buildDenylistTokenKey(jti string) string {
+	return fmt.Sprintf("auth:access_denylist:jti:%s", jti)
+}
+
+// Формат ключа: auth:access_denylist:sid:<sessionID>
+func buildDenylistSessionKey(sessionID string) string {
+	return fmt.Sprintf("auth:access_denylist:sid:%s", sessionID)
+}
+
+// Формат ключа: auth:access_denylist:user:<userID> - unix-время в миллисекундах, до которого все токены пользователя отозваны
+func buildDenylistUserKey(userID int64) string {
+	return fmt.Sprintf("auth:access_denylist:user:%d", userID)
+}
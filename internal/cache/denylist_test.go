@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIssuedBefore(t *testing.T) {
+	revokedAt := time.Date(2025, 1, 1, 0, 0, 0, 500*int(time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name      string
+		issuedAt  time.Time
+		revokedAt int64
+		want      bool
+	}{
+		{name: "same second before revoke", issuedAt: revokedAt.Add(-300 * time.Millisecond), revokedAt: revokedAt.UnixMilli(), want: true},
+		{name: "same second after revoke", issuedAt: revokedAt.Add(300 * time.Millisecond), revokedAt: revokedAt.UnixMilli(), want: false},
+		{name: "same millisecond", issuedAt: revokedAt, revokedAt: revokedAt.UnixMilli(), want: false},
+		{name: "no iat", issuedAt: time.Time{}, revokedAt: revokedAt.UnixMilli(), want: true},
+		{name: "legacy seconds mark", issuedAt: revokedAt.Add(-time.Second), revokedAt: revokedAt.Unix(), want: true},
+		{name: "legacy seconds mark, later token", issuedAt: revokedAt.Add(time.Second), revokedAt: revokedAt.Unix(), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, issuedBefore(tt.issuedAt, tt.revokedAt))
+		})
+	}
+}
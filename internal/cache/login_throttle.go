@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/redis/go-redis/v9"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLoginWindow         = 15 * time.Minute
+	defaultMaxAttemptsPerEmail = 5
+	defaultMaxAttemptsPerIP    = 50
+	defaultLockoutBase         = time.Minute
+	defaultLockoutMax          = time.Hour
+
+	// lockoutLevelTTL Сколько помним прошлые блокировки для прогрессии
+	lockoutLevelTTL = 24 * time.Hour
+)
+
+// registerLoginFailureScript Скользящее окно на sorted set: score - время попытки в мс.
+// При достижении порога ставит блокировку длительностью base * 2^(уровень-1), но не больше max
+//
+// KEYS: 1 - окно попыток, 2 - блокировка, 3 - уровень блокировки
+// ARGV: 1 - сейчас в мс, 2 - окно в мс, 3 - порог, 4 - base в мс, 5 - max в мс, 6 - уникальный id попытки, 7 - ttl уровня в мс
+var registerLoginFailureScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], 0, now - window)
+redis.call('ZADD', KEYS[1], now, ARGV[6])
+redis.call('PEXPIRE', KEYS[1], window)
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[3]) then
+	return 0
+end
+local level = redis.call('INCR', KEYS[3])
+redis.call('PEXPIRE', KEYS[3], ARGV[7])
+local lock = tonumber(ARGV[4]) * math.pow(2, level - 1)
+if lock > tonumber(ARGV[5]) then
+	lock = tonumber(ARGV[5])
+end
+lock = math.floor(lock)
+redis.call('SET', KEYS[2], 1, 'PX', lock)
+redis.call('DEL', KEYS[1])
+return lock
+`)
+
+type loginThrottle struct {
+	redis  *redis.Client
+	limits config.LoginThrottle
+}
+
+func NewLoginThrottleRedis(redis *redis.Client, limits config.LoginThrottle) cache.LoginThrottle {
+	if limits.Window == 0 {
+		limits.Window = defaultLoginWindow
+	}
+
+	if limits.MaxAttemptsPerEmail == 0 {
+		limits.MaxAttemptsPerEmail = defaultMaxAttemptsPerEmail
+	}
+
+	if limits.MaxAttemptsPerIP == 0 {
+		limits.MaxAttemptsPerIP = defaultMaxAttemptsPerIP
+	}
+
+	if limits.LockoutBase == 0 {
+		limits.LockoutBase = defaultLockoutBase
+	}
+
+	if limits.LockoutMax == 0 {
+		limits.LockoutMax = defaultLockoutMax
+	}
+
+	return &loginThrottle{redis: redis, limits: limits}
+}
+
+func (t *loginThrottle) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	pipe := t.redis.Pipeline()
+	emailTTL := pipe.PTTL(ctx, buildLoginLockKey("email", normalizeEmail(email)))
+	ipTTL := pipe.PTTL(ctx, buildLoginLockKey("ip", ip))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	// Для отсутствующего ключа PTTL отрицательный
+	return max(emailTTL.Val(), ipTTL.Val(), 0), nil
+}
+
+func (t *loginThrottle) RegisterFailure(ctx context.Context, email, ip string) (time.Duration, error) {
+	emailLock, err := t.registerFailure(ctx, "email", normalizeEmail(email), t.limits.MaxAttemptsPerEmail)
+	if err != nil {
+		return 0, err
+	}
+
+	ipLock, err := t.registerFailure(ctx, "ip", ip, t.limits.MaxAttemptsPerIP)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(emailLock, ipLock), nil
+}
+
+func (t *loginThrottle) Reset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	return t.redis.Del(ctx, buildLoginAttemptsKey("email", email), buildLoginLevelKey("email", email)).Err()
+}
+
+func (t *loginThrottle) Unlock(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	return t.redis.Del(ctx,
+		buildLoginAttemptsKey("email", email),
+		buildLoginLevelKey("email", email),
+		buildLoginLockKey("email", email),
+	).Err()
+}
+
+func (t *loginThrottle) registerFailure(ctx context.Context, kind, value string, maxAttempts int) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	now := time.Now()
+	keys := []string{buildLoginAttemptsKey(kind, value), buildLoginLockKey(kind, value), buildLoginLevelKey(kind, value)}
+	attemptID := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int64())
+
+	lockMs, err := registerLoginFailureScript.Run(ctx, t.redis, keys,
+		now.UnixMilli(), t.limits.Window.Milliseconds(), maxAttempts,
+		t.limits.LockoutBase.Milliseconds(), t.limits.LockoutMax.Milliseconds(),
+		attemptID, lockoutLevelTTL.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(lockMs) * time.Millisecond, nil
+}
+
+// normalizeEmail Иначе перебор обходится сменой регистра
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func buildLoginAttemptsKey(kind, value string) string {
+	return fmt.Sprintf("auth:login_attempts:%s:%s", kind, value)
+}
+
+func buildLoginLockKey(kind, value string) string {
+	return fmt.Sprintf("auth:login_lock:%s:%s", kind, value)
+}
+
+func buildLoginLevelKey(kind, value string) string {
+	return fmt.Sprintf("auth:login_lock_level:%s:%s", kind, value)
+}
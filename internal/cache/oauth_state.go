@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const oauthStatePrefix = "auth:oauth_state:"
+
+type oauthStateCache struct {
+	redis *redis.Client
+}
+
+func NewOAuthStateRedis(redis *redis.Client) cache.OAuthStateCache {
+	return &oauthStateCache{redis: redis}
+}
+
+func (c *oauthStateCache) Save(ctx context.Context, hashedState string, state model.OAuthState, ttl time.Duration) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return c.redis.Set(ctx, oauthStatePrefix+hashedState, data, ttl).Err()
+}
+
+// Consume GETDEL, чтобы один state нельзя было использовать дважды
+func (c *oauthStateCache) Consume(ctx context.Context, hashedState string) (model.OAuthState, error) {
+	data, err := c.redis.GetDel(ctx, oauthStatePrefix+hashedState).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return model.OAuthState{}, cache.ErrNotFound
+	}
+
+	if err != nil {
+		return model.OAuthState{}, err
+	}
+
+	var state model.OAuthState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return model.OAuthState{}, err
+	}
+
+	return state, nil
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// saveOneTimeTokenScript KEYS: 1 - ключ пользователя, 2 - ключ нового токена
+// ARGV: 1 - префикс ключа токена, 2 - hash нового токена, 3 - userID, 4 - ttl в мс
+var saveOneTimeTokenScript = redis.NewScript(`
+local old = redis.call('GET', KEYS[1])
+if old then
+	redis.call('DEL', ARGV[1] .. old)
+end
+redis.call('SET', KEYS[2], ARGV[3], 'PX', ARGV[4])
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[4])
+return 1
+`)
+
+// consumeOneTimeTokenScript Чтение и удаление одним скриптом, чтобы токен нельзя было использовать дважды
+//
+// KEYS: 1 - ключ токена
+// ARGV: 1 - префикс ключа пользователя
+var consumeOneTimeTokenScript = redis.NewScript(`
+local userID = redis.call('GET', KEYS[1])
+if not userID then
+	return false
+end
+redis.call('DEL', KEYS[1], ARGV[1] .. userID)
+return userID
+`)
+
+type oneTimeTokenCache struct {
+	redis  *redis.Client
+	prefix string
+}
+
+// NewOneTimeTokenRedis prefix отделяет назначение токенов, например "auth:email_verify"
+func NewOneTimeTokenRedis(redis *redis.Client, prefix string) cache.OneTimeTokenCache {
+	return &oneTimeTokenCache{redis: redis, prefix: prefix}
+}
+
+func (c *oneTimeTokenCache) Save(ctx context.Context, userID int64, hashedToken string, ttl time.Duration) error {
+	keys := []string{c.userKey(strconv.FormatInt(userID, 10)), c.tokenKey(hashedToken)}
+	return saveOneTimeTokenScript.Run(ctx, c.redis, keys, c.tokenKey(""), hashedToken, userID, ttl.Milliseconds()).Err()
+}
+
+func (c *oneTimeTokenCache) Peek(ctx context.Context, hashedToken string) (int64, error) {
+	res, err := c.redis.Get(ctx, c.tokenKey(hashedToken)).Result()
+	return parseOneTimeTokenOwner(res, err)
+}
+
+func (c *oneTimeTokenCache) Consume(ctx context.Context, hashedToken string) (int64, error) {
+	res, err := consumeOneTimeTokenScript.Run(ctx, c.redis, []string{c.tokenKey(hashedToken)}, c.userKey("")).Text()
+	return parseOneTimeTokenOwner(res, err)
+}
+
+func parseOneTimeTokenOwner(res string, err error) (int64, error) {
+	if errors.Is(err, redis.Nil) {
+		return 0, cache.ErrNotFound
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := strconv.ParseInt(res, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid one-time token owner: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (c *oneTimeTokenCache) tokenKey(hashedToken string) string {
+	return c.prefix + ":token:" + hashedToken
+}
+
+func (c *oneTimeTokenCache) userKey(userID string) string {
+	return c.prefix + ":user:" + userID
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// gcraScript GCRA: храним только TAT (theoretical arrival time) в мс.
+// Время берем из Redis, чтобы у всех инстансов были одни часы
+//
+// KEYS: 1 - ключ лимита
+// ARGV: 1 - интервал восстановления одного запроса в мс, 2 - burst
+// Ответ: {разрешен, осталось, retry_after мс, reset_after мс}
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local newTat = tat + interval
+local allowAt = newTat - interval * burst
+if now < allowAt then
+	return {0, 0, allowAt - now, tat - now}
+end
+
+redis.call('SET', KEYS[1], string.format('%.0f', newTat), 'PX', newTat - now)
+return {1, math.floor((now - allowAt) / interval), 0, newTat - now}
+`)
+
+type rateLimiterRedis struct {
+	redis *redis.Client
+}
+
+func NewRateLimiterRedis(redis *redis.Client) cache.RateLimiter {
+	return &rateLimiterRedis{redis: redis}
+}
+
+func (l *rateLimiterRedis) Allow(ctx context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error) {
+	values, err := gcraScript.Run(ctx, l.redis, []string{"ratelimit:" + key}, limit.Interval().Milliseconds(), limit.Burst).Int64Slice()
+	if err != nil {
+		return model.RateLimitResult{}, err
+	}
+
+	return model.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval Как часто удаляем ключи, бюджет которых уже восстановился
+const memorySweepInterval = time.Minute
+
+// rateLimiterMemory GCRA в памяти процесса. Для одного инстанса и тестов, между инстансами лимиты не делятся
+type rateLimiterMemory struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewRateLimiterMemory() cache.RateLimiter {
+	return newRateLimiterMemory(time.Now)
+}
+
+func newRateLimiterMemory(now func() time.Time) *rateLimiterMemory {
+	return &rateLimiterMemory{
+		tats:      make(map[string]time.Time),
+		lastSweep: now(),
+		now:       now,
+	}
+}
+
+func (l *rateLimiterMemory) Allow(_ context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweepLocked(now)
+
+	interval := limit.Interval()
+	tat, ok := l.tats[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-interval * time.Duration(limit.Burst))
+	if now.Before(allowAt) {
+		return model.RateLimitResult{
+			Allowed:    false,
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}, nil
+	}
+
+	l.tats[key] = newTat
+	return model.RateLimitResult{
+		Allowed:    true,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTat.Sub(now),
+	}, nil
+}
+
+func (l *rateLimiterMemory) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, tat := range l.tats {
+		if !tat.After(now) {
+			delete(l.tats, key)
+		}
+	}
+
+	l.lastSweep = now
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterMemory(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiterMemory(func() time.Time { return now })
+	limit := model.RateLimit{Rate: 6, Period: time.Minute, Burst: 3}
+	ctx := context.Background()
+
+	// Burst запросов проходит сразу
+	for i := 2; i >= 0; i-- {
+		res, err := limiter.Allow(ctx, "ip:1", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, err := limiter.Allow(ctx, "ip:1", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 10*time.Second, res.RetryAfter)
+	assert.Equal(t, 30*time.Second, res.ResetAfter)
+
+	// Другой ключ считается отдельно
+	res, err = limiter.Allow(ctx, "ip:2", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// Через интервал восстанавливается ровно один запрос
+	now = now.Add(10 * time.Second)
+	res, err = limiter.Allow(ctx, "ip:1", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, err = limiter.Allow(ctx, "ip:1", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+
+	// Восстановившиеся ключи удаляются
+	now = now.Add(2 * memorySweepInterval)
+	_, err = limiter.Allow(ctx, "ip:3", limit)
+	require.NoError(t, err)
+	assert.Len(t, limiter.tats, 1)
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Elaman1/full-project-mock/internal/domain/cache"
 	"github.com/redis/go-redis/v9"
@@ -10,6 +11,51 @@ import (
 	"time"
 )
 
+const (
+	sessionKeyPrefix     = "auth:refresh:"
+	refreshHashKeyPrefix = "auth:refresh_hash:"
+)
+
+// rotateSessionScript Проверка и замена выполняются одним скриптом, поэтому из двух
+// параллельных refresh с одним токеном пройдет только один
+//
+// KEYS: 1 - старый hash, 2 - старая сессия, 3 - след ротации, 4 - индекс пользователя,
+// 5 - семейство, 6 - новый hash, 7 - новая сессия
+// ARGV: 1 - старый tokenID, 2 - старый compound, 3 - след ротации (json),
+// 4 - новый tokenID, 5 - новая сессия (json), 6 - новый compound, 7 - ttl в мс
+var rotateSessionScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+redis.call('SREM', KEYS[4], ARGV[2])
+redis.call('SREM', KEYS[5], ARGV[2])
+redis.call('SET', KEYS[3], ARGV[3], 'PX', ARGV[7])
+redis.call('SET', KEYS[6], ARGV[4], 'PX', ARGV[7])
+redis.call('SET', KEYS[7], ARGV[5], 'PX', ARGV[7])
+redis.call('SADD', KEYS[4], ARGV[6])
+redis.call('SADD', KEYS[5], ARGV[6])
+redis.call('PEXPIRE', KEYS[5], ARGV[7])
+return 1
+`)
+
+// revokeFamilyScript Удаляет все живые сессии семейства
+//
+// KEYS: 1 - семейство, 2 - индекс пользователя
+// ARGV: 1 - префикс ключа сессии, 2 - префикс ключа hash
+var revokeFamilyScript = redis.NewScript(`
+local members = redis.call('SMEMBERS', KEYS[1])
+for _, compound in ipairs(members) do
+	local sep = string.find(compound, ':', 1, true)
+	if sep then
+		redis.call('DEL', ARGV[1] .. string.sub(compound, 1, sep - 1), ARGV[2] .. string.sub(compound, sep + 1))
+	end
+	redis.call('SREM', KEYS[2], compound)
+end
+redis.call('DEL', KEYS[1])
+return #members
+`)
+
 type sessionCache struct {
 	redis *redis.Client
 }
@@ -32,13 +78,30 @@ func (c *sessionCache) SaveSession(ctx context.Context, s *cache.RefreshSession,
 
 	// Добавим tokenID в индекс (для DeleteAll)
 	indexKey := buildIndexKey(s.UserID)
-	compound := fmt.Sprintf("%s:%s", s.TokenID, s.TokenHash)
-	return c.redis.SAdd(ctx, indexKey, compound).Err()
+	compound := buildCompound(s)
+	if err = c.redis.SAdd(ctx, indexKey, compound).Err(); err != nil {
+		return err
+	}
+
+	if s.FamilyID == "" {
+		return nil
+	}
+
+	familyKey := buildFamilyKey(s.FamilyID)
+	pipe := c.redis.TxPipeline()
+	pipe.SAdd(ctx, familyKey, compound)
+	pipe.Expire(ctx, familyKey, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
 }
 
 func (c *sessionCache) GetSession(ctx context.Context, tokenID string) (*cache.RefreshSession, error) {
 	key := buildSessionKey(tokenID)
 	data, err := c.redis.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, cache.ErrNotFound
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -51,14 +114,28 @@ func (c *sessionCache) GetSession(ctx context.Context, tokenID string) (*cache.R
 	return &session, nil
 }
 
+// DeleteSession В индексе и семействе хранится compound сессии, поэтому сначала читаем ее саму.
+// Если сессия уже истекла, ее запись в индексе уберет ListUserSessions
 func (c *sessionCache) DeleteSession(ctx context.Context, userID int64, tokenID string) error {
 	key := buildSessionKey(tokenID)
-	indexKey := buildIndexKey(userID)
 
+	session, err := c.GetSession(ctx, tokenID)
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	compound := buildCompound(session)
 	pipe := c.redis.TxPipeline()
 	pipe.Del(ctx, key)
-	pipe.SRem(ctx, indexKey, tokenID)
-	_, err := pipe.Exec(ctx)
+	pipe.SRem(ctx, buildIndexKey(userID), compound)
+	if session.FamilyID != "" {
+		pipe.SRem(ctx, buildFamilyKey(session.FamilyID), compound)
+	}
+	_, err = pipe.Exec(ctx)
 	return err
 }
 
@@ -90,9 +167,37 @@ func (c *sessionCache) DeleteAllUserSessions(ctx context.Context, userID int64)
 	return err
 }
 
+func (c *sessionCache) DeleteOtherUserSessions(ctx context.Context, userID int64, keepTokenID string) error {
+	indexKey := buildIndexKey(userID)
+
+	tokenIDs, err := c.redis.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return err
+	}
+
+	pipe := c.redis.TxPipeline()
+	for _, compound := range tokenIDs {
+		parts := strings.SplitN(compound, ":", 2)
+		if len(parts) != 2 || parts[0] == keepTokenID {
+			continue
+		}
+
+		pipe.Del(ctx, buildSessionKey(parts[0]))
+		pipe.Del(ctx, buildRefreshKey(parts[1]))
+		pipe.SRem(ctx, indexKey, compound)
+	}
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
 // GetRefreshTokenId Через хэшированный refresh token получаю tokenId чтобы потом искать по ИД ключу в списке
 func (c *sessionCache) GetRefreshTokenId(ctx context.Context, hashedRefreshToken string) (string, error) {
 	data, err := c.redis.Get(ctx, buildRefreshKey(hashedRefreshToken)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", cache.ErrNotFound
+	}
+
 	return data, err
 }
 
@@ -104,9 +209,127 @@ func (c *sessionCache) DeleteRefreshTokenId(ctx context.Context, hashedRefreshTo
 	return c.redis.Del(ctx, buildRefreshKey(hashedRefreshToken)).Err()
 }
 
-// Формат ключа: auth:refresh:<userID>:<tokenID>
+func (c *sessionCache) RotateSession(ctx context.Context, oldSess, newSess *cache.RefreshSession, ttl time.Duration) error {
+	newData, err := json.Marshal(newSess)
+	if err != nil {
+		return err
+	}
+
+	rotated, err := json.Marshal(cache.RotatedRefreshToken{
+		UserID:    oldSess.UserID,
+		FamilyID:  newSess.FamilyID,
+		RotatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := []string{
+		buildRefreshKey(oldSess.TokenHash),
+		buildSessionKey(oldSess.TokenID),
+		buildRotatedKey(oldSess.TokenHash),
+		buildIndexKey(oldSess.UserID),
+		buildFamilyKey(newSess.FamilyID),
+		buildRefreshKey(newSess.TokenHash),
+		buildSessionKey(newSess.TokenID),
+	}
+
+	res, err := rotateSessionScript.Run(ctx, c.redis, keys,
+		oldSess.TokenID,
+		buildCompound(oldSess),
+		rotated,
+		newSess.TokenID,
+		newData,
+		buildCompound(newSess),
+		ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return err
+	}
+
+	if res == 0 {
+		return cache.ErrRefreshTokenConsumed
+	}
+
+	return nil
+}
+
+func (c *sessionCache) GetRotatedRefreshToken(ctx context.Context, hashedRefreshToken string) (*cache.RotatedRefreshToken, error) {
+	data, err := c.redis.Get(ctx, buildRotatedKey(hashedRefreshToken)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, cache.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rotated cache.RotatedRefreshToken
+	if err = json.Unmarshal([]byte(data), &rotated); err != nil {
+		return nil, err
+	}
+
+	return &rotated, nil
+}
+
+func (c *sessionCache) RevokeFamily(ctx context.Context, userID int64, familyID string) error {
+	keys := []string{buildFamilyKey(familyID), buildIndexKey(userID)}
+	return revokeFamilyScript.Run(ctx, c.redis, keys, sessionKeyPrefix, refreshHashKeyPrefix).Err()
+}
+
+func (c *sessionCache) ListUserSessions(ctx context.Context, userID int64) ([]*cache.RefreshSession, error) {
+	indexKey := buildIndexKey(userID)
+
+	compounds, err := c.redis.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(compounds) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(compounds))
+	for _, compound := range compounds {
+		tokenID, _, _ := strings.Cut(compound, ":")
+		keys = append(keys, buildSessionKey(tokenID))
+	}
+
+	values, err := c.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*cache.RefreshSession, 0, len(values))
+	var stale []any
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// Сессия истекла по TTL, а запись в индексе осталась
+			stale = append(stale, compounds[i])
+			continue
+		}
+
+		var session cache.RefreshSession
+		if err = json.Unmarshal([]byte(data), &session); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	if len(stale) > 0 {
+		if err = c.redis.SRem(ctx, indexKey, stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sessions, nil
+}
+
+// Формат ключа: auth:refresh:<tokenID>
 func buildSessionKey(tokenID string) string {
-	return fmt.Sprintf("auth:refresh:%s", tokenID)
+	return sessionKeyPrefix + tokenID
 }
 
 // Формат index ключа: auth:refresh:index:<userID>
@@ -115,5 +338,20 @@ func buildIndexKey(userID int64) string {
 }
 
 func buildRefreshKey(hashRefreshToken string) string {
-	return fmt.Sprintf("auth:refresh_hash:%s", hashRefreshToken)
+	return refreshHashKeyPrefix + hashRefreshToken
+}
+
+// Формат ключа: auth:refresh_used:<hash> - хранит семейство уже ротированного токена
+func buildRotatedKey(hashRefreshToken string) string {
+	return fmt.Sprintf("auth:refresh_used:%s", hashRefreshToken)
+}
+
+// Формат ключа: auth:refresh:family:<familyID> - живые сессии одной цепочки ротаций
+func buildFamilyKey(familyID string) string {
+	return fmt.Sprintf("auth:refresh:family:%s", familyID)
+}
+
+// Формат элемента индекса: <tokenID>:<tokenHash>
+func buildCompound(s *cache.RefreshSession) string {
+	return fmt.Sprintf("%s:%s", s.TokenID, s.TokenHash)
 }
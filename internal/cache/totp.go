@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+type totpStepCache struct {
+	redis *redis.Client
+}
+
+func NewTOTPStepRedis(redis *redis.Client) cache.TOTPStepCache {
+	return &totpStepCache{redis: redis}
+}
+
+func (c *totpStepCache) MarkUsed(ctx context.Context, userID, step int64, ttl time.Duration) (bool, error) {
+	return c.redis.SetNX(ctx, fmt.Sprintf("auth:mfa_used:%d:%d", userID, step), 1, ttl).Result()
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"github.com/caarlos0/env/v10"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +16,110 @@ type Config struct {
 	Logger     Logger     `yaml:"logger"`
 	Redis      Redis      `yaml:"redis"`
 	JWT        JWTConfig  `yaml:"jwt"`
+	Auth       Auth       `yaml:"auth"`
+	Mail       Mail       `yaml:"mail"`
+	MFA        MFA        `yaml:"mfa"`
+	// LoginThrottle Защита логина от перебора паролей
+	LoginThrottle LoginThrottle `yaml:"login_throttle"`
+	RateLimit     RateLimit     `yaml:"rate_limit"`
+	Audit         Audit         `yaml:"audit"`
+	OAuth         OAuth         `yaml:"oauth"`
+	Account       Account       `yaml:"account"`
+	// PasswordPolicy Проверка нового пароля при регистрации, сбросе и смене
+	PasswordPolicy PasswordPolicy `yaml:"password_policy"`
+}
+
+type Auth struct {
+	RequireVerifiedEmail bool          `yaml:"require_verified_email"` // без подтвержденного email логин запрещен
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl"` // по умолчанию 24h
+	VerifyEmailURL       string        `yaml:"verify_email_url"`       // ссылка в письме, токен добавляется параметром token
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl"`     // по умолчанию 30m
+	PasswordResetURL     string        `yaml:"password_reset_url"`
+	// SessionBinding Привязка refresh-сессии к клиенту: strict (по умолчанию), subnet, ua_family или off
+	SessionBinding string `yaml:"session_binding"`
+	// SessionStepUp При смене отпечатка вместо отказа предложить подтвердить сессию паролем через /refresh/step-up
+	SessionStepUp bool `yaml:"session_step_up"`
+}
+
+type MFA struct {
+	Issuer        string        `yaml:"issuer"`                     // название сервиса в приложении-аутентификаторе
+	EncryptionKey string        `yaml:"-" env:"MFA_ENCRYPTION_KEY"` // base64, 32 байта. Без ключа MFA не включить
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl"`              // время на ввод кода при логине, по умолчанию 5m
+}
+
+// LoginThrottle Нулевые значения заменяются значениями по умолчанию
+type LoginThrottle struct {
+	Window              time.Duration `yaml:"window"`                 // окно подсчета неудачных попыток, по умолчанию 15m
+	MaxAttemptsPerEmail int           `yaml:"max_attempts_per_email"` // по умолчанию 5
+	MaxAttemptsPerIP    int           `yaml:"max_attempts_per_ip"`    // по умолчанию 50
+	LockoutBase         time.Duration `yaml:"lockout_base"`           // первая блокировка, каждая следующая вдвое дольше. По умолчанию 1m
+	LockoutMax          time.Duration `yaml:"lockout_max"`            // по умолчанию 1h
+}
+
+type RateLimit struct {
+	Store    string                     `yaml:"store"`    // redis (по умолчанию) или memory для одного инстанса
+	Policies map[string]RateLimitPolicy `yaml:"policies"` // переопределяет политики по имени: register, login, refresh, password, auth, admin
+}
+
+// RateLimitPolicy Rate запросов за Period, Burst - сколько можно сделать подряд
+type RateLimitPolicy struct {
+	Rate   int           `yaml:"rate"`
+	Period time.Duration `yaml:"period"`
+	Burst  int           `yaml:"burst"`
+}
+
+// Audit Журнал событий пишется в таблицу logs пакетами в фоне
+type Audit struct {
+	BufferSize    int           `yaml:"buffer_size"`    // сколько событий ждут записи, при переполнении новые теряются. По умолчанию 1024
+	BatchSize     int           `yaml:"batch_size"`     // по умолчанию 100
+	FlushInterval time.Duration `yaml:"flush_interval"` // неполный пакет пишется не реже, по умолчанию 1s
+}
+
+// OAuth Вход через OpenID-провайдеров. Ключ Providers - имя провайдера в пути /oauth/{provider}
+type OAuth struct {
+	StateTTL  time.Duration            `yaml:"state_ttl"` // время на вход у провайдера, по умолчанию 10m
+	Providers map[string]OAuthProvider `yaml:"providers"`
+}
+
+// Account Удаление аккаунта самим пользователем
+type Account struct {
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period"` // сколько удаленный аккаунт хранится до очистки, по умолчанию 720h (30 дней)
+	PurgeInterval       time.Duration `yaml:"purge_interval"`        // как часто искать аккаунты для очистки, по умолчанию 1h
+}
+
+// PasswordPolicy Длина считается в символах, нулевые значения заменяются значениями по умолчанию
+type PasswordPolicy struct {
+	MinLength        int  `yaml:"min_length"` // по умолчанию 8
+	MaxLength        int  `yaml:"max_length"` // по умолчанию 128, ограничивает работу хеширования на длинных паролях
+	RequireLowercase bool `yaml:"require_lowercase"`
+	RequireUppercase bool `yaml:"require_uppercase"`
+	RequireDigit     bool `yaml:"require_digit"`
+	RequireSymbol    bool `yaml:"require_symbol"`
+	// BreachedListPath Файл с SHA-1 утекших паролей (формат Have I Been Pwned). Если пусто, утечки не проверяются
+	BreachedListPath string `yaml:"breached_list_path"`
+}
+
+// OAuthProvider Эндпоинты берутся из discovery по issuer, если не заданы явно.
+// Секрет задается переменной OAUTH_<ИМЯ>_CLIENT_SECRET, например OAUTH_GOOGLE_CLIENT_SECRET
+type OAuthProvider struct {
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"-"`
+	RedirectURL  string   `yaml:"redirect_url"` // страница клиента, которая передает code и state в /oauth/{provider}/callback
+	Scopes       []string `yaml:"scopes"`       // по умолчанию openid, email, profile
+	AuthURL      string   `yaml:"auth_url"`
+	TokenURL     string   `yaml:"token_url"`
+	JWKSURL      string   `yaml:"jwks_url"`
+}
+
+type Mail struct {
+	Driver   string `yaml:"driver"` // smtp или file (по умолчанию)
+	From     string `yaml:"from"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" env:"MAIL_PASSWORD"`
+	FilePath string `yaml:"file_path"` // для file: если пусто, письма пишутся в лог
 }
 
 type Logger struct {
@@ -45,12 +150,28 @@ type Server struct {
 	Port         string        `yaml:"port"`
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// TrustedProxies CIDR или адреса прокси, которым можно верить в X-Forwarded-For и Forwarded
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type JWTConfig struct {
-	PrivateKeyPath string `env:"JWT_PRIVATE_KEY_PATH,required"`
-	PublicKeyPath  string `env:"JWT_PUBLIC_KEY_PATH,required"`
-	AccessTTL      string `yaml:"access_ttl"`
+	PrivateKeyPath string          `env:"JWT_PRIVATE_KEY_PATH,required"`
+	PublicKeyPath  string          `env:"JWT_PUBLIC_KEY_PATH,required"`
+	KeyID          string          `yaml:"key_id" env:"JWT_KEY_ID"`       // если пусто, kid вычисляется по публичному ключу
+	Algorithm      string          `yaml:"algorithm" env:"JWT_ALGORITHM"` // RS256 (по умолчанию), ES256 или EdDSA
+	AccessTTL      string          `yaml:"access_ttl"`
+	RetiredKeys    []JWTRetiredKey `yaml:"retired_keys"`
+	Issuer         string          `yaml:"issuer"`
+	Audience       []string        `yaml:"audience"`
+	Leeway         time.Duration   `yaml:"leeway"` // допустимое расхождение часов при проверке exp/nbf/iat
+}
+
+// JWTRetiredKey Старый ключ после ротации, нужен только для проверки еще живых токенов
+type JWTRetiredKey struct {
+	KeyID         string    `yaml:"key_id"`
+	Algorithm     string    `yaml:"algorithm"` // если пусто, совпадает с JWTConfig.Algorithm
+	PublicKeyPath string    `yaml:"public_key_path"`
+	RetiredAt     time.Time `yaml:"retired_at"`
 }
 
 func LoadConfig(path, envPath string) (*Config, error) {
@@ -73,6 +194,19 @@ func LoadConfig(path, envPath string) (*Config, error) {
 		return nil, err
 	}
 
+	if err = env.Parse(&cfg.Mail); err != nil {
+		return nil, err
+	}
+
+	if err = env.Parse(&cfg.MFA); err != nil {
+		return nil, err
+	}
+
+	for name, provider := range cfg.OAuth.Providers {
+		provider.ClientSecret = os.Getenv("OAUTH_" + strings.ToUpper(name) + "_CLIENT_SECRET")
+		cfg.OAuth.Providers[name] = provider
+	}
+
 	if err = validateCfg(&cfg); err != nil {
 		return nil, err
 	}
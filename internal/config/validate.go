@@ -1,12 +1,22 @@
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"log/slog"
+	"regexp"
 	"time"
 )
 
+// oauthProviderName Имя идет в путь и в имя переменной окружения с секретом
+var oauthProviderName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// maxPasswordLength Верхняя граница для password_policy.max_length: хеширование очень длинных паролей нагружает CPU
+const maxPasswordLength = 1024
+
 func validateCfg(cfg *Config) error {
 	if err := validateServer(cfg); err != nil {
 		return err
@@ -28,6 +38,38 @@ func validateCfg(cfg *Config) error {
 		return err
 	}
 
+	if err := validateAuth(cfg); err != nil {
+		return err
+	}
+
+	if err := validateMail(cfg); err != nil {
+		return err
+	}
+
+	if err := validateMFA(cfg); err != nil {
+		return err
+	}
+
+	if err := validateLoginThrottle(cfg); err != nil {
+		return err
+	}
+
+	if err := validateRateLimit(cfg); err != nil {
+		return err
+	}
+
+	if err := validateAudit(cfg); err != nil {
+		return err
+	}
+
+	if err := validateOAuth(cfg); err != nil {
+		return err
+	}
+
+	if err := validatePasswordPolicy(cfg); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -44,9 +86,41 @@ func validateJWT(cfg *Config) error {
 		return errors.New("public key file path is required")
 	}
 
+	if err := validateJWTAlgorithm(cfg.JWT.Algorithm); err != nil {
+		return err
+	}
+
+	if cfg.JWT.Leeway < 0 {
+		return errors.New("jwt leeway must not be negative")
+	}
+
+	for i, key := range cfg.JWT.RetiredKeys {
+		if err := validateJWTAlgorithm(key.Algorithm); err != nil {
+			return err
+		}
+
+		if key.PublicKeyPath == "" {
+			return fmt.Errorf("missing required configuration variable: jwt_retired_keys[%d].public_key_path", i)
+		}
+
+		if key.RetiredAt.IsZero() {
+			return fmt.Errorf("missing required configuration variable: jwt_retired_keys[%d].retired_at", i)
+		}
+	}
+
 	return nil
 }
 
+// validateJWTAlgorithm Пустое значение означает RS256
+func validateJWTAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", "RS256", "ES256", "EdDSA":
+		return nil
+	default:
+		return fmt.Errorf("unsupported jwt algorithm: %s", algorithm)
+	}
+}
+
 func validateLogger(cfg *Config) error {
 	if err := validateLogLevel(cfg.Logger.Level); err != nil {
 		return err
@@ -84,6 +158,12 @@ func validateServer(cfg *Config) error {
 		return errors.New("missing required configuration variable: server_write_timeout")
 	}
 
+	for _, proxy := range cfg.Server.TrustedProxies {
+		if _, err := req.ParseTrustedProxy(proxy); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -131,6 +211,132 @@ func validateRedis(cfg *Config) error {
 	return nil
 }
 
+func validateAuth(cfg *Config) error {
+	if cfg.Auth.EmailVerificationTTL < 0 {
+		return errors.New("auth email_verification_ttl must not be negative")
+	}
+
+	if cfg.Auth.PasswordResetTTL < 0 {
+		return errors.New("auth password_reset_ttl must not be negative")
+	}
+
+	switch cfg.Auth.SessionBinding {
+	case "", "strict", "subnet", "ua_family", "off":
+	default:
+		return fmt.Errorf("invalid auth session_binding: %s", cfg.Auth.SessionBinding)
+	}
+
+	return nil
+}
+
+func validateMail(cfg *Config) error {
+	switch cfg.Mail.Driver {
+	case "", "file":
+		return nil
+	case "smtp":
+		if cfg.Mail.Host == "" {
+			return errors.New("missing required configuration variable: mail_host")
+		}
+
+		if cfg.Mail.Port == 0 {
+			return errors.New("missing required configuration variable: mail_port")
+		}
+
+		if cfg.Mail.From == "" {
+			return errors.New("missing required configuration variable: mail_from")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported mail driver: %s", cfg.Mail.Driver)
+	}
+}
+
+func validateMFA(cfg *Config) error {
+	if cfg.MFA.ChallengeTTL < 0 {
+		return errors.New("mfa challenge_ttl must not be negative")
+	}
+
+	if cfg.MFA.EncryptionKey == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.MFA.EncryptionKey)
+	if err != nil {
+		return fmt.Errorf("invalid mfa encryption key: %w", err)
+	}
+
+	if len(key) != 32 {
+		return errors.New("mfa encryption key must be 32 bytes")
+	}
+
+	return nil
+}
+
+func validateLoginThrottle(cfg *Config) error {
+	throttle := cfg.LoginThrottle
+	if throttle.Window < 0 || throttle.LockoutBase < 0 || throttle.LockoutMax < 0 {
+		return errors.New("login_throttle durations must not be negative")
+	}
+
+	if throttle.MaxAttemptsPerEmail < 0 || throttle.MaxAttemptsPerIP < 0 {
+		return errors.New("login_throttle attempts must not be negative")
+	}
+
+	if throttle.LockoutMax != 0 && throttle.LockoutBase > throttle.LockoutMax {
+		return errors.New("login_throttle lockout_base must not exceed lockout_max")
+	}
+
+	return nil
+}
+
+func validateRateLimit(cfg *Config) error {
+	switch cfg.RateLimit.Store {
+	case "", "redis", "memory":
+	default:
+		return fmt.Errorf("invalid rate_limit store: %s", cfg.RateLimit.Store)
+	}
+
+	for name, policy := range cfg.RateLimit.Policies {
+		if policy.Rate <= 0 || policy.Period <= 0 || policy.Burst <= 0 {
+			return fmt.Errorf("rate_limit policy %s: rate, period and burst must be positive", name)
+		}
+	}
+
+	return nil
+}
+
+func validateAudit(cfg *Config) error {
+	if cfg.Audit.BufferSize < 0 || cfg.Audit.BatchSize < 0 || cfg.Audit.FlushInterval < 0 {
+		return errors.New("audit settings must not be negative")
+	}
+
+	// В одном INSERT не больше 65535 параметров, на событие их 7
+	if cfg.Audit.BatchSize > 5000 {
+		return errors.New("audit batch_size must not exceed 5000")
+	}
+
+	return nil
+}
+
+func validateOAuth(cfg *Config) error {
+	if cfg.OAuth.StateTTL < 0 {
+		return errors.New("oauth state_ttl must not be negative")
+	}
+
+	for name, provider := range cfg.OAuth.Providers {
+		if !oauthProviderName.MatchString(name) {
+			return fmt.Errorf("invalid oauth provider name: %s", name)
+		}
+
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return fmt.Errorf("oauth provider %s: issuer, client_id and redirect_url are required", name)
+		}
+	}
+
+	return nil
+}
+
 func validateJWTAccessTTL(cfg *Config) error {
 	if cfg.JWT.AccessTTL == "" {
 		return errors.New("missing required configuration variable: jwt_access_ttl")
@@ -143,3 +349,25 @@ func validateJWTAccessTTL(cfg *Config) error {
 
 	return nil
 }
+
+func validatePasswordPolicy(cfg *Config) error {
+	policy := cfg.PasswordPolicy
+	if policy.MinLength < 0 || policy.MaxLength < 0 {
+		return errors.New("password_policy lengths must not be negative")
+	}
+
+	maxLength := policy.MaxLength
+	if maxLength == 0 {
+		maxLength = validator.DefaultMaxPasswordLength
+	}
+
+	if maxLength > maxPasswordLength {
+		return fmt.Errorf("password_policy max_length must not exceed %d", maxPasswordLength)
+	}
+
+	if policy.MinLength > maxLength {
+		return errors.New("password_policy min_length must not exceed max_length")
+	}
+
+	return nil
+}
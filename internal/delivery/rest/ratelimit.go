@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"time"
+)
+
+// DefaultRateLimits Строгие лимиты на публичные ручки, мягкие - на ручки под авторизацией.
+// Ключ - имя политики, его же можно переопределить в секции rate_limit.policies
+var DefaultRateLimits = map[string]model.RateLimit{
+	"register": {Rate: 5, Period: time.Hour, Burst: 5},
+	"login":    {Rate: 20, Period: time.Minute, Burst: 10},
+	"refresh":  {Rate: 30, Period: time.Minute, Burst: 10},
+	"password": {Rate: 5, Period: 15 * time.Minute, Burst: 5},
+	"auth":     {Rate: 120, Period: time.Minute, Burst: 60},
+	"admin":    {Rate: 300, Period: time.Minute, Burst: 100},
+	"oauth":    {Rate: 600, Period: time.Minute, Burst: 200},
+}
+
+// RateLimits Значения по умолчанию с переопределениями из конфига
+func RateLimits(cfg *config.RateLimit) map[string]model.RateLimit {
+	limits := make(map[string]model.RateLimit, len(DefaultRateLimits))
+	for name, limit := range DefaultRateLimits {
+		limits[name] = limit
+	}
+
+	for name, policy := range cfg.Policies {
+		limits[name] = model.RateLimit{Rate: policy.Rate, Period: policy.Period, Burst: policy.Burst}
+	}
+
+	return limits
+}
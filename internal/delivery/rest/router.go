@@ -2,36 +2,155 @@ package rest
 
 import (
 	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
 	"github.com/Elaman1/full-project-mock/internal/middleware"
 	"github.com/Elaman1/full-project-mock/internal/module"
+	"github.com/Elaman1/full-project-mock/pkg/req"
 	"github.com/go-chi/chi/v5"
 	"log/slog"
+	"net/http"
 )
 
 func InitRouter(ctx context.Context, routeApp *RouteApp, allModules *module.Modules) *chi.Mux {
 	r := chi.NewRouter()
 
+	r.Use(middleware.ClientIP(routeApp.ClientIPResolver))
 	r.Use(middleware.LogMiddleware(routeApp.Logs))
 	r.Use(middleware.ContextJoinMiddleware(ctx))
 
-	r.Post("/register", allModules.UserHandler.RegisterHandler)
-	r.Post("/login", allModules.UserHandler.LoginHandler)
-	r.Post("/refresh", allModules.UserHandler.RefreshHandler)
+	r.With(rateLimit(routeApp, "register", middleware.KeyByIP)).Post("/register", allModules.UserHandler.RegisterHandler)
+
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimit(routeApp, "login", middleware.KeyByIP))
+
+		r.Post("/login", allModules.UserHandler.LoginHandler)
+		r.Post("/login/mfa", allModules.UserHandler.LoginMFAHandler)
+		r.Get("/oauth/{provider}/authorize", allModules.OAuthHandler.AuthorizeHandler)
+		r.Post("/oauth/{provider}/callback", allModules.OAuthHandler.CallbackHandler)
+	})
+
+	// Ручки для сервисов: шлюз проверяет токены часто и с одного адреса, поэтому своя политика
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimit(routeApp, "oauth", middleware.KeyByIP))
+
+		r.Post("/oauth/token", allModules.OAuthServerHandler.TokenHandler)
+		r.Post("/oauth/introspect", allModules.OAuthServerHandler.IntrospectHandler)
+		r.Post("/oauth/revoke", allModules.OAuthServerHandler.RevokeHandler)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimit(routeApp, "refresh", middleware.KeyByIP))
+
+		r.Post("/refresh", allModules.UserHandler.RefreshHandler)
+		r.Post("/refresh/step-up", allModules.UserHandler.RefreshStepUpHandler)
+	})
+
+	// Ручки, которые отправляют письма или принимают токены из писем
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimit(routeApp, "password", middleware.KeyByIP))
+
+		r.Post("/verify-email", allModules.UserHandler.VerifyEmailHandler)
+		r.Post("/verify-email/resend", allModules.UserHandler.ResendVerificationHandler)
+		r.Post("/password/forgot", allModules.UserHandler.ForgotPasswordHandler)
+		r.Post("/password/reset", allModules.UserHandler.ResetPasswordHandler)
+	})
+
+	r.Get("/.well-known/jwks.json", allModules.JWKSHandler.KeysHandler)
+
+	// Ручки для сервисов с токеном client_credentials, доступ по scope токена. API-ключи здесь не принимаются
+	r.Route("/service", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(routeApp.TokenService, routeApp.AccessDenylist, routeApp.Permissions, nil))
+		r.Use(rateLimit(routeApp, "oauth", middleware.KeyByIP))
+
+		r.With(middleware.RequireScope(constants.ScopeUsersRead)).Get("/users/{id}", allModules.AdminHandler.GetUserHandler)
+	})
 
 	// auth group
 	r.Route("/auth", func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(routeApp.TokenService))
+		r.Use(middleware.AuthMiddleware(routeApp.TokenService, routeApp.AccessDenylist, routeApp.Permissions, routeApp.APIKeys))
+		r.Use(middleware.RequireUser)
+		r.Use(rateLimit(routeApp, "auth", middleware.KeyByAPIKey(middleware.KeyByUserID)))
 
 		r.Get("/me", allModules.UserHandler.MeHandler)
-		r.Post("/logout", allModules.UserHandler.LogoutHandler)
-		r.Post("/logout_all", allModules.UserHandler.LogoutAllHandler)
+		r.Get("/activity", allModules.AuditHandler.ActivityHandler)
+
+		// По API-ключу нельзя менять профиль и аккаунт, управлять входом и самими ключами
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+
+			r.Patch("/me", allModules.UserHandler.UpdateMeHandler)
+			r.Delete("/me", allModules.AccountHandler.DeleteHandler)
+			r.Post("/me/export", allModules.AccountHandler.ExportHandler)
+			r.Post("/logout", allModules.UserHandler.LogoutHandler)
+			r.Post("/logout_all", allModules.UserHandler.LogoutAllHandler)
+			r.Post("/password", allModules.UserHandler.ChangePasswordHandler)
+			r.Get("/sessions", allModules.UserHandler.SessionsHandler)
+			r.Delete("/sessions/{id}", allModules.UserHandler.RevokeSessionHandler)
+
+			r.Route("/mfa", func(r chi.Router) {
+				r.Post("/enroll", allModules.MFAHandler.EnrollHandler)
+				r.Post("/confirm", allModules.MFAHandler.ConfirmHandler)
+				r.Post("/disable", allModules.MFAHandler.DisableHandler)
+				r.Post("/recovery-codes", allModules.MFAHandler.RecoveryCodesHandler)
+			})
+
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Post("/", allModules.APIKeyHandler.CreateHandler)
+				r.Get("/", allModules.APIKeyHandler.ListHandler)
+				r.Delete("/{id}", allModules.APIKeyHandler.RevokeHandler)
+			})
+		})
+	})
+
+	// admin group
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(routeApp.TokenService, routeApp.AccessDenylist, routeApp.Permissions, routeApp.APIKeys))
+		r.Use(middleware.RequireUser)
+		r.Use(rateLimit(routeApp, "admin", middleware.KeyByAPIKey(middleware.KeyByUserID)))
+
+		r.Route("/users", func(r chi.Router) {
+			r.With(middleware.RequirePermission(constants.PermissionUsersRead)).Get("/", allModules.AdminHandler.ListUsersHandler)
+			r.With(middleware.RequirePermission(constants.PermissionUsersRead)).Get("/{id}", allModules.AdminHandler.GetUserHandler)
+			r.With(middleware.RequirePermission(constants.PermissionUsersManage)).Post("/{id}/block", allModules.AdminHandler.BlockUserHandler)
+			r.With(middleware.RequirePermission(constants.PermissionUsersManage)).Post("/{id}/unblock", allModules.AdminHandler.UnblockUserHandler)
+			r.With(middleware.RequirePermission(constants.PermissionUsersManage)).Patch("/{id}/role", allModules.AdminHandler.ChangeRoleHandler)
+			r.With(middleware.RequirePermission(constants.PermissionUsersManage)).Post("/{id}/unlock", allModules.AdminHandler.UnlockLoginHandler)
+		})
+
+		r.Route("/audit", func(r chi.Router) {
+			r.Use(middleware.RequirePermission(constants.PermissionAuditRead))
+
+			r.Get("/", allModules.AuditHandler.ListHandler)
+			r.Get("/export", allModules.AuditHandler.ExportHandler)
+		})
 	})
 
 	return r
 }
 
+// rateLimit Без лимитера или без политики с таким именем запросы не ограничиваются
+func rateLimit(routeApp *RouteApp, name string, key middleware.RateLimitKeyFunc) func(http.Handler) http.Handler {
+	limit, ok := routeApp.RateLimits[name]
+	if routeApp.RateLimiter == nil || !ok {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return middleware.RateLimit(routeApp.RateLimiter, middleware.RateLimitPolicy{Name: name, Limit: limit, Key: key})
+}
+
 type RouteApp struct {
-	Logs         *slog.Logger
-	TokenService usecase.TokenService
+	Logs           *slog.Logger
+	TokenService   usecase.TokenService
+	AccessDenylist cache.AccessTokenDenylist
+	Permissions    repository.PermissionRepository
+	RateLimiter    cache.RateLimiter
+	RateLimits     map[string]model.RateLimit
+	// ClientIPResolver IP клиента с учетом доверенных прокси
+	ClientIPResolver *req.ClientIPResolver
+	// APIKeys Проверка персональных ключей в AuthMiddleware, nil - ключи не принимаются
+	APIKeys usecase.APIKeyUsecase
 }
@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/internal/module"
+	"github.com/Elaman1/full-project-mock/internal/module/account"
+	"github.com/Elaman1/full-project-mock/internal/module/admin"
+	"github.com/Elaman1/full-project-mock/internal/module/apikey"
+	auditmodule "github.com/Elaman1/full-project-mock/internal/module/audit"
+	"github.com/Elaman1/full-project-mock/internal/module/jwks"
+	"github.com/Elaman1/full-project-mock/internal/module/mfa"
+	"github.com/Elaman1/full-project-mock/internal/module/oauth"
+	"github.com/Elaman1/full-project-mock/internal/module/oauthserver"
+	"github.com/Elaman1/full-project-mock/internal/module/user"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stubAdminUsecase Для /service/users нужен только GetUser
+type stubAdminUsecase struct {
+	usecase.AdminUserUsecase
+}
+
+func (stubAdminUsecase) GetUser(_ context.Context, id int64) (*model.User, error) {
+	return &model.User{ID: id, Email: "user@example.com", Username: "user"}, nil
+}
+
+func TestServiceRoutes(t *testing.T) {
+	expiresAt := jwt.NewNumericDate(time.Now().Add(10 * time.Minute))
+	clientClaims := func(scope string) model.AccessClaims {
+		return model.AccessClaims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: "client-jti", Subject: "billing", ExpiresAt: expiresAt},
+			ClientID:         "billing",
+			Scope:            scope,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		claims         model.AccessClaims
+		expectedStatus int
+	}{
+		{
+			name:           "client with scope",
+			token:          "client-token",
+			claims:         clientClaims(constants.ScopeUsersRead),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "client without scope",
+			token:          "client-token",
+			claims:         clientClaims("audit:read"),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			// Право users:read у роли не открывает ручки сервисов
+			name:  "user token",
+			token: "user-token",
+			claims: model.AccessClaims{
+				RegisteredClaims: jwt.RegisteredClaims{ID: "user-jti", Subject: "1", ExpiresAt: expiresAt},
+				Role:             "admin",
+				SessionID:        "session-id",
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	resolver, err := req.NewClientIPResolver(nil)
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenService := new(mocks.MockTokenService)
+			denylist := new(mocks.MockAccessTokenDenylist)
+			permissions := new(mocks.MockPermissionRepository)
+			if tt.token != "" {
+				tokenService.On("ParseToken", tt.token).Return(tt.claims, nil)
+				denylist.On("IsRevoked", mock.Anything, tt.claims.ID, tt.claims.SessionID, mock.Anything, mock.Anything).Return(false, nil)
+			}
+			permissions.On("GetRolePermissions", mock.Anything, "admin").Return([]string{constants.PermissionUsersRead}, nil).Maybe()
+
+			router := InitRouter(context.Background(), &RouteApp{
+				Logs:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+				TokenService:     tokenService,
+				AccessDenylist:   denylist,
+				Permissions:      permissions,
+				ClientIPResolver: resolver,
+			}, testModules())
+
+			request := httptest.NewRequest(http.MethodGet, "/service/users/42", nil)
+			if tt.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"id":42`)
+			}
+			tokenService.AssertExpectations(t)
+			denylist.AssertExpectations(t)
+		})
+	}
+}
+
+// testModules Роутеру нужны только методы обработчиков, зависимости есть только у админки
+func testModules() *module.Modules {
+	return &module.Modules{
+		UserHandler:        &user.UserHandler{},
+		JWKSHandler:        &jwks.JWKSHandler{},
+		AdminHandler:       admin.NewAdminHandler(stubAdminUsecase{}),
+		MFAHandler:         &mfa.MFAHandler{},
+		AuditHandler:       &auditmodule.AuditHandler{},
+		OAuthHandler:       &oauth.OAuthHandler{},
+		OAuthServerHandler: &oauthserver.OAuthServerHandler{},
+		APIKeyHandler:      &apikey.APIKeyHandler{},
+		AccountHandler:     &account.AccountHandler{},
+	}
+}
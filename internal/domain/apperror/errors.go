@@ -3,7 +3,36 @@ package apperror
 import "errors"
 
 var (
-	ExistsEmailErr = errors.New("email already exists")
+	ExistsEmailErr           = errors.New("email already exists")
+	RefreshTokenReusedErr    = errors.New("refresh token reuse detected")
+	UserBlockedErr           = errors.New("user is blocked")
+	UserNotFoundErr          = errors.New("user not found")
+	RoleNotFoundErr          = errors.New("role not found")
+	SelfActionErr            = errors.New("action is not allowed on own account")
+	EmailNotVerifiedErr      = errors.New("email is not verified")
+	InvalidTokenErr          = errors.New("invalid or expired token")
+	WrongPasswordErr         = errors.New("current password is incorrect")
+	InvalidMFACodeErr        = errors.New("invalid mfa code")
+	MFANotEnabledErr         = errors.New("mfa is not enabled")
+	MFAAlreadyEnabledErr     = errors.New("mfa is already enabled")
+	MFANotEnrolledErr        = errors.New("mfa enrollment is not started")
+	MFANotConfiguredErr      = errors.New("mfa is not configured")
+	SessionNotFoundErr       = errors.New("session not found")
+	StepUpRequiredErr        = errors.New("session fingerprint changed, confirm with password")
+	StepUpNotEnabledErr      = errors.New("session step-up is disabled")
+	OAuthProviderNotFoundErr = errors.New("oauth provider not found")
+	OAuthEmailRequiredErr    = errors.New("oauth provider did not return email")
+	IdentityNotFoundErr      = errors.New("identity not found")
+	OAuthClientNotFoundErr   = errors.New("oauth client not found")
+	InvalidClientErr         = errors.New("invalid client credentials")
+	InvalidScopeErr          = errors.New("requested scope is not allowed")
+	UnauthorizedClientErr    = errors.New("client is not allowed to use this endpoint")
+	APIKeyNotFoundErr        = errors.New("api key not found")
+	InvalidAPIKeyErr         = errors.New("invalid or expired api key")
+	ScopeNotGrantedErr       = errors.New("scope is not granted to user role")
+	ExistsUsernameErr        = errors.New("username already exists")
+	VersionConflictErr       = errors.New("profile was modified, reload and retry")
+	PasswordRequiredErr      = errors.New("current password is required")
 )
 
 // Для списка ошибок в internal
@@ -0,0 +1,32 @@
+package apperror
+
+import (
+	"errors"
+	"time"
+)
+
+var TooManyLoginAttemptsErr = errors.New("too many login attempts")
+
+// RetryAfterError Ошибка с временем, через которое можно повторить запрос (заголовок Retry-After)
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter Время из RetryAfterError в цепочке err, 0 - если его нет
+func RetryAfter(err error) time.Duration {
+	var retryErr *RetryAfterError
+	if !errors.As(err, &retryErr) {
+		return 0
+	}
+
+	return retryErr.RetryAfter
+}
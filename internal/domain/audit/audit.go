@@ -0,0 +1,12 @@
+package audit
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+// AuditLogger Журнал событий безопасности. Log не блокирует запрос и не возвращает ошибку:
+// сбой записи журнала не должен ломать логин
+type AuditLogger interface {
+	Log(ctx context.Context, event model.AuditEvent)
+}
@@ -0,0 +1,17 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// AccessTokenDenylist Отозванные access-токены. Запись живет не дольше самого токена
+type AccessTokenDenylist interface {
+	// RevokeToken Отзывает один токен по jti до момента его истечения
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeAllUserTokens Отзывает все токены пользователя, выпущенные до текущего момента
+	RevokeAllUserTokens(ctx context.Context, userID int64) error
+	// RevokeSession Отзывает токены сессии sessionID (claim sid) из всех ротаций refresh-токена
+	RevokeSession(ctx context.Context, sessionID string) error
+	IsRevoked(ctx context.Context, jti, sessionID string, userID int64, issuedAt time.Time) (bool, error)
+}
@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// LoginThrottle Счетчики неудачных входов в скользящем окне по email и по IP.
+// При превышении порога email или IP блокируется, каждая следующая блокировка дольше предыдущей
+type LoginThrottle interface {
+	// Check Сколько еще ждать, если email или IP заблокированы. 0 - вход разрешен
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	// RegisterFailure Учитывает неудачную попытку. Если она привела к блокировке, возвращает ее длительность
+	RegisterFailure(ctx context.Context, email, ip string) (time.Duration, error)
+	// Reset Успешный вход сбрасывает счетчик и уровень блокировки email
+	Reset(ctx context.Context, email string) error
+	// Unlock Снимает блокировку email, для администраторов
+	Unlock(ctx context.Context, email string) error
+}
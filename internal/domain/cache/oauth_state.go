@@ -0,0 +1,14 @@
+package cache
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"time"
+)
+
+// OAuthStateCache Одноразовые state для входа через провайдера
+type OAuthStateCache interface {
+	Save(ctx context.Context, hashedState string, state model.OAuthState, ttl time.Duration) error
+	// Consume Возвращает и сразу удаляет state. Неизвестный или истекший - ErrNotFound
+	Consume(ctx context.Context, hashedState string) (model.OAuthState, error)
+}
@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// OneTimeTokenCache Одноразовые токены (подтверждение email и т.п.), хранятся только хешами.
+// У пользователя живет один токен: новый токен гасит предыдущий
+type OneTimeTokenCache interface {
+	Save(ctx context.Context, userID int64, hashedToken string, ttl time.Duration) error
+	// Peek Владелец токена без удаления, чтобы проверить запрос до того, как погасить токен. Неизвестный или истекший токен - ErrNotFound
+	Peek(ctx context.Context, hashedToken string) (int64, error)
+	// Consume Возвращает владельца и сразу удаляет токен. Неизвестный или истекший токен - ErrNotFound
+	Consume(ctx context.Context, hashedToken string) (int64, error)
+}
@@ -0,0 +1,11 @@
+package cache
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+// RateLimiter Хранилище состояния лимитов. Каждый вызов Allow расходует один запрос, если он разрешен
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error)
+}
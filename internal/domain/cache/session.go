@@ -2,24 +2,52 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	ErrNotFound = errors.New("not found in cache")
+	// ErrRefreshTokenConsumed refresh токен уже был ротирован (в том числе параллельным запросом)
+	ErrRefreshTokenConsumed = errors.New("refresh token already consumed")
+)
+
 type SessionCache interface {
 	SaveSession(ctx context.Context, s *RefreshSession, ttl time.Duration) error
+	// GetSession Если сессии нет или она истекла - ErrNotFound
 	GetSession(ctx context.Context, tokenID string) (*RefreshSession, error)
 	DeleteSession(ctx context.Context, userID int64, tokenID string) error
 	DeleteAllUserSessions(ctx context.Context, userID int64) error
+	// DeleteOtherUserSessions Удаляет все сессии пользователя, кроме keepTokenID
+	DeleteOtherUserSessions(ctx context.Context, userID int64, keepTokenID string) error
 	GetRefreshTokenId(ctx context.Context, hashedRefreshToken string) (string, error)
 	SetRefreshTokenId(ctx context.Context, hashedRefreshToken string, refreshTokenID string, ttl time.Duration) error
 	DeleteRefreshTokenId(ctx context.Context, hashedRefreshToken string) error
+	// RotateSession Атомарно заменяет старую сессию новой. Если старый токен уже использован, вернет ErrRefreshTokenConsumed
+	RotateSession(ctx context.Context, oldSess, newSess *RefreshSession, ttl time.Duration) error
+	GetRotatedRefreshToken(ctx context.Context, hashedRefreshToken string) (*RotatedRefreshToken, error)
+	RevokeFamily(ctx context.Context, userID int64, familyID string) error
+	// ListUserSessions Живые сессии пользователя. Истекшие записи индекса удаляются попутно
+	ListUserSessions(ctx context.Context, userID int64) ([]*RefreshSession, error)
 }
 
 type RefreshSession struct {
 	UserID    int64     `json:"user_id"`              // кому принадлежит токен
 	TokenID   string    `json:"token_id"`             // уникальный ID (UUID)
 	TokenHash string    `json:"token_hash"`           // хеш самого refresh токена (для безопасности)
+	FamilyID  string    `json:"family_id,omitempty"`  // цепочка ротаций от одного логина
 	ExpiresAt time.Time `json:"expires_at"`           // когда истечёт
 	IP        string    `json:"ip,omitempty"`         // (опционально, по безопасности)
 	UserAgent string    `json:"user_agent,omitempty"` // (опционально, по безопасности)
+	// CreatedAt Время логина, при ротации переносится в новую сессию
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt Время последнего логина или refresh
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// RotatedRefreshToken След уже ротированного refresh токена, чтобы заметить его повторное использование
+type RotatedRefreshToken struct {
+	UserID    int64     `json:"user_id"`
+	FamilyID  string    `json:"family_id"`
+	RotatedAt time.Time `json:"rotated_at"`
 }
@@ -0,0 +1,12 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// TOTPStepCache Защита от повторного использования TOTP-кода в пределах его окна
+type TOTPStepCache interface {
+	// MarkUsed false, если код этого шага уже использован
+	MarkUsed(ctx context.Context, userID, step int64, ttl time.Duration) (bool, error)
+}
@@ -0,0 +1,4 @@
+package constants
+
+// APIKeyPrefix Префикс персональных API-ключей. По нему AuthMiddleware отличает ключ от JWT в заголовке Authorization
+const APIKeyPrefix = "sk_"
@@ -0,0 +1,34 @@
+package constants
+
+// Действия в журнале аудита (logs.action)
+const (
+	AuditRegister       = "register"
+	AuditLoginSuccess   = "login_success"
+	AuditLoginFailure   = "login_failure"
+	AuditRefresh        = "refresh"
+	AuditLogout         = "logout"
+	AuditLogoutAll      = "logout_all"
+	AuditPasswordChange = "password_change"
+	AuditPasswordReset  = "password_reset"
+	AuditSessionRevoke  = "session_revoke"
+	AuditProfileUpdate  = "profile_update"
+	AuditEmailChange    = "email_change"
+	AuditAccountExport  = "account_export"
+	AuditAccountDelete  = "account_delete"
+	AuditAccountPurge   = "account_purge"
+
+	// AuditSessionFingerprintMismatch refresh с другого IP или User-Agent, чем у сессии
+	AuditSessionFingerprintMismatch = "session_fingerprint_mismatch"
+
+	AuditClientToken       = "client_token"
+	AuditClientAuthFailure = "client_auth_failure"
+	AuditTokenRevoke       = "token_revoke"
+
+	AuditAPIKeyCreate = "api_key_create"
+	AuditAPIKeyRevoke = "api_key_revoke"
+
+	AuditAdminBlock      = "admin_block"
+	AuditAdminUnblock    = "admin_unblock"
+	AuditAdminChangeRole = "admin_change_role"
+	AuditAdminUnlock     = "admin_unlock_login"
+)
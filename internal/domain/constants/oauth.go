@@ -0,0 +1,17 @@
+package constants
+
+// ScopeTokensIntrospect Scope клиента (oauth_clients.scopes), без которого /oauth/introspect недоступен
+const ScopeTokensIntrospect = "tokens:introspect"
+
+// ScopeTokensRevoke Scope клиента, без которого через /oauth/revoke нельзя отозвать токены пользователей.
+// Свои токены клиент отзывает без него
+const ScopeTokensRevoke = "tokens:revoke"
+
+// ScopeUsersRead Scope клиента для чтения пользователей через /service/users
+const ScopeUsersRead = "users:read"
+
+// Значения token_type_hint (RFC 7009, 2.1). В ответе интроспекции ими же помечается тип токена
+const (
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+)
@@ -0,0 +1,8 @@
+package constants
+
+// Коды прав из таблицы permissions
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersManage = "users:manage"
+	PermissionAuditRead   = "audit:read"
+)
@@ -0,0 +1,11 @@
+package mailer
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+// Mailer Отправка писем. Реализации: SMTP для прода, файл/лог для локальной разработки и тестов
+type Mailer interface {
+	Send(ctx context.Context, msg model.MailMessage) error
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// AccountExport Все данные пользователя для выгрузки по его запросу (GDPR)
+type AccountExport struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	Profile    *User          `json:"profile"`
+	Sessions   []Session      `json:"sessions"`
+	Identities []UserIdentity `json:"identities"`
+	APIKeys    []*APIKey      `json:"api_keys"`
+	AuditLog   []AuditEvent   `json:"audit_log"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// APIKey Персональный ключ пользователя для скриптов. Сам ключ показывается один раз, хранится только хеш
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Начало ключа, чтобы пользователь узнал его в списке
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyOwner Ключ вместе с данными владельца, нужными для Principal
+type APIKeyOwner struct {
+	Key     APIKey
+	Email   string
+	Role    string
+	Blocked bool // владелец заблокирован или удалил аккаунт
+	Revoked bool
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// AuditEvent Запись таблицы logs. IP, User-Agent и trace ID, если не заданы, берутся из контекста запроса
+type AuditEvent struct {
+	ID        int64          `json:"id"`
+	UserID    int64          `json:"user_id,omitempty"` // 0 - пользователь неизвестен, например вход с несуществующим email
+	Action    string         `json:"action"`
+	IP        string         `json:"ip,omitempty"`
+	UserAgent string         `json:"user_agent,omitempty"`
+	TraceID   string         `json:"trace_id,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// AuditCursor Последняя запись страницы. События отсортированы от новых к старым по (created_at, id)
+type AuditCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// AuditFilter Пустые поля не фильтруют. Время - полуинтервал [From, To)
+type AuditFilter struct {
+	UserID int64
+	Action string
+	IP     string
+	From   *time.Time
+	To     *time.Time
+	After  *AuditCursor // следующая страница после этой записи
+	Limit  int
+}
+
+type AuditPage struct {
+	Items []AuditEvent
+	Next  *AuditCursor // nil - страница последняя
+}
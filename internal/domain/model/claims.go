@@ -0,0 +1,32 @@
+package model
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// AccessClaims Claims access-токена. Роль и email кладем в токен, чтобы не ходить в БД на каждую проверку
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"` // ID сессии (семейства ротаций refresh-токена), не меняется при refresh
+	Email     string `json:"email,omitempty"`
+	// ClientID и Scope есть только у токенов сервисов (client_credentials), sub у них равен client_id
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"` // scope через пробел, как в RFC 9068
+	// IssuedAtMs iat в миллисекундах: iat в секундах не отличает токен, выпущенный до отзыва в ту же секунду, от выпущенного после
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+}
+
+// IssuedTime Время выпуска для denylist. У токенов без iat_ms - iat, у токенов без iat - нулевое время
+func (c AccessClaims) IssuedTime() time.Time {
+	if c.IssuedAtMs != 0 {
+		return time.UnixMilli(c.IssuedAtMs)
+	}
+
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,19 @@
+package model
+
+// JWK Публичный ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet Набор ключей, который отдаем по /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
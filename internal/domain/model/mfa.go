@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// UserMFA Состояние второго фактора. Secret хранится зашифрованным,
+// EnabledAt nil, пока enrollment не подтвержден кодом
+type UserMFA struct {
+	Secret    string
+	EnabledAt *time.Time
+}
+
+// MFAEnrollment Секрет и otpauth:// ссылка для приложения-аутентификатора
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// LoginResult При включенном MFA токенов еще нет, вместо них выдается MFAToken для второго шага
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+	MFARequired  bool
+	MFAToken     string
+}
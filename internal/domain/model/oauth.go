@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// OAuthState Данные авторизации у провайдера между редиректом и callback. Хранится по хешу state
+type OAuthState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// UserIdentity Аккаунт у внешнего провайдера, привязанный к пользователю
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"` // sub из ID-токена, не меняется у провайдера в отличие от email
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
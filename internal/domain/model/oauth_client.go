@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// OAuthClient Сервис, который получает токены по client_credentials. Секрет хранится только хешем
+type OAuthClient struct {
+	ID         int64
+	ClientID   string
+	SecretHash string
+	Name       string
+	Scopes     []string // Какие scope клиент может запросить
+	Disabled   bool
+	CreatedAt  time.Time
+}
+
+// ClientToken Ответ /oauth/token (RFC 6749, 5.1)
+type ClientToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// TokenIntrospection Ответ /oauth/introspect (RFC 7662, 2.2). У неактивного токена только active=false
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
@@ -0,0 +1,55 @@
+package model
+
+// Principal Кто выполняет запрос. Кладется в контекст в AuthMiddleware
+type Principal struct {
+	UserID      int64
+	Email       string
+	Role        string
+	SessionID   string
+	Permissions []string
+	// ClientID Заполнен, если запрос от сервиса по client_credentials. UserID, роль и сессия тогда пустые
+	ClientID string
+	Scopes   []string
+	// APIKeyID Заполнен, если запрос по персональному API-ключу. Права тогда ограничены scope ключа
+	APIKeyID int64
+}
+
+// IsClient Токен выпущен сервису, а не пользователю
+func (p *Principal) IsClient() bool {
+	return p.ClientID != ""
+}
+
+// IsAPIKey Запрос по персональному API-ключу, а не по токену после входа
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Principal) HasPermission(permission string) bool {
+	for _, perm := range p.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+
+	return false
+}
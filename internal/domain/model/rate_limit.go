@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// RateLimit Политика GCRA: до Burst запросов подряд, дальше не чаще Rate запросов за Period
+type RateLimit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// Interval Через сколько восстанавливается один запрос
+func (l RateLimit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// ResetAfter Через сколько бюджет восстановится полностью
+	ResetAfter time.Duration
+	// RetryAfter Через сколько можно повторить отклоненный запрос
+	RetryAfter time.Duration
+}
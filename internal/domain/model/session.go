@@ -0,0 +1,17 @@
+package model
+
+import (
+	"github.com/Elaman1/full-project-mock/pkg/useragent"
+	"time"
+)
+
+// Session Активная сессия для пользователя. ID не меняется при ротации refresh-токена
+type Session struct {
+	ID         string           `json:"id"`
+	Current    bool             `json:"current"`
+	IP         string           `json:"ip"`
+	Device     useragent.Device `json:"device"`
+	CreatedAt  time.Time        `json:"created_at"`
+	LastUsedAt time.Time        `json:"last_used_at"`
+	ExpiresAt  time.Time        `json:"expires_at"`
+}
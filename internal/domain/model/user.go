@@ -6,10 +6,19 @@ type User struct {
 	ID        int64     `json:"id"`
 	Email     string    `json:"email"`
 	Username  string    `json:"username"`
-	Password  string    `json:"password"`
+	Password  string    `json:"-"`
 	CreatedAt time.Time `json:"created_at"`
 	RoleID    int64     `json:"role_id"`
 	Role      UserRole  `json:"role"`
+	Blocked   bool      `json:"blocked"`
+	// EmailVerifiedAt nil, пока email не подтвержден
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// MFAEnabledAt nil, пока второй фактор не включен
+	MFAEnabledAt *time.Time `json:"mfa_enabled_at"`
+	// PendingEmail Новый email, который еще не подтвержден по ссылке. До подтверждения вход по прежнему Email
+	PendingEmail *string `json:"pending_email"`
+	// Version Растет при каждом изменении профиля, PATCH /auth/me передает ее для оптимистичной блокировки
+	Version int64 `json:"version"`
 }
 
 type UserRole struct {
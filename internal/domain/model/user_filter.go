@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// UserFilter Фильтры и пагинация для списка пользователей в админке
+type UserFilter struct {
+	Email       string // подстрока email, без учета регистра
+	Role        string // код роли
+	Blocked     *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       int
+	Offset      int
+}
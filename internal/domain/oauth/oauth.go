@@ -0,0 +1,14 @@
+package oauth
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/pkg/oidc"
+)
+
+// Provider Внешний OpenID-провайдер (Google и т.п.)
+type Provider interface {
+	// AuthCodeURL Ссылка на вход у провайдера. codeChallenge - S256 от code_verifier (PKCE)
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange Меняет code на ID-токен и возвращает его проверенные claims
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (oidc.Claims, error)
+}
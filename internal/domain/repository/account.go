@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type AccountRepository interface {
+	// MarkDeleted Если аккаунта нет или он уже удален - apperror.UserNotFoundErr
+	MarkDeleted(ctx context.Context, userID int64, deletedAt time.Time) error
+	// ListDeletedBefore Аккаунты, удаленные раньше before, не больше limit
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]int64, error)
+	// Purge Удаляет строку пользователя (ключи, привязки и коды каскадно) и обезличивает его записи в logs
+	Purge(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	// ListByUser Неотозванные ключи пользователя, новые первыми
+	ListByUser(ctx context.Context, userID int64) ([]*model.APIKey, error)
+	// Revoke Если ключа нет, он чужой или уже отозван - apperror.APIKeyNotFoundErr
+	Revoke(ctx context.Context, userID, id int64) error
+	// GetByHash Если ключа нет - apperror.APIKeyNotFoundErr
+	GetByHash(ctx context.Context, keyHash string) (*model.APIKeyOwner, error)
+	// TouchLastUsed Обновляет last_used_at не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+	TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error
+}
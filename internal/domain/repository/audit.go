@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type AuditRepository interface {
+	InsertBatch(ctx context.Context, events []model.AuditEvent) error
+	// List Не больше filter.Limit событий, от новых к старым
+	List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error)
+}
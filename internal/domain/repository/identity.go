@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type IdentityRepository interface {
+	// GetUserID Владелец аккаунта провайдера. Если аккаунт не привязан - apperror.IdentityNotFoundErr
+	GetUserID(ctx context.Context, provider, subject string) (int64, error)
+	// CreateUserWithIdentity Создает пользователя и привязку в одной транзакции.
+	// Если email уже занят - apperror.ExistsEmailErr
+	CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error
+	ListByUser(ctx context.Context, userID int64) ([]model.UserIdentity, error)
+}
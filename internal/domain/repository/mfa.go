@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type MFARepository interface {
+	Get(ctx context.Context, userID int64) (*model.UserMFA, error)
+	// SavePendingSecret Сохраняет новый секрет, MFA остается выключенным до Enable
+	SavePendingSecret(ctx context.Context, userID int64, encryptedSecret string) error
+	// Enable Включает MFA и сохраняет хеши кодов восстановления одной транзакцией
+	Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+	Disable(ctx context.Context, userID int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, recoveryCodeHashes []string) error
+	// UseRecoveryCode Отмечает код использованным. false, если кода нет или он уже использован
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+}
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type OAuthClientRepository interface {
+	// GetByClientID Если клиента нет - apperror.OAuthClientNotFoundErr
+	GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+}
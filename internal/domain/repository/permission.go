@@ -0,0 +1,8 @@
+package repository
+
+import "context"
+
+type PermissionRepository interface {
+	// GetRolePermissions Коды прав роли. Для неизвестной роли вернет пустой список
+	GetRolePermissions(ctx context.Context, roleCode string) ([]string, error)
+}
@@ -10,4 +10,12 @@ type UserRepository interface {
 	Get(ctx context.Context, email string) (*model.User, error)
 	Exists(ctx context.Context, email string) (bool, error)
 	GetById(ctx context.Context, id int64) (*model.User, error)
+	MarkEmailVerified(ctx context.Context, id int64) error
+	UpdatePassword(ctx context.Context, id int64, hashedPassword string) error
+	// UpdateProfile Сохраняет username и pending_email, если версия профиля все еще version, иначе apperror.VersionConflictErr.
+	// Новая Version записывается в user
+	UpdateProfile(ctx context.Context, user *model.User, version int64) error
+	// ConfirmEmailChange Заменяет email подтвержденным pending_email. Если смены нет - apperror.UserNotFoundErr,
+	// если адрес уже занят - apperror.ExistsEmailErr
+	ConfirmEmailChange(ctx context.Context, id int64) error
 }
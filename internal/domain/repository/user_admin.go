@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type UserAdminRepository interface {
+	// List Возвращает страницу пользователей и общее количество под фильтр
+	List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error)
+	GetById(ctx context.Context, id int64) (*model.User, error)
+	SetBlocked(ctx context.Context, id int64, blocked bool) error
+	UpdateRole(ctx context.Context, id int64, roleCode string) error
+}
@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"time"
+)
+
+type AccountUsecase interface {
+	Export(ctx context.Context, userID int64) (*model.AccountExport, error)
+	// Delete Проверяет пароль, скрывает аккаунт и завершает все сессии. Возвращает время, после которого
+	// данные будут удалены окончательно. Неверный пароль - apperror.WrongPasswordErr,
+	// он учитывается счетчиком входа, при блокировке - apperror.TooManyLoginAttemptsErr
+	Delete(ctx context.Context, userID int64, password, clientIP string) (time.Time, error)
+	// PurgeDeleted Окончательно удаляет аккаунты, у которых истек grace period. Возвращает их количество
+	PurgeDeleted(ctx context.Context) (int, error)
+}
@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+// AdminUserUsecase actorID - id администратора, который выполняет действие
+type AdminUserUsecase interface {
+	ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error)
+	GetUser(ctx context.Context, id int64) (*model.User, error)
+	BlockUser(ctx context.Context, actorID, id int64) error
+	UnblockUser(ctx context.Context, actorID, id int64) error
+	ChangeRole(ctx context.Context, actorID, id int64, roleCode string) error
+	// UnlockLogin Снимает блокировку входа после перебора паролей
+	UnlockLogin(ctx context.Context, actorID, id int64) error
+}
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"time"
+)
+
+type APIKeyUsecase interface {
+	// Create Возвращает ключ в открытом виде, повторно его получить нельзя.
+	// scopes должны входить в права роли пользователя (permissions)
+	Create(ctx context.Context, userID int64, permissions []string, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error)
+	List(ctx context.Context, userID int64) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, userID, id int64) error
+	// Authenticate Principal по ключу для AuthMiddleware. Неизвестный, истекший, отозванный ключ
+	// или заблокированный владелец - apperror.InvalidAPIKeyErr
+	Authenticate(ctx context.Context, plainKey string) (*model.Principal, error)
+}
@@ -0,0 +1,13 @@
+package usecase
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type AuditUsecase interface {
+	ListEvents(ctx context.Context, filter model.AuditFilter) (model.AuditPage, error)
+	// ExportEvents Отдает все подходящие события в write по одному, не загружая их в память целиком.
+	// filter.Limit игнорируется
+	ExportEvents(ctx context.Context, filter model.AuditFilter, write func(model.AuditEvent) error) error
+}
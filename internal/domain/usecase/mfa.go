@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type MFAUsecase interface {
+	// Enroll account - подпись в приложении-аутентификаторе, обычно email. Требует текущий пароль
+	Enroll(ctx context.Context, userID int64, account, password, clientIP string) (model.MFAEnrollment, error)
+	// Confirm Включает MFA по текущему паролю и первому TOTP-коду, возвращает коды восстановления
+	Confirm(ctx context.Context, userID int64, password, code, clientIP string) ([]string, error)
+	// Disable Требует текущий пароль и код, попытки учитываются счетчиком входа
+	Disable(ctx context.Context, userID int64, password, code, clientIP string) error
+	// RegenerateRecoveryCodes Подтверждается так же, как Disable
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, password, code, clientIP string) ([]string, error)
+	// VerifyCode Принимает TOTP-код или код восстановления
+	VerifyCode(ctx context.Context, userID int64, code string) error
+}
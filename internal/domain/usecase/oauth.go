@@ -0,0 +1,14 @@
+package usecase
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type OAuthUsecase interface {
+	// Authorize Ссылка на вход у провайдера и ее state. state, nonce и PKCE verifier сохраняются до callback
+	Authorize(ctx context.Context, provider string) (authURL, state string, err error)
+	// Callback Вход по коду от провайдера. Новый аккаунт провайдера создает пользователя.
+	// Как и Login, при включенном MFA возвращает mfa_token
+	Callback(ctx context.Context, provider, state, code, clientIP, ua string) (model.LoginResult, int, error)
+}
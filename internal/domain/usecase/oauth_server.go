@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+type OAuthServerUsecase interface {
+	// ClientCredentials Токен для сервиса по client_id и секрету (RFC 6749, 4.4).
+	// Пустой scope означает все разрешенные клиенту scope
+	ClientCredentials(ctx context.Context, clientID, clientSecret, scope, clientIP, ua string) (model.ClientToken, int, error)
+	// Introspect Жив ли access или refresh токен (RFC 7662). Клиенту нужен scope tokens:introspect
+	Introspect(ctx context.Context, clientID, clientSecret, token, tokenTypeHint, clientIP, ua string) (model.TokenIntrospection, int, error)
+	// Revoke Отзывает access или refresh токен (RFC 7009). Неизвестный токен не считается ошибкой.
+	// Токены пользователей клиент отзывает только со scope tokens:revoke, токены сервисов - только свои
+	Revoke(ctx context.Context, clientID, clientSecret, token, tokenTypeHint, clientIP, ua string) (int, error)
+}
@@ -1,11 +1,43 @@
 package usecase
 
-import "context"
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
 
 type UserUsecase interface {
+	// Register Нарушения политики пароля возвращаются как *validator.PasswordPolicyError, так же в ResetPassword и ChangePassword
 	Register(ctx context.Context, email, username, password string) (int64, error)
-	Login(ctx context.Context, email, password, clientIP, ua string) (string, string, int, error)
+	// Login При включенном MFA вместо токенов возвращает mfa_token для LoginMFA
+	Login(ctx context.Context, email, password, clientIP, ua string) (model.LoginResult, int, error)
+	LoginMFA(ctx context.Context, mfaToken, code, clientIP, ua string) (string, string, int, error)
+	// LoginWithIdentity Вход пользователя, которого уже проверил внешний провайдер (provider).
+	// Блокировка и MFA проверяются так же, как в Login
+	LoginWithIdentity(ctx context.Context, userID int64, provider, clientIP, ua string) (model.LoginResult, int, error)
+	// Refresh При смене IP или User-Agent, недопустимой по auth.session_binding, может вернуть StepUpRequiredErr
 	Refresh(ctx context.Context, accessToken, refreshToken, clientIP, ua string) (string, string, int, error)
-	Logout(ctx context.Context, refreshToken, clientIP, ua string) error
+	// RefreshStepUp Обновляет токены после смены отпечатка, если пользователь подтвердил себя паролем и кодом MFA
+	RefreshStepUp(ctx context.Context, refreshToken, password, code, clientIP, ua string) (string, string, int, error)
+	Logout(ctx context.Context, accessToken, refreshToken, clientIP, ua string) error
 	LogoutAllDevices(ctx context.Context, refreshToken, clientIP, ua string) error
+	VerifyEmail(ctx context.Context, token string) error
+	// ResendVerification Письмо отправляется в фоне, поэтому ни ошибка, ни время ответа не выдают, есть ли такой email
+	ResendVerification(ctx context.Context, email string)
+	// ForgotPassword Как и ResendVerification, выполняется в фоне
+	ForgotPassword(ctx context.Context, email string)
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	// ChangePassword Завершает все сессии, кроме sessionID, и возвращает новый access-токен для текущей.
+	// Неверный текущий пароль учитывается счетчиком входа, при блокировке - *apperror.RetryAfterError
+	ChangePassword(ctx context.Context, userID int64, sessionID, currentPassword, newPassword, clientIP string) (string, error)
+	// ListSessions Активные сессии пользователя, currentSessionID помечается как текущая
+	ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]model.Session, error)
+	// RevokeSession Завершает сессию пользователя. Чужая сессия не отличается от несуществующей
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	GetProfile(ctx context.Context, userID int64) (*model.User, error)
+	// UpdateProfile nil - поле не меняется. version - версия профиля, которую видел клиент.
+	// Новый email сохраняется как pending_email и заменяет текущий только после подтверждения по ссылке.
+	// Для смены email нужен currentPassword, неверный учитывается счетчиком входа
+	UpdateProfile(ctx context.Context, userID, version int64, username, email *string, currentPassword, clientIP string) (*model.User, error)
+	// Close Дожидается писем и других задач, запущенных в фоне. Вызывается при остановке до закрытия БД и Redis
+	Close(ctx context.Context) error
 }
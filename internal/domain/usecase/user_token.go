@@ -2,11 +2,14 @@ package usecase
 
 import (
 	"github.com/Elaman1/full-project-mock/internal/domain/model"
-	"github.com/golang-jwt/jwt/v5"
+	"time"
 )
 
 type TokenService interface {
-	GenerateAccessToken(user *model.User) (string, error)
+	GenerateAccessToken(user *model.User, sessionID string) (string, error)
 	GenerateRefreshToken() (tokenID, plainToken string, err error)
-	ParseToken(tokenStr string) (jwt.RegisteredClaims, error)
+	// GenerateClientToken Access-токен сервиса: sub и client_id - идентификатор клиента, без роли и сессии
+	GenerateClientToken(clientID string, scopes []string) (token string, expiresIn time.Duration, err error)
+	ParseToken(tokenStr string) (model.AccessClaims, error)
+	JWKS() model.JWKSet
 }
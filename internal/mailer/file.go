@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/mailer"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileMailer struct {
+	mu   sync.Mutex
+	path string
+	lgr  *slog.Logger
+}
+
+// NewFileMailer Для локальной разработки и тестов: письма дописываются в файл,
+// а если путь пустой - пишутся в лог
+func NewFileMailer(path string, lgr *slog.Logger) mailer.Mailer {
+	return &fileMailer{path: path, lgr: lgr}
+}
+
+func (m *fileMailer) Send(_ context.Context, msg model.MailMessage) error {
+	if m.path == "" {
+		m.lgr.Info("mail sent", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open mail file error: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("write mail file error: %w", err)
+	}
+
+	return nil
+}
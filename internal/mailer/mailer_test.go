@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewFileMailer(path, slog.Default())
+
+	require.NoError(t, m.Send(context.Background(), model.MailMessage{To: "a@test.com", Subject: "first", Body: "token-1"}))
+	require.NoError(t, m.Send(context.Background(), model.MailMessage{To: "b@test.com", Subject: "second", Body: "token-2"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: a@test.com\nSubject: first\n\ntoken-1")
+	assert.Contains(t, string(data), "To: b@test.com\nSubject: second\n\ntoken-2")
+}
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := buildMessage("noreply@test.com", model.MailMessage{To: "a@test.com", Subject: "Verify email", Body: "line1\nline2"}, date)
+
+	assert.Equal(t, "From: noreply@test.com\r\n"+
+		"To: a@test.com\r\n"+
+		"Subject: Verify email\r\n"+
+		"Date: Thu, 02 Jan 2025 03:04:05 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: 8bit\r\n"+
+		"\r\n"+
+		"line1\r\nline2", string(msg))
+
+	// Не-ASCII тема кодируется по RFC 2047
+	msg = buildMessage("noreply@test.com", model.MailMessage{To: "a@test.com", Subject: "Подтверждение email"}, date)
+	assert.Contains(t, string(msg), "Subject: =?utf-8?q?")
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(strings.SplitN(strings.SplitN(string(msg), "Subject: ", 2)[1], "\r\n", 2)[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Подтверждение email", subject)
+}
@@ -2,15 +2,29 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/pkg/req"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type contextKey string
 
-const UserIDKey = contextKey("userID")
+const (
+	UserIDKey    = contextKey("userID")
+	PrincipalKey = contextKey("principal")
+)
+
+const apiKeyHeader = "X-API-Key"
 
 func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	id, ok := ctx.Value(UserIDKey).(string)
@@ -21,16 +35,30 @@ func SetUserIDToContext(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
 }
 
-func AuthMiddleware(tokenSvc usecase.TokenService) func(http.Handler) http.Handler {
+func GetPrincipalFromContext(ctx context.Context) (*model.Principal, bool) {
+	principal, ok := ctx.Value(PrincipalKey).(*model.Principal)
+	return principal, ok
+}
+
+func SetPrincipalToContext(ctx context.Context, principal *model.Principal) context.Context {
+	return context.WithValue(ctx, PrincipalKey, principal)
+}
+
+// AuthMiddleware Принимает JWT или персональный API-ключ (X-API-Key или Bearer sk_...).
+// apiKeys может быть nil, тогда ключи не принимаются
+func AuthMiddleware(tokenSvc usecase.TokenService, denylist cache.AccessTokenDenylist, permissions repository.PermissionRepository, apiKeys usecase.APIKeyUsecase) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-				http.Error(w, "missing or invalid Authorization header", http.StatusUnauthorized)
+			if plainKey, ok := getAPIKey(r); ok && apiKeys != nil {
+				authenticateAPIKey(w, r, next, apiKeys, permissions, plainKey)
 				return
 			}
 
-			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+			tokenStr, ok := req.GetBearerToken(r)
+			if !ok {
+				http.Error(w, "missing or invalid Authorization header", http.StatusUnauthorized)
+				return
+			}
 
 			mapClaims, err := tokenSvc.ParseToken(tokenStr)
 			if err != nil {
@@ -48,8 +76,109 @@ func AuthMiddleware(tokenSvc usecase.TokenService) func(http.Handler) http.Handl
 				return
 			}
 
+			// У токена сервиса sub - это client_id, у пользовательского - числовой ID
+			isClient := mapClaims.ClientID != ""
+			var userID int64
+			if isClient {
+				if mapClaims.Subject != mapClaims.ClientID {
+					http.Error(w, "invalid token: bad subject", http.StatusUnauthorized)
+					return
+				}
+			} else {
+				userID, err = strconv.ParseInt(mapClaims.Subject, 10, 64)
+				if err != nil {
+					http.Error(w, "invalid token: bad subject", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			// После logout или завершения сессии токен не должен работать до истечения accessTTL
+			revoked, err := denylist.IsRevoked(r.Context(), mapClaims.ID, mapClaims.SessionID, userID, mapClaims.IssuedTime())
+			if err != nil {
+				http.Error(w, "failed to check token", http.StatusInternalServerError)
+				return
+			}
+
+			if revoked {
+				http.Error(w, "revoked token", http.StatusUnauthorized)
+				return
+			}
+
+			if isClient {
+				ctx := SetPrincipalToContext(r.Context(), &model.Principal{
+					ClientID: mapClaims.ClientID,
+					Scopes:   strings.Fields(mapClaims.Scope),
+				})
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Роль берем из токена, права роли - из репозитория (с кешем)
+			var rolePermissions []string
+			if mapClaims.Role != "" {
+				rolePermissions, err = permissions.GetRolePermissions(r.Context(), mapClaims.Role)
+				if err != nil {
+					http.Error(w, "failed to load permissions", http.StatusInternalServerError)
+					return
+				}
+			}
+
 			ctx := SetUserIDToContext(r.Context(), mapClaims.Subject)
+			ctx = SetPrincipalToContext(ctx, &model.Principal{
+				UserID:      userID,
+				Email:       mapClaims.Email,
+				Role:        mapClaims.Role,
+				SessionID:   mapClaims.SessionID,
+				Permissions: rolePermissions,
+			})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func getAPIKey(r *http.Request) (string, bool) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key, true
+	}
+
+	if tokenStr, ok := req.GetBearerToken(r); ok && strings.HasPrefix(tokenStr, constants.APIKeyPrefix) {
+		return tokenStr, true
+	}
+
+	return "", false
+}
+
+// authenticateAPIKey Права по ключу - пересечение прав роли владельца и scope ключа
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, apiKeys usecase.APIKeyUsecase, permissions repository.PermissionRepository, plainKey string) {
+	principal, err := apiKeys.Authenticate(r.Context(), plainKey)
+	if errors.Is(err, apperror.InvalidAPIKeyErr) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "failed to check api key", http.StatusInternalServerError)
+		return
+	}
+
+	var rolePermissions []string
+	if principal.Role != "" {
+		rolePermissions, err = permissions.GetRolePermissions(r.Context(), principal.Role)
+		if err != nil {
+			http.Error(w, "failed to load permissions", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Права роли могли сократиться после выпуска ключа
+	principal.Permissions = make([]string, 0, len(principal.Scopes))
+	for _, scope := range principal.Scopes {
+		if slices.Contains(rolePermissions, scope) {
+			principal.Permissions = append(principal.Permissions, scope)
+		}
+	}
+
+	ctx := SetUserIDToContext(r.Context(), strconv.FormatInt(principal.UserID, 10))
+	ctx = SetPrincipalToContext(ctx, principal)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
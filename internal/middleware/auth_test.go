@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/internal/mocks"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
@@ -14,29 +17,44 @@ import (
 )
 
 const (
-	testUserID = "123"
+	testUserID    = "123"
+	testJTI       = "test-jti"
+	testRole      = "admin"
+	testSessionID = "session-id"
+	testEmail     = "admin@test.com"
 )
 
 func TestAuthMiddleware(t *testing.T) {
 	type testCase struct {
 		name              string
 		authHeader        string
-		mockParseResponse jwt.RegisteredClaims
+		mockParseResponse model.AccessClaims
 		mockParseErr      error
+		mockRevoked       bool
+		mockRevokedErr    error
+		mockPermsErr      error
 		expectedStatus    int
 		expectedBody      string
 		expectNextCalled  bool
 	}
 
 	now := time.Now()
-	validClaims := jwt.RegisteredClaims{
-		Subject:   testUserID,
-		ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute)),
+	validClaims := model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        testJTI,
+			Subject:   testUserID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute)),
+		},
+		Role:       testRole,
+		SessionID:  testSessionID,
+		Email:      testEmail,
+		IssuedAtMs: now.UnixMilli(),
 	}
-	expiredClaims := jwt.RegisteredClaims{
+	expiredClaims := model.AccessClaims{RegisteredClaims: jwt.RegisteredClaims{
 		Subject:   testUserID,
 		ExpiresAt: jwt.NewNumericDate(now.Add(-10 * time.Minute)),
-	}
+	}}
 
 	tests := []testCase{
 		{
@@ -72,11 +90,46 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name:              "empty subject",
 			authHeader:        "Bearer empty-subject",
-			mockParseResponse: jwt.RegisteredClaims{Subject: "", ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute))},
+			mockParseResponse: model.AccessClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "", ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute))}},
 			expectedStatus:    http.StatusUnauthorized,
 			expectedBody:      "invalid token: empty subject",
 			expectNextCalled:  false,
 		},
+		{
+			name:              "bad subject",
+			authHeader:        "Bearer bad-subject",
+			mockParseResponse: model.AccessClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "abc", ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute))}},
+			expectedStatus:    http.StatusUnauthorized,
+			expectedBody:      "invalid token: bad subject",
+			expectNextCalled:  false,
+		},
+		{
+			name:              "revoked token",
+			authHeader:        "Bearer revoked-token",
+			mockParseResponse: validClaims,
+			mockRevoked:       true,
+			expectedStatus:    http.StatusUnauthorized,
+			expectedBody:      "revoked token",
+			expectNextCalled:  false,
+		},
+		{
+			name:              "denylist error",
+			authHeader:        "Bearer good-token",
+			mockParseResponse: validClaims,
+			mockRevokedErr:    errors.New("redis is down"),
+			expectedStatus:    http.StatusInternalServerError,
+			expectedBody:      "failed to check token",
+			expectNextCalled:  false,
+		},
+		{
+			name:              "permissions error",
+			authHeader:        "Bearer good-token",
+			mockParseResponse: validClaims,
+			mockPermsErr:      errors.New("db is down"),
+			expectedStatus:    http.StatusInternalServerError,
+			expectedBody:      "failed to load permissions",
+			expectNextCalled:  false,
+		},
 		{
 			name:              "valid token",
 			authHeader:        "Bearer good-token",
@@ -93,6 +146,13 @@ func TestAuthMiddleware(t *testing.T) {
 			t.Parallel()
 
 			mockTokenSvc := new(mocks.MockTokenService)
+			mockDenylist := new(mocks.MockAccessTokenDenylist)
+			// В denylist уходит iat_ms, а не округленный до секунды iat
+			mockDenylist.On("IsRevoked", mock.Anything, testJTI, testSessionID, int64(123), time.UnixMilli(now.UnixMilli())).
+				Return(tc.mockRevoked, tc.mockRevokedErr).Maybe()
+			mockPerms := new(mocks.MockPermissionRepository)
+			mockPerms.On("GetRolePermissions", mock.Anything, testRole).
+				Return([]string{"users:read"}, tc.mockPermsErr).Maybe()
 
 			// Мокаем ParseToken, если передан header
 			if tc.authHeader != "" && strings.HasPrefix(tc.authHeader, "Bearer ") {
@@ -109,6 +169,14 @@ func TestAuthMiddleware(t *testing.T) {
 				require.True(t, ok)
 				require.Equal(t, testUserID, uid)
 
+				principal, ok := GetPrincipalFromContext(r.Context())
+				require.True(t, ok)
+				require.Equal(t, int64(123), principal.UserID)
+				require.Equal(t, testRole, principal.Role)
+				require.Equal(t, testSessionID, principal.SessionID)
+				require.Equal(t, testEmail, principal.Email)
+				require.True(t, principal.HasPermission("users:read"))
+
 				w.WriteHeader(http.StatusOK)
 			})
 
@@ -118,7 +186,7 @@ func TestAuthMiddleware(t *testing.T) {
 			}
 			rec := httptest.NewRecorder()
 
-			middlewareFunc := AuthMiddleware(mockTokenSvc)
+			middlewareFunc := AuthMiddleware(mockTokenSvc, mockDenylist, mockPerms, nil)
 			handler := middlewareFunc(nextHandler)
 			handler.ServeHTTP(rec, req)
 
@@ -131,6 +199,174 @@ func TestAuthMiddleware(t *testing.T) {
 			}
 
 			mockTokenSvc.AssertExpectations(t)
+			mockDenylist.AssertExpectations(t)
+			mockPerms.AssertExpectations(t)
 		})
 	}
 }
+
+func TestAuthMiddleware_ClientToken(t *testing.T) {
+	now := time.Now()
+	clientClaims := model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        testJTI,
+			Subject:   "billing",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute)),
+		},
+		ClientID: "billing",
+		Scope:    "users:read audit:read",
+	}
+
+	t.Run("client principal", func(t *testing.T) {
+		mockTokenSvc := new(mocks.MockTokenService)
+		mockTokenSvc.On("ParseToken", "client-token").Return(clientClaims, nil)
+		mockDenylist := new(mocks.MockAccessTokenDenylist)
+		mockDenylist.On("IsRevoked", mock.Anything, testJTI, "", int64(0), clientClaims.IssuedAt.Time).Return(false, nil)
+		// Права ролей для сервиса не загружаются
+		mockPerms := new(mocks.MockPermissionRepository)
+
+		var principal *model.Principal
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ = GetPrincipalFromContext(r.Context())
+			_, hasUserID := GetUserIDFromContext(r.Context())
+			assert.False(t, hasUserID)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer client-token")
+		rec := httptest.NewRecorder()
+		AuthMiddleware(mockTokenSvc, mockDenylist, mockPerms, nil)(next).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, principal)
+		assert.True(t, principal.IsClient())
+		assert.Equal(t, "billing", principal.ClientID)
+		assert.Equal(t, []string{"users:read", "audit:read"}, principal.Scopes)
+		assert.Zero(t, principal.UserID)
+		assert.Empty(t, principal.Role)
+		mockPerms.AssertNotCalled(t, "GetRolePermissions", mock.Anything, mock.Anything)
+	})
+
+	t.Run("subject differs from client_id", func(t *testing.T) {
+		forged := clientClaims
+		forged.Subject = testUserID
+
+		mockTokenSvc := new(mocks.MockTokenService)
+		mockTokenSvc.On("ParseToken", "client-token").Return(forged, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer client-token")
+		rec := httptest.NewRecorder()
+		AuthMiddleware(mockTokenSvc, new(mocks.MockAccessTokenDenylist), new(mocks.MockPermissionRepository), nil)(http.NotFoundHandler()).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "bad subject")
+	})
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	const plainKey = "sk_test-key"
+
+	tests := []struct {
+		name             string
+		header           string
+		value            string
+		authErr          error
+		expectedStatus   int
+		expectedPerms    []string
+		expectNextCalled bool
+	}{
+		{
+			name:             "x-api-key header",
+			header:           "X-API-Key",
+			value:            plainKey,
+			expectedStatus:   http.StatusOK,
+			expectedPerms:    []string{"users:read"},
+			expectNextCalled: true,
+		},
+		{
+			name:             "bearer with key prefix",
+			header:           "Authorization",
+			value:            "Bearer " + plainKey,
+			expectedStatus:   http.StatusOK,
+			expectedPerms:    []string{"users:read"},
+			expectNextCalled: true,
+		},
+		{
+			name:           "invalid key",
+			header:         "X-API-Key",
+			value:          plainKey,
+			authErr:        apperror.InvalidAPIKeyErr,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "storage error",
+			header:         "X-API-Key",
+			value:          plainKey,
+			authErr:        errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPIKeys := new(mocks.MockAPIKeyUsecase)
+			mockPerms := new(mocks.MockPermissionRepository)
+			if tc.authErr != nil {
+				mockAPIKeys.On("Authenticate", mock.Anything, plainKey).Return(nil, tc.authErr)
+			} else {
+				// У ключа есть scope, которого роль уже лишилась
+				mockAPIKeys.On("Authenticate", mock.Anything, plainKey).Return(&model.Principal{
+					UserID:   123,
+					Email:    testEmail,
+					Role:     testRole,
+					APIKeyID: 7,
+					Scopes:   []string{"users:read", "users:manage"},
+				}, nil)
+				mockPerms.On("GetRolePermissions", mock.Anything, testRole).Return([]string{"users:read", "audit:read"}, nil)
+			}
+
+			nextCalled := false
+			var principal *model.Principal
+			var userID string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				principal, _ = GetPrincipalFromContext(r.Context())
+				userID, _ = GetUserIDFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set(tc.header, tc.value)
+			rec := httptest.NewRecorder()
+			// JWT по ключу не разбирается
+			mockTokenSvc := new(mocks.MockTokenService)
+			AuthMiddleware(mockTokenSvc, new(mocks.MockAccessTokenDenylist), mockPerms, mockAPIKeys)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectNextCalled, nextCalled)
+			if tc.expectNextCalled {
+				require.NotNil(t, principal)
+				assert.True(t, principal.IsAPIKey())
+				assert.Equal(t, testUserID, userID)
+				assert.Equal(t, tc.expectedPerms, principal.Permissions)
+			}
+
+			mockAPIKeys.AssertExpectations(t)
+			mockTokenSvc.AssertNotCalled(t, "ParseToken", mock.Anything)
+		})
+	}
+
+	t.Run("keys disabled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("X-API-Key", plainKey)
+		rec := httptest.NewRecorder()
+		AuthMiddleware(new(mocks.MockTokenService), new(mocks.MockAccessTokenDenylist), new(mocks.MockPermissionRepository), nil)(http.NotFoundHandler()).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "missing or invalid Authorization header")
+	})
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"net/http"
+)
+
+// ClientIP Определяет IP клиента один раз на запрос, дальше его читает req.GetClientMeta.
+// User-Agent тоже кладется в контекст, чтобы журнал аудита мог его взять без протаскивания через usecase
+func ClientIP(resolver *req.ClientIPResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := req.WithClientIP(r.Context(), resolver.Resolve(r))
+			ctx = req.WithUserAgent(ctx, r.Header.Get("User-Agent"))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	resolver, err := req.NewClientIPResolver([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var gotIP string
+	handler := ClientIP(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIP, _ = req.GetClientMeta(r)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:4000"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4, 203.0.113.5")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "203.0.113.5", gotIP)
+}
@@ -16,6 +16,7 @@ func LogMiddleware(logs *slog.Logger) func(http.Handler) http.Handler {
 			}
 
 			ctx := service.WithLogger(r.Context(), logs.With("traceId", traceId))
+			ctx = service.WithTraceID(ctx, traceId)
 
 			srw := &StatusResponseWriter{
 				ResponseWriter: w,
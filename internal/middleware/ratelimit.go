@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitKeyFunc Ключ, по которому считается лимит. Пустой ключ - запрос не ограничивается
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitPolicy Name отделяет бюджеты разных политик: лимит на /register не расходует лимит на /auth/me
+type RateLimitPolicy struct {
+	Name  string
+	Limit model.RateLimit
+	Key   RateLimitKeyFunc
+}
+
+// KeyByIP Ключ по IP клиента
+func KeyByIP(r *http.Request) string {
+	ip, _ := req.GetClientMeta(r)
+	return "ip:" + ip
+}
+
+// KeyByUserID Ключ по пользователю из AuthMiddleware, без авторизации - по IP
+func KeyByUserID(r *http.Request) string {
+	if userID, ok := GetUserIDFromContext(r.Context()); ok {
+		return "user:" + userID
+	}
+
+	return KeyByIP(r)
+}
+
+// KeyByAPIKey Запрос по API-ключу считается отдельно от входа владельца, остальные - по fallback.
+// Ключ берется только после AuthMiddleware, иначе подставной заголовок давал бы новый бюджет. В хранилище попадает хеш
+func KeyByAPIKey(fallback RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		principal, ok := GetPrincipalFromContext(r.Context())
+		if !ok || !principal.IsAPIKey() {
+			return fallback(r)
+		}
+
+		if plainKey, ok := getAPIKey(r); ok {
+			return "apikey:" + hasher.Sha256Hex(plainKey)
+		}
+
+		return fallback(r)
+	}
+}
+
+// RateLimit Ограничивает частоту запросов и отдает заголовки RateLimit-* (draft-ietf-httpapi-ratelimit-headers).
+// При недоступном хранилище запрос пропускается: лимиты не должны ронять сервис
+func RateLimit(limiter cache.RateLimiter, policy RateLimitPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := policy.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := limiter.Allow(r.Context(), policy.Name+":"+key, policy.Limit)
+			if err != nil {
+				service.LoggerFromContext(r.Context()).Error("rate limiter error", "error", err, "policy", policy.Name)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			// Политика - устойчивая квота за окно, Burst виден в RateLimit-Limit
+			header.Set("RateLimit-Policy", fmt.Sprintf("q=%d;w=%d", policy.Limit.Rate, ceilSeconds(policy.Limit.Period)))
+			header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit.Burst))
+			header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(res.ResetAfter), 10))
+
+			if !res.Allowed {
+				header.Set("Retry-After", strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ceilSeconds Округляем вверх, чтобы клиент не повторил запрос раньше времени
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
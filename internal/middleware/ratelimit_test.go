@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testLimit = model.RateLimit{Rate: 5, Period: time.Minute, Burst: 10}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name            string
+		result          model.RateLimitResult
+		err             error
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "allowed",
+			result:         model.RateLimitResult{Allowed: true, Remaining: 3, ResetAfter: 24 * time.Second},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Policy":    "q=5;w=60",
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "3",
+				"RateLimit-Reset":     "24",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:           "limited",
+			result:         model.RateLimitResult{Allowed: false, RetryAfter: 11500 * time.Millisecond, ResetAfter: time.Minute},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "12",
+			},
+		},
+		{
+			name:           "store error fails open",
+			err:            errors.New("redis down"),
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := new(mocks.MockRateLimiter)
+			limiter.On("Allow", mock.Anything, "login:ip:10.0.0.1", testLimit).Return(tt.result, tt.err)
+
+			handler := RateLimit(limiter, RateLimitPolicy{Name: "login", Limit: testLimit, Key: KeyByIP})(okHandler())
+
+			req := httptest.NewRequest(http.MethodPost, "/login", nil)
+			req.RemoteAddr = "10.0.0.1:40000"
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			for header, value := range tt.expectedHeaders {
+				assert.Equal(t, value, rec.Header().Get(header), header)
+			}
+
+			limiter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:40000"
+
+	assert.Equal(t, "ip:10.0.0.1", KeyByIP(req))
+	assert.Equal(t, "ip:10.0.0.1", KeyByUserID(req))
+
+	req = req.WithContext(SetUserIDToContext(context.Background(), "42"))
+	assert.Equal(t, "user:42", KeyByUserID(req))
+	assert.Equal(t, "user:42", KeyByAPIKey(KeyByUserID)(req))
+}
+
+func TestRateLimitKeyByAPIKey(t *testing.T) {
+	keyFunc := KeyByAPIKey(KeyByUserID)
+	withPrincipal := func(r *http.Request, principal *model.Principal) *http.Request {
+		ctx := SetUserIDToContext(r.Context(), "42")
+		return r.WithContext(SetPrincipalToContext(ctx, principal))
+	}
+
+	t.Run("x-api-key header", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+		r.Header.Set("X-API-Key", "sk_secret")
+		r = withPrincipal(r, &model.Principal{UserID: 42, APIKeyID: 7})
+
+		assert.Equal(t, "apikey:"+hasher.Sha256Hex("sk_secret"), keyFunc(r))
+	})
+
+	t.Run("bearer api key", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+		r.Header.Set("Authorization", "Bearer sk_secret")
+		r = withPrincipal(r, &model.Principal{UserID: 42, APIKeyID: 7})
+
+		assert.Equal(t, "apikey:"+hasher.Sha256Hex("sk_secret"), keyFunc(r))
+	})
+
+	t.Run("header ignored for jwt login", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+		r.Header.Set("X-API-Key", "sk_random")
+		r = withPrincipal(r, &model.Principal{UserID: 42, SessionID: "s"})
+
+		assert.Equal(t, "user:42", keyFunc(r))
+	})
+}
+
+func TestRateLimitEmptyKey(t *testing.T) {
+	limiter := new(mocks.MockRateLimiter)
+	policy := RateLimitPolicy{Name: "any", Limit: testLimit, Key: func(*http.Request) string { return "" }}
+
+	rec := httptest.NewRecorder()
+	RateLimit(limiter, policy)(okHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	limiter.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
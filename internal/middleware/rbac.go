@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireRole Пропускает, если у пользователя одна из ролей. Ставится после AuthMiddleware
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := GetPrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !principal.HasRole(roles...) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireUser Не пускает токены сервисов (client_credentials) на ручки пользователей. Ставится после AuthMiddleware
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := GetPrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if principal.IsClient() {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireSession Пускает только пользователя, вошедшего по логину. Управление сессиями, паролем, MFA и ключами
+// нельзя делать по API-ключу или токену сервиса. Ставится после AuthMiddleware
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := GetPrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if principal.IsClient() || principal.IsAPIKey() {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope Пропускает токен сервиса, только если в нем есть все перечисленные scope. Ставится после AuthMiddleware
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := GetPrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// Scope API-ключа - это права пользователя, а не сервиса
+			if !principal.IsClient() {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !principal.HasScope(scope) {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission Пропускает, только если есть все перечисленные права. Ставится после AuthMiddleware
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := GetPrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			for _, permission := range permissions {
+				if !principal.HasPermission(permission) {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *model.Principal
+		roles          []string
+		expectedStatus int
+	}{
+		{
+			name:           "no principal",
+			roles:          []string{"admin"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "role not allowed",
+			principal:      &model.Principal{UserID: 1, Role: "user"},
+			roles:          []string{"admin"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "one of roles",
+			principal:      &model.Principal{UserID: 1, Role: "moderator"},
+			roles:          []string{"admin", "moderator"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveWithPrincipal(RequireRole(tc.roles...), tc.principal)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *model.Principal
+		permissions    []string
+		expectedStatus int
+	}{
+		{
+			name:           "no principal",
+			permissions:    []string{"users:read"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing permission",
+			principal:      &model.Principal{UserID: 1, Permissions: []string{"users:read"}},
+			permissions:    []string{"users:read", "users:manage"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "all permissions",
+			principal:      &model.Principal{UserID: 1, Permissions: []string{"users:read", "users:manage"}},
+			permissions:    []string{"users:read", "users:manage"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveWithPrincipal(RequirePermission(tc.permissions...), tc.principal)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *model.Principal
+		expectedStatus int
+	}{
+		{
+			name:           "no principal",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "service client",
+			principal:      &model.Principal{ClientID: "billing", Scopes: []string{"users:read"}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "user",
+			principal:      &model.Principal{UserID: 1, Role: "user"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveWithPrincipal(RequireUser, tc.principal)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *model.Principal
+		expectedStatus int
+	}{
+		{
+			name:           "no principal",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "service client",
+			principal:      &model.Principal{ClientID: "billing"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "api key",
+			principal:      &model.Principal{UserID: 1, Role: "user", APIKeyID: 7},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "user session",
+			principal:      &model.Principal{UserID: 1, Role: "user", SessionID: "session-id"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveWithPrincipal(RequireSession, tc.principal)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *model.Principal
+		scopes         []string
+		expectedStatus int
+	}{
+		{
+			name:           "no principal",
+			scopes:         []string{"users:read"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing scope",
+			principal:      &model.Principal{ClientID: "billing", Scopes: []string{"users:read"}},
+			scopes:         []string{"users:read", "audit:read"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "user token has no scopes",
+			principal:      &model.Principal{UserID: 1, Role: "admin", Permissions: []string{"users:read"}},
+			scopes:         []string{"users:read"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "api key scopes are not client scopes",
+			principal:      &model.Principal{UserID: 1, APIKeyID: 7, Scopes: []string{"users:read"}, Permissions: []string{"users:read"}},
+			scopes:         []string{"users:read"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "all scopes",
+			principal:      &model.Principal{ClientID: "billing", Scopes: []string{"users:read", "audit:read"}},
+			scopes:         []string{"users:read", "audit:read"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveWithPrincipal(RequireScope(tc.scopes...), tc.principal)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func serveWithPrincipal(mw func(http.Handler) http.Handler, principal *model.Principal) *httptest.ResponseRecorder {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	if principal != nil {
+		req = req.WithContext(SetPrincipalToContext(req.Context(), principal))
+	}
+
+	rec := httptest.NewRecorder()
+	mw(next).ServeHTTP(rec, req)
+	return rec
+}
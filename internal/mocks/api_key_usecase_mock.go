@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockAPIKeyUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyUsecase) Create(ctx context.Context, userID int64, permissions []string, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	args := m.Called(ctx, userID, permissions, name, scopes, expiresAt)
+	key, _ := args.Get(0).(*model.APIKey)
+	return key, args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyUsecase) List(ctx context.Context, userID int64) ([]*model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	keys, _ := args.Get(0).([]*model.APIKey)
+	return keys, args.Error(1)
+}
+
+func (m *MockAPIKeyUsecase) Revoke(ctx context.Context, userID, id int64) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyUsecase) Authenticate(ctx context.Context, plainKey string) (*model.Principal, error) {
+	args := m.Called(ctx, plainKey)
+	principal, _ := args.Get(0).(*model.Principal)
+	return principal, args.Error(1)
+}
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditLogger struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogger) Log(ctx context.Context, event model.AuditEvent) {
+	m.Called(ctx, event)
+}
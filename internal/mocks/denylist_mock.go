@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockAccessTokenDenylist struct {
+	mock.Mock
+}
+
+func (m *MockAccessTokenDenylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenDenylist) RevokeAllUserTokens(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenDenylist) RevokeSession(ctx context.Context, sessionID string) error {
+	args := m.Called(ctx, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenDenylist) IsRevoked(ctx context.Context, jti, sessionID string, userID int64, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, jti, sessionID, userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
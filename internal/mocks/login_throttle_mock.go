@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockLoginThrottle struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottle) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginThrottle) RegisterFailure(ctx context.Context, email, ip string) (time.Duration, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginThrottle) Reset(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottle) Unlock(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, msg model.MailMessage) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
@@ -0,0 +1,39 @@
+package mocks
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFAUsecase struct {
+	mock.Mock
+}
+
+func (m *MockMFAUsecase) Enroll(ctx context.Context, userID int64, account, password, clientIP string) (model.MFAEnrollment, error) {
+	args := m.Called(ctx, userID, account, password, clientIP)
+	enrollment, _ := args.Get(0).(model.MFAEnrollment)
+	return enrollment, args.Error(1)
+}
+
+func (m *MockMFAUsecase) Confirm(ctx context.Context, userID int64, password, code, clientIP string) ([]string, error) {
+	args := m.Called(ctx, userID, password, code, clientIP)
+	codes, _ := args.Get(0).([]string)
+	return codes, args.Error(1)
+}
+
+func (m *MockMFAUsecase) Disable(ctx context.Context, userID int64, password, code, clientIP string) error {
+	args := m.Called(ctx, userID, password, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockMFAUsecase) RegenerateRecoveryCodes(ctx context.Context, userID int64, password, code, clientIP string) ([]string, error) {
+	args := m.Called(ctx, userID, password, code, clientIP)
+	codes, _ := args.Get(0).([]string)
+	return codes, args.Error(1)
+}
+
+func (m *MockMFAUsecase) VerifyCode(ctx context.Context, userID int64, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockOneTimeTokenCache struct {
+	mock.Mock
+}
+
+func (m *MockOneTimeTokenCache) Save(ctx context.Context, userID int64, hashedToken string, ttl time.Duration) error {
+	args := m.Called(ctx, userID, hashedToken, ttl)
+	return args.Error(0)
+}
+
+func (m *MockOneTimeTokenCache) Peek(ctx context.Context, hashedToken string) (int64, error) {
+	args := m.Called(ctx, hashedToken)
+	userID, _ := args.Get(0).(int64)
+	return userID, args.Error(1)
+}
+
+func (m *MockOneTimeTokenCache) Consume(ctx context.Context, hashedToken string) (int64, error) {
+	args := m.Called(ctx, hashedToken)
+	userID, _ := args.Get(0).(int64)
+	return userID, args.Error(1)
+}
@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPermissionRepository struct {
+	mock.Mock
+}
+
+func (m *MockPermissionRepository) GetRolePermissions(ctx context.Context, roleCode string) ([]string, error) {
+	args := m.Called(ctx, roleCode)
+	if perms, ok := args.Get(0).([]string); ok {
+		return perms, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRateLimiter struct {
+	mock.Mock
+}
+
+func (m *MockRateLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (model.RateLimitResult, error) {
+	args := m.Called(ctx, key, limit)
+	return args.Get(0).(model.RateLimitResult), args.Error(1)
+}
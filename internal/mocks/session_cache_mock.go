@@ -0,0 +1,93 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockSessionCache struct {
+	mock.Mock
+}
+
+func (m *MockSessionCache) SaveSession(ctx context.Context, s *cache.RefreshSession, ttl time.Duration) error {
+	args := m.Called(ctx, s, ttl)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) GetSession(ctx context.Context, tokenID string) (*cache.RefreshSession, error) {
+	args := m.Called(ctx, tokenID)
+	c := args.Get(0)
+	if c == nil {
+		return nil, args.Error(1)
+	}
+
+	refreshSession, ok := c.(*cache.RefreshSession)
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+
+	return refreshSession, args.Error(1)
+}
+
+func (m *MockSessionCache) DeleteSession(ctx context.Context, userID int64, tokenID string) error {
+	args := m.Called(ctx, userID, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) DeleteAllUserSessions(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) DeleteOtherUserSessions(ctx context.Context, userID int64, keepTokenID string) error {
+	args := m.Called(ctx, userID, keepTokenID)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) GetRefreshTokenId(ctx context.Context, hashedRefreshToken string) (string, error) {
+	args := m.Called(ctx, hashedRefreshToken)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSessionCache) SetRefreshTokenId(ctx context.Context, hashedRefreshToken string, refreshTokenID string, ttl time.Duration) error {
+	args := m.Called(ctx, hashedRefreshToken, refreshTokenID, ttl)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) DeleteRefreshTokenId(ctx context.Context, hashedRefreshToken string) error {
+	args := m.Called(ctx, hashedRefreshToken)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) RotateSession(ctx context.Context, oldSess, newSess *cache.RefreshSession, ttl time.Duration) error {
+	args := m.Called(ctx, oldSess, newSess, ttl)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) GetRotatedRefreshToken(ctx context.Context, hashedRefreshToken string) (*cache.RotatedRefreshToken, error) {
+	args := m.Called(ctx, hashedRefreshToken)
+	rotated, ok := args.Get(0).(*cache.RotatedRefreshToken)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return rotated, args.Error(1)
+}
+
+func (m *MockSessionCache) RevokeFamily(ctx context.Context, userID int64, familyID string) error {
+	args := m.Called(ctx, userID, familyID)
+	return args.Error(0)
+}
+
+func (m *MockSessionCache) ListUserSessions(ctx context.Context, userID int64) ([]*cache.RefreshSession, error) {
+	args := m.Called(ctx, userID)
+	sessions, ok := args.Get(0).([]*cache.RefreshSession)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return sessions, args.Error(1)
+}
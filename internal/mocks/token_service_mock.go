@@ -3,16 +3,16 @@ package mocks
 import (
 	"errors"
 	"github.com/Elaman1/full-project-mock/internal/domain/model"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 type MockTokenService struct {
 	mock.Mock
 }
 
-func (m *MockTokenService) GenerateAccessToken(user *model.User) (string, error) {
-	args := m.Called(user)
+func (m *MockTokenService) GenerateAccessToken(user *model.User, sessionID string) (string, error) {
+	args := m.Called(user, sessionID)
 	return args.String(0), args.Error(1)
 }
 
@@ -21,12 +21,27 @@ func (m *MockTokenService) GenerateRefreshToken() (tokenID, plainToken string, e
 	return args.String(0), args.String(1), args.Error(2)
 }
 
-func (m *MockTokenService) ParseToken(tokenStr string) (jwt.RegisteredClaims, error) {
+func (m *MockTokenService) GenerateClientToken(clientID string, scopes []string) (string, time.Duration, error) {
+	args := m.Called(clientID, scopes)
+	return args.String(0), args.Get(1).(time.Duration), args.Error(2)
+}
+
+func (m *MockTokenService) ParseToken(tokenStr string) (model.AccessClaims, error) {
 	args := m.Called(tokenStr)
-	jwtClaims, ok := args.Get(0).(jwt.RegisteredClaims)
+	jwtClaims, ok := args.Get(0).(model.AccessClaims)
 	if !ok {
-		return jwt.RegisteredClaims{}, errors.New("invalid token")
+		return model.AccessClaims{}, errors.New("invalid token")
 	}
 
 	return jwtClaims, args.Error(1)
 }
+
+func (m *MockTokenService) JWKS() model.JWKSet {
+	args := m.Called()
+	set, ok := args.Get(0).(model.JWKSet)
+	if !ok {
+		return model.JWKSet{}
+	}
+
+	return set
+}
@@ -0,0 +1,83 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"net/http"
+)
+
+type AccountHandler struct {
+	Usecase usecase.AccountUsecase
+}
+
+// ExportHandler Данные отдаются файлом, чтобы браузер сохранил его, а не показал
+func (a *AccountHandler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	export, err := a.Usecase.Export(r.Context(), principal.UserID)
+	if err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Export account error: %v", err), lgr)
+		return
+	}
+
+	filename := fmt.Sprintf("account-%d-%s.json", principal.UserID, export.ExportedAt.Format("20060102T150405Z"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	respond.WithSuccessJSON(w, http.StatusOK, export)
+}
+
+func (a *AccountHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	var deleteRequest DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&deleteRequest); err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err := deleteRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Delete account validation error: %v", err), lgr)
+		return
+	}
+
+	ip, _ := req.GetClientMeta(r)
+	purgeAt, err := a.Usecase.Delete(r.Context(), principal.UserID, deleteRequest.Password, ip)
+	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Delete account error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusAccepted, DeleteAccountResponse{PurgeAt: purgeAt.UTC()})
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperror.UserNotFoundErr):
+		return http.StatusNotFound
+	case errors.Is(err, apperror.WrongPasswordErr):
+		return http.StatusBadRequest
+	case errors.Is(err, apperror.TooManyLoginAttemptsErr):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,98 @@
+package account
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withPrincipal(r *http.Request) *http.Request {
+	return r.WithContext(middleware.SetPrincipalToContext(r.Context(), &model.Principal{UserID: userID, SessionID: "session-id"}))
+}
+
+func TestExportHandler(t *testing.T) {
+	uc := new(MockAccountUsecase)
+	uc.On("Export", mock.Anything, userID).Return(&model.AccountExport{
+		ExportedAt: now,
+		Profile:    &model.User{ID: userID, Email: "user@test.com", Password: "bcrypt-hash"},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	NewAccountHandler(uc).ExportHandler(rec, withPrincipal(httptest.NewRequest(http.MethodPost, "/auth/me/export", nil)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `attachment; filename="account-42-20260301T120000Z.json"`, rec.Header().Get("Content-Disposition"))
+	assert.Contains(t, rec.Body.String(), `"email":"user@test.com"`)
+	assert.NotContains(t, rec.Body.String(), "bcrypt-hash")
+	uc.AssertExpectations(t)
+}
+
+func TestDeleteHandler(t *testing.T) {
+	purgeAt := now.Add(30 * 24 * time.Hour)
+
+	cases := []struct {
+		name       string
+		body       string
+		setupMock  func(*MockAccountUsecase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "scheduled",
+			body: `{"password":"secret"}`,
+			setupMock: func(uc *MockAccountUsecase) {
+				uc.On("Delete", mock.Anything, userID, "secret", mock.Anything).Return(purgeAt, nil)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   `{"purge_at":"2026-03-31T12:00:00Z"}`,
+		},
+		{
+			name:       "password missing",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "wrong password",
+			body: `{"password":"wrong"}`,
+			setupMock: func(uc *MockAccountUsecase) {
+				uc.On("Delete", mock.Anything, userID, "wrong", mock.Anything).Return(time.Time{}, apperror.WrongPasswordErr)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "locked out",
+			body: `{"password":"secret"}`,
+			setupMock: func(uc *MockAccountUsecase) {
+				uc.On("Delete", mock.Anything, userID, "secret", mock.Anything).
+					Return(time.Time{}, &apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: 90 * time.Second})
+			},
+			wantStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := new(MockAccountUsecase)
+			if tc.setupMock != nil {
+				tc.setupMock(uc)
+			}
+
+			rec := httptest.NewRecorder()
+			req := withPrincipal(httptest.NewRequest(http.MethodDelete, "/auth/me", strings.NewReader(tc.body)))
+			NewAccountHandler(uc).DeleteHandler(rec, req)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+			}
+			uc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package account
+
+import (
+	"errors"
+	"time"
+)
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+func (r DeleteAccountRequest) Validate() error {
+	if r.Password == "" {
+		return errors.New("password is empty")
+	}
+
+	return nil
+}
+
+type DeleteAccountResponse struct {
+	// PurgeAt После этого времени данные удаляются окончательно
+	PurgeAt time.Time `json:"purge_at"`
+}
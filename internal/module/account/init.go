@@ -0,0 +1,23 @@
+package account
+
+import (
+	"database/sql"
+	"github.com/Elaman1/full-project-mock/internal/cache"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// InitAccountModule Выгрузка и удаление собираются из данных других модулей, поэтому их usecase передаются сюда
+func InitAccountModule(db *sql.DB, redisDB *redis.Client, accessDenylist domcache.AccessTokenDenylist, userRep repository.UserRepository, users usecase.UserUsecase, identities repository.IdentityRepository, apiKeys usecase.APIKeyUsecase, auditEvents usecase.AuditUsecase, auditLogger domaudit.AuditLogger, reauth *service.Reauthenticator, cfg config.Account) *AccountHandler {
+	accountUsecase := NewAccountUsecase(NewAccountRepository(db), userRep, users, identities, apiKeys, auditEvents, cache.NewSessionRedisRepository(redisDB), accessDenylist, auditLogger, reauth, cfg)
+	return NewAccountHandler(accountUsecase)
+}
+
+func NewAccountHandler(usecase usecase.AccountUsecase) *AccountHandler {
+	return &AccountHandler{Usecase: usecase}
+}
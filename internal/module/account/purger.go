@@ -0,0 +1,55 @@
+package account
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"log/slog"
+	"time"
+)
+
+const defaultPurgeInterval = time.Hour
+
+// Purger Периодически очищает аккаунты, у которых истек grace period.
+// Может работать на нескольких инстансах: повторная очистка ничего не делает
+type Purger struct {
+	Usecase  usecase.AccountUsecase
+	Logs     *slog.Logger
+	interval time.Duration
+}
+
+func NewPurger(uc usecase.AccountUsecase, cfg config.Account, logs *slog.Logger) *Purger {
+	if cfg.PurgeInterval == 0 {
+		cfg.PurgeInterval = defaultPurgeInterval
+	}
+
+	return &Purger{Usecase: uc, Logs: logs, interval: cfg.PurgeInterval}
+}
+
+// Run Чистит сразу и затем раз в interval, пока не отменен ctx
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	purged, err := p.Usecase.PurgeDeleted(ctx)
+	if err != nil && ctx.Err() == nil {
+		p.Logs.Error("account purge failed", "purged", purged, "error", err)
+		return
+	}
+
+	if purged > 0 {
+		p.Logs.Info("deleted accounts purged", "event", "account_purge", "purged", purged)
+	}
+}
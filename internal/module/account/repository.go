@@ -0,0 +1,104 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"time"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewAccountRepository(db *sql.DB) repository.AccountRepository {
+	return &Repository{DB: db}
+}
+
+func (a *Repository) MarkDeleted(ctx context.Context, userID int64, deletedAt time.Time) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := a.DB.ExecContext(ctxTimeout, "UPDATE users SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL", deletedAt.UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("delete user error: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+
+	if affected == 0 {
+		return apperror.UserNotFoundErr
+	}
+
+	return nil
+}
+
+func (a *Repository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	rows, err := a.DB.QueryContext(ctxTimeout,
+		"SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1 ORDER BY deleted_at, id LIMIT $2", before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list deleted users error: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan user id error: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list deleted users error: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (a *Repository) Purge(ctx context.Context, userID int64) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := a.DB.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx error: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctxTimeout, "DELETE FROM users WHERE id = $1 AND deleted_at IS NOT NULL", userID)
+	if err != nil {
+		return fmt.Errorf("purge user error: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+
+	// Аккаунт уже очищен, например другим инстансом
+	if affected == 0 {
+		return nil
+	}
+
+	// События остаются для журнала, но без адресов и устройств пользователя
+	if _, err = tx.ExecContext(ctxTimeout, "UPDATE logs SET ip = NULL, user_agent = NULL WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("anonymize logs error: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package account
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"time"
+)
+
+const (
+	defaultDeletionGracePeriod = 30 * 24 * time.Hour
+	purgeBatchSize             = 100
+)
+
+type Usecase struct {
+	Rep     repository.AccountRepository
+	UserRep repository.UserRepository
+	// Users Сессии отдаются в том же виде, что и в /auth/sessions
+	Users          usecase.UserUsecase
+	Identities     repository.IdentityRepository
+	APIKeys        usecase.APIKeyUsecase
+	AuditEvents    usecase.AuditUsecase
+	SessionCache   domcache.SessionCache
+	AccessDenylist domcache.AccessTokenDenylist
+	Audit          domaudit.AuditLogger
+	Reauth         *service.Reauthenticator // пароль при удалении, счетчик тот же, что у входа
+	GracePeriod    time.Duration
+	Now            func() time.Time
+}
+
+func NewAccountUsecase(rep repository.AccountRepository, userRep repository.UserRepository, users usecase.UserUsecase, identities repository.IdentityRepository, apiKeys usecase.APIKeyUsecase, auditEvents usecase.AuditUsecase, sessionCache domcache.SessionCache, accessDenylist domcache.AccessTokenDenylist, auditLogger domaudit.AuditLogger, reauth *service.Reauthenticator, cfg config.Account) usecase.AccountUsecase {
+	if cfg.DeletionGracePeriod == 0 {
+		cfg.DeletionGracePeriod = defaultDeletionGracePeriod
+	}
+
+	return &Usecase{
+		Rep:            rep,
+		UserRep:        userRep,
+		Users:          users,
+		Identities:     identities,
+		APIKeys:        apiKeys,
+		AuditEvents:    auditEvents,
+		SessionCache:   sessionCache,
+		AccessDenylist: accessDenylist,
+		Audit:          auditLogger,
+		Reauth:         reauth,
+		GracePeriod:    cfg.DeletionGracePeriod,
+		Now:            time.Now,
+	}
+}
+
+func (u *Usecase) Export(ctx context.Context, userID int64) (*model.AccountExport, error) {
+	user, err := u.UserRep.GetById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.Users.ListSessions(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := u.Identities.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := u.APIKeys.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog := make([]model.AuditEvent, 0)
+	err = u.AuditEvents.ExportEvents(ctx, model.AuditFilter{UserID: userID}, func(event model.AuditEvent) error {
+		auditLog = append(auditLog, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditAccountExport})
+	return &model.AccountExport{
+		ExportedAt: u.Now().UTC(),
+		Profile:    user,
+		Sessions:   sessions,
+		Identities: identities,
+		APIKeys:    apiKeys,
+		AuditLog:   auditLog,
+	}, nil
+}
+
+func (u *Usecase) Delete(ctx context.Context, userID int64, password, clientIP string) (time.Time, error) {
+	user, err := u.UserRep.GetById(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err = u.Reauth.Verify(ctx, user, password, clientIP, nil); err != nil {
+		return time.Time{}, err
+	}
+
+	deletedAt := u.Now()
+	if err = u.Rep.MarkDeleted(ctx, userID, deletedAt); err != nil {
+		return time.Time{}, err
+	}
+
+	// Аккаунт уже скрыт, и refresh по нему не пройдет. Если отзыв не удался, его повторит очистка
+	if err = u.revokeSessions(ctx, userID); err != nil {
+		service.LoggerFromContext(ctx).Warn("failed to revoke sessions of deleted account", "user_id", userID, "error", err)
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditAccountDelete})
+	return deletedAt.Add(u.GracePeriod), nil
+}
+
+func (u *Usecase) PurgeDeleted(ctx context.Context) (int, error) {
+	before := u.Now().Add(-u.GracePeriod)
+
+	purged := 0
+	for {
+		ids, err := u.Rep.ListDeletedBefore(ctx, before, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, id := range ids {
+			if err = u.purge(ctx, id); err != nil {
+				return purged, err
+			}
+
+			purged++
+		}
+
+		if len(ids) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (u *Usecase) purge(ctx context.Context, userID int64) error {
+	if err := u.revokeSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := u.Rep.Purge(ctx, userID); err != nil {
+		return err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditAccountPurge})
+	return nil
+}
+
+// revokeSessions Удаляет refresh-сессии и отзывает уже выданные access-токены
+func (u *Usecase) revokeSessions(ctx context.Context, userID int64) error {
+	if err := u.SessionCache.DeleteAllUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	return u.AccessDenylist.RevokeAllUserTokens(ctx, userID)
+}
+
+func (u *Usecase) recordAudit(ctx context.Context, event model.AuditEvent) {
+	if u.Audit == nil {
+		return
+	}
+
+	u.Audit.Log(ctx, event)
+}
@@ -0,0 +1,104 @@
+package account
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockAccountRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccountRepository) MarkDeleted(ctx context.Context, userID int64, deletedAt time.Time) error {
+	args := m.Called(ctx, userID, deletedAt)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	args := m.Called(ctx, before, limit)
+	ids, _ := args.Get(0).([]int64)
+	return ids, args.Error(1)
+}
+
+func (m *MockAccountRepository) Purge(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockUserRepository Аккаунту нужен только GetById
+type MockUserRepository struct {
+	repository.UserRepository
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetById(ctx context.Context, id int64) (*model.User, error) {
+	args := m.Called(ctx, id)
+	user, _ := args.Get(0).(*model.User)
+	return user, args.Error(1)
+}
+
+// MockUserUsecase Аккаунту нужен только ListSessions
+type MockUserUsecase struct {
+	usecase.UserUsecase
+	mock.Mock
+}
+
+func (m *MockUserUsecase) ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]model.Session, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	sessions, _ := args.Get(0).([]model.Session)
+	return sessions, args.Error(1)
+}
+
+// MockIdentityRepository Аккаунту нужен только ListByUser
+type MockIdentityRepository struct {
+	repository.IdentityRepository
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) ListByUser(ctx context.Context, userID int64) ([]model.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	identities, _ := args.Get(0).([]model.UserIdentity)
+	return identities, args.Error(1)
+}
+
+// MockAuditUsecase Аккаунту нужен только ExportEvents. События из Events передаются в write
+type MockAuditUsecase struct {
+	usecase.AuditUsecase
+	mock.Mock
+	Events []model.AuditEvent
+}
+
+func (m *MockAuditUsecase) ExportEvents(ctx context.Context, filter model.AuditFilter, write func(model.AuditEvent) error) error {
+	args := m.Called(ctx, filter)
+	for _, event := range m.Events {
+		if err := write(event); err != nil {
+			return err
+		}
+	}
+
+	return args.Error(0)
+}
+
+type MockAccountUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAccountUsecase) Export(ctx context.Context, userID int64) (*model.AccountExport, error) {
+	args := m.Called(ctx, userID)
+	export, _ := args.Get(0).(*model.AccountExport)
+	return export, args.Error(1)
+}
+
+func (m *MockAccountUsecase) Delete(ctx context.Context, userID int64, password, clientIP string) (time.Time, error) {
+	args := m.Called(ctx, userID, password, clientIP)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAccountUsecase) PurgeDeleted(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,250 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	userID   = int64(42)
+	password = "correct-password"
+	clientIP = "10.0.0.1"
+)
+
+var (
+	now       = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	customErr = errors.New("custom error")
+)
+
+type testDeps struct {
+	rep        *MockAccountRepository
+	userRep    *MockUserRepository
+	users      *MockUserUsecase
+	identities *MockIdentityRepository
+	apiKeys    *mocks.MockAPIKeyUsecase
+	events     *MockAuditUsecase
+	sessions   *mocks.MockSessionCache
+	denylist   *mocks.MockAccessTokenDenylist
+	audit      *mocks.MockAuditLogger
+	throttle   *mocks.MockLoginThrottle
+}
+
+func newTestUsecase() (*Usecase, *testDeps) {
+	d := &testDeps{
+		rep:        new(MockAccountRepository),
+		userRep:    new(MockUserRepository),
+		users:      new(MockUserUsecase),
+		identities: new(MockIdentityRepository),
+		apiKeys:    new(mocks.MockAPIKeyUsecase),
+		events:     new(MockAuditUsecase),
+		sessions:   new(mocks.MockSessionCache),
+		denylist:   new(mocks.MockAccessTokenDenylist),
+		audit:      new(mocks.MockAuditLogger),
+		throttle:   new(mocks.MockLoginThrottle),
+	}
+
+	uc := NewAccountUsecase(d.rep, d.userRep, d.users, d.identities, d.apiKeys, d.events, d.sessions, d.denylist, d.audit, service.NewReauthenticator(d.throttle, validator.PasswordPolicy{}), config.Account{}).(*Usecase)
+	uc.Now = func() time.Time { return now }
+	return uc, d
+}
+
+func (d *testDeps) assertExpectations(t *testing.T) {
+	d.rep.AssertExpectations(t)
+	d.userRep.AssertExpectations(t)
+	d.users.AssertExpectations(t)
+	d.identities.AssertExpectations(t)
+	d.apiKeys.AssertExpectations(t)
+	d.events.AssertExpectations(t)
+	d.sessions.AssertExpectations(t)
+	d.denylist.AssertExpectations(t)
+	d.audit.AssertExpectations(t)
+	d.throttle.AssertExpectations(t)
+}
+
+func expectAudit(audit *mocks.MockAuditLogger, action string) {
+	audit.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+		return e.Action == action && e.UserID == userID
+	})).Return()
+}
+
+func TestExport(t *testing.T) {
+	uc, d := newTestUsecase()
+	user := &model.User{ID: userID, Email: "user@test.com", Password: "hash"}
+	sessions := []model.Session{{ID: "session-id"}}
+	identities := []model.UserIdentity{{ID: 1, Provider: "google", Subject: "sub"}}
+	keys := []*model.APIKey{{ID: 7, Name: "ci"}}
+	d.events.Events = []model.AuditEvent{{ID: 2, Action: constants.AuditLoginSuccess}, {ID: 1, Action: constants.AuditRegister}}
+
+	d.userRep.On("GetById", mock.Anything, userID).Return(user, nil)
+	d.users.On("ListSessions", mock.Anything, userID, "").Return(sessions, nil)
+	d.identities.On("ListByUser", mock.Anything, userID).Return(identities, nil)
+	d.apiKeys.On("List", mock.Anything, userID).Return(keys, nil)
+	d.events.On("ExportEvents", mock.Anything, model.AuditFilter{UserID: userID}).Return(nil)
+	expectAudit(d.audit, constants.AuditAccountExport)
+
+	export, err := uc.Export(context.Background(), userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, &model.AccountExport{
+		ExportedAt: now,
+		Profile:    user,
+		Sessions:   sessions,
+		Identities: identities,
+		APIKeys:    keys,
+		AuditLog:   d.events.Events,
+	}, export)
+	d.assertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	hashed, err := hasher.HashPassword(password)
+	require.NoError(t, err)
+	user := &model.User{ID: userID, Email: "user@test.com", Password: hashed}
+
+	// Такой пароль нельзя установить сейчас, но хеш совпал бы: проверка длины должна сработать раньше argon2
+	longPassword := strings.Repeat("a", validator.DefaultMaxPasswordLength+1)
+	longHashed, err := hasher.HashPassword(longPassword)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		password   string
+		setupMocks func(*testDeps)
+		wantErr    error
+	}{
+		{
+			name:     "soft delete and revoke sessions",
+			password: password,
+			setupMocks: func(d *testDeps) {
+				d.userRep.On("GetById", mock.Anything, userID).Return(user, nil)
+				d.throttle.On("Check", mock.Anything, "user@test.com", clientIP).Return(time.Duration(0), nil)
+				d.throttle.On("Reset", mock.Anything, "user@test.com").Return(nil)
+				d.rep.On("MarkDeleted", mock.Anything, userID, now).Return(nil)
+				d.sessions.On("DeleteAllUserSessions", mock.Anything, userID).Return(nil)
+				d.denylist.On("RevokeAllUserTokens", mock.Anything, userID).Return(nil)
+				expectAudit(d.audit, constants.AuditAccountDelete)
+			},
+		},
+		{
+			name:     "revoke failure does not undo deletion",
+			password: password,
+			setupMocks: func(d *testDeps) {
+				d.userRep.On("GetById", mock.Anything, userID).Return(user, nil)
+				d.throttle.On("Check", mock.Anything, "user@test.com", clientIP).Return(time.Duration(0), nil)
+				d.throttle.On("Reset", mock.Anything, "user@test.com").Return(nil)
+				d.rep.On("MarkDeleted", mock.Anything, userID, now).Return(nil)
+				d.sessions.On("DeleteAllUserSessions", mock.Anything, userID).Return(customErr)
+				expectAudit(d.audit, constants.AuditAccountDelete)
+			},
+		},
+		{
+			name:     "wrong password",
+			password: "wrong-password",
+			setupMocks: func(d *testDeps) {
+				d.userRep.On("GetById", mock.Anything, userID).Return(user, nil)
+				d.throttle.On("Check", mock.Anything, "user@test.com", clientIP).Return(time.Duration(0), nil)
+				d.throttle.On("RegisterFailure", mock.Anything, "user@test.com", clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:     "locked out",
+			password: password,
+			setupMocks: func(d *testDeps) {
+				d.userRep.On("GetById", mock.Anything, userID).Return(user, nil)
+				d.throttle.On("Check", mock.Anything, "user@test.com", clientIP).Return(time.Minute, nil)
+			},
+			wantErr: apperror.TooManyLoginAttemptsErr,
+		},
+		{
+			name:     "password longer than policy is not hashed",
+			password: longPassword,
+			setupMocks: func(d *testDeps) {
+				d.userRep.On("GetById", mock.Anything, userID).Return(&model.User{ID: userID, Email: "user@test.com", Password: longHashed}, nil)
+				d.throttle.On("Check", mock.Anything, "user@test.com", clientIP).Return(time.Duration(0), nil)
+				d.throttle.On("RegisterFailure", mock.Anything, "user@test.com", clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:     "already deleted",
+			password: password,
+			setupMocks: func(d *testDeps) {
+				d.userRep.On("GetById", mock.Anything, userID).Return(nil, apperror.UserNotFoundErr)
+			},
+			wantErr: apperror.UserNotFoundErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, d := newTestUsecase()
+			tc.setupMocks(d)
+
+			purgeAt, err := uc.Delete(context.Background(), userID, tc.password, clientIP)
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.True(t, purgeAt.IsZero())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, now.Add(defaultDeletionGracePeriod), purgeAt)
+			}
+			d.assertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	before := now.Add(-defaultDeletionGracePeriod)
+
+	t.Run("purges in batches", func(t *testing.T) {
+		uc, d := newTestUsecase()
+		fullBatch := make([]int64, purgeBatchSize)
+		for i := range fullBatch {
+			fullBatch[i] = userID
+		}
+
+		d.rep.On("ListDeletedBefore", mock.Anything, before, purgeBatchSize).Return(fullBatch, nil).Once()
+		d.rep.On("ListDeletedBefore", mock.Anything, before, purgeBatchSize).Return([]int64{userID}, nil).Once()
+		d.sessions.On("DeleteAllUserSessions", mock.Anything, userID).Return(nil)
+		d.denylist.On("RevokeAllUserTokens", mock.Anything, userID).Return(nil)
+		d.rep.On("Purge", mock.Anything, userID).Return(nil)
+		expectAudit(d.audit, constants.AuditAccountPurge)
+
+		purged, err := uc.PurgeDeleted(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, purgeBatchSize+1, purged)
+		d.rep.AssertNumberOfCalls(t, "Purge", purgeBatchSize+1)
+		d.assertExpectations(t)
+	})
+
+	t.Run("stops on error", func(t *testing.T) {
+		uc, d := newTestUsecase()
+		d.rep.On("ListDeletedBefore", mock.Anything, before, purgeBatchSize).Return([]int64{userID, userID + 1}, nil)
+		d.sessions.On("DeleteAllUserSessions", mock.Anything, userID).Return(nil)
+		d.denylist.On("RevokeAllUserTokens", mock.Anything, userID).Return(nil)
+		d.rep.On("Purge", mock.Anything, userID).Return(customErr)
+
+		purged, err := uc.PurgeDeleted(context.Background())
+
+		assert.ErrorIs(t, err, customErr)
+		assert.Zero(t, purged)
+		d.assertExpectations(t)
+	})
+}
@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+type AdminHandler struct {
+	Usecase usecase.AdminUserUsecase
+}
+
+func (a *AdminHandler) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Filter validation error: %v", err), lgr)
+		return
+	}
+
+	users, total, err := a.Usecase.ListUsers(r.Context(), filter)
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("List users error: %v", err), lgr)
+		return
+	}
+
+	items := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		items = append(items, newUserResponse(user))
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, ListUsersResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+func (a *AdminHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	id, err := userIDParam(r)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid user id", lgr)
+		return
+	}
+
+	user, err := a.Usecase.GetUser(r.Context(), id)
+	if err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Get user error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+func (a *AdminHandler) BlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	actorID, id, ok := a.actionParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.Usecase.BlockUser(r.Context(), actorID, id); err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Block user error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "user blocked")
+}
+
+func (a *AdminHandler) UnblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	actorID, id, ok := a.actionParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.Usecase.UnblockUser(r.Context(), actorID, id); err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Unblock user error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "user unblocked")
+}
+
+func (a *AdminHandler) UnlockLoginHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	actorID, id, ok := a.actionParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.Usecase.UnlockLogin(r.Context(), actorID, id); err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Unlock login error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "login unlocked")
+}
+
+func (a *AdminHandler) ChangeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	actorID, id, ok := a.actionParams(w, r)
+	if !ok {
+		return
+	}
+
+	var changeRole ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&changeRole); err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err := changeRole.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Role validation error: %v", err), lgr)
+		return
+	}
+
+	if err := a.Usecase.ChangeRole(r.Context(), actorID, id, changeRole.Role); err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Change role error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "role changed")
+}
+
+// actionParams Кто выполняет действие и над кем. При ошибке ответ уже записан
+func (a *AdminHandler) actionParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return 0, 0, false
+	}
+
+	id, err := userIDParam(r)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid user id", lgr)
+		return 0, 0, false
+	}
+
+	return principal.UserID, id, true
+}
+
+func userIDParam(r *http.Request) (int64, error) {
+	return strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperror.UserNotFoundErr):
+		return http.StatusNotFound
+	case errors.Is(err, apperror.RoleNotFoundErr), errors.Is(err, apperror.SelfActionErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAdminRouter(uc *MockAdminUsecase) http.Handler {
+	handler := NewAdminHandler(uc)
+
+	r := chi.NewRouter()
+	r.Get("/admin/users", handler.ListUsersHandler)
+	r.Get("/admin/users/{id}", handler.GetUserHandler)
+	r.Post("/admin/users/{id}/block", handler.BlockUserHandler)
+	r.Post("/admin/users/{id}/unlock", handler.UnlockLoginHandler)
+	r.Patch("/admin/users/{id}/role", handler.ChangeRoleHandler)
+	return r
+}
+
+func adminRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := middleware.SetPrincipalToContext(context.Background(), &model.Principal{UserID: adminID, Role: "admin"})
+	return req.WithContext(ctx)
+}
+
+func TestListUsersHandler(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("success with filters", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+		uc.On("ListUsers", mock.Anything, mock.MatchedBy(func(f model.UserFilter) bool {
+			return f.Email == "test" && f.Role == "user" && f.Blocked != nil && *f.Blocked && f.Limit == 100 && f.Offset == 10
+		})).Return([]*model.User{
+			{ID: targetID, Email: "test@example.com", Username: "test", Password: "secret", CreatedAt: createdAt, Role: model.UserRole{Code: "user"}, Blocked: true},
+		}, 11, nil)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/users?email=test&role=user&blocked=true&limit=500&offset=10", ""))
+
+		body, _ := io.ReadAll(rec.Body)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"items":[{"id":7,"email":"test@example.com","username":"test","role":"user","blocked":true,"created_at":"2025-01-02T03:04:05Z"}],"total":11,"limit":100,"offset":10}`, string(body))
+		assert.NotContains(t, string(body), "secret")
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/users?created_from=yesterday", ""))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		uc.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetUserHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		setupMocks   func(uc *MockAdminUsecase)
+		expectedCode int
+	}{
+		{
+			name:   "success",
+			target: "/admin/users/7",
+			setupMocks: func(uc *MockAdminUsecase) {
+				uc.On("GetUser", mock.Anything, targetID).Return(&model.User{ID: targetID}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "not found",
+			target: "/admin/users/7",
+			setupMocks: func(uc *MockAdminUsecase) {
+				uc.On("GetUser", mock.Anything, targetID).Return(nil, apperror.UserNotFoundErr)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "bad id",
+			target:       "/admin/users/abc",
+			setupMocks:   func(*MockAdminUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := new(MockAdminUsecase)
+			tt.setupMocks(uc)
+
+			rec := httptest.NewRecorder()
+			newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodGet, tt.target, ""))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			uc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBlockUserHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+		uc.On("BlockUser", mock.Anything, adminID, targetID).Return(nil)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/users/7/block", ""))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("self block", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+		uc.On("BlockUser", mock.Anything, adminID, targetID).Return(apperror.SelfActionErr)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/users/7/block", ""))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/users/7/block", nil)
+		newAdminRouter(uc).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestUnlockLoginHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+		uc.On("UnlockLogin", mock.Anything, adminID, targetID).Return(nil)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/users/7/unlock", ""))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+		uc.On("UnlockLogin", mock.Anything, adminID, targetID).Return(apperror.UserNotFoundErr)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/users/7/unlock", ""))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestChangeRoleHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+		uc.On("ChangeRole", mock.Anything, adminID, targetID, "admin").Return(nil)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodPatch, "/admin/users/7/role", `{"role":"admin"}`))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("empty role", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodPatch, "/admin/users/7/role", `{"role":""}`))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		uc := new(MockAdminUsecase)
+		uc.On("ChangeRole", mock.Anything, adminID, targetID, "root").Return(apperror.RoleNotFoundErr)
+
+		rec := httptest.NewRecorder()
+		newAdminRouter(uc).ServeHTTP(rec, adminRequest(http.MethodPatch, "/admin/users/7/role", `{"role":"root"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
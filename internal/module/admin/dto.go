@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// UserResponse Пользователь в ответах админки, без пароля
+type UserResponse struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Blocked   bool      `json:"blocked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListUsersResponse struct {
+	Items  []UserResponse `json:"items"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (r ChangeRoleRequest) Validate() error {
+	if r.Role == "" {
+		return errors.New("role is empty")
+	}
+
+	return nil
+}
+
+func newUserResponse(user *model.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		Role:      user.Role.Code,
+		Blocked:   user.Blocked,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// parseUserFilter Фильтры из query: email, role, blocked, created_from, created_to (RFC 3339), limit, offset
+func parseUserFilter(query url.Values) (model.UserFilter, error) {
+	filter := model.UserFilter{
+		Email: query.Get("email"),
+		Role:  query.Get("role"),
+		Limit: defaultListLimit,
+	}
+
+	if v := query.Get("blocked"); v != "" {
+		blocked, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid blocked: %s", v)
+		}
+		filter.Blocked = &blocked
+	}
+
+	if v := query.Get("created_from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_from: %s", v)
+		}
+		filter.CreatedFrom = &from
+	}
+
+	if v := query.Get("created_to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_to: %s", v)
+		}
+		filter.CreatedTo = &to
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("invalid limit: %s", v)
+		}
+		filter.Limit = min(limit, maxListLimit)
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, fmt.Errorf("invalid offset: %s", v)
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,21 @@
+package admin
+
+import (
+	"database/sql"
+	"github.com/Elaman1/full-project-mock/internal/cache"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitAdminModule(db *sql.DB, redisDB *redis.Client, accessDenylist domcache.AccessTokenDenylist, loginThrottle domcache.LoginThrottle, auditLogger domaudit.AuditLogger) *AdminHandler {
+	sessionCache := cache.NewSessionRedisRepository(redisDB)
+	adminRepo := NewUserAdminRepository(db)
+	adminUsecase := NewAdminUsecase(adminRepo, sessionCache, accessDenylist, loginThrottle, auditLogger)
+	return NewAdminHandler(adminUsecase)
+}
+
+func NewAdminHandler(usecase usecase.AdminUserUsecase) *AdminHandler {
+	return &AdminHandler{Usecase: usecase}
+}
@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"strings"
+	"time"
+)
+
+// У старых записей email может быть NULL
+const selectAdminUserQuery = `SELECT u.id, COALESCE(u.email, ''), u.name, u.created_at, u.role_id, COALESCE(r.code, ''), COALESCE(r.name, ''), u.blocked, u.version
+	FROM users u LEFT JOIN roles r ON r.id = u.role_id`
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewUserAdminRepository(db *sql.DB) repository.UserAdminRepository {
+	return &Repository{DB: db}
+}
+
+func (a *Repository) List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	where, args := buildUserFilter(filter)
+
+	var total int
+	err := a.DB.QueryRowContext(ctxTimeout, "SELECT COUNT(*) FROM users u LEFT JOIN roles r ON r.id = u.role_id"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count error: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf("%s%s ORDER BY u.id LIMIT $%d OFFSET $%d", selectAdminUserQuery, where, len(args)-1, len(args))
+
+	rows, err := a.DB.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*model.User, 0, filter.Limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan error: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	return users, total, nil
+}
+
+func (a *Repository) GetById(ctx context.Context, id int64) (*model.User, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	user, err := scanUser(a.DB.QueryRowContext(ctxTimeout, selectAdminUserQuery+" WHERE u.id = $1 AND u.deleted_at IS NULL", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.UserNotFoundErr
+		}
+
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	return user, nil
+}
+
+func (a *Repository) SetBlocked(ctx context.Context, id int64, blocked bool) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := a.DB.ExecContext(ctxTimeout, "UPDATE users SET blocked = $1 WHERE id = $2 AND deleted_at IS NULL", blocked, id)
+	if err != nil {
+		return fmt.Errorf("update user error: %w", err)
+	}
+
+	return checkAffected(res)
+}
+
+func (a *Repository) UpdateRole(ctx context.Context, id int64, roleCode string) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var roleID int64
+	err := a.DB.QueryRowContext(ctxTimeout, "SELECT id FROM roles WHERE code = $1", roleCode).Scan(&roleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.RoleNotFoundErr
+		}
+
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	res, err := a.DB.ExecContext(ctxTimeout, "UPDATE users SET role_id = $1 WHERE id = $2 AND deleted_at IS NULL", roleID, id)
+	if err != nil {
+		return fmt.Errorf("update user error: %w", err)
+	}
+
+	return checkAffected(res)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*model.User, error) {
+	user := &model.User{}
+	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.CreatedAt, &user.RoleID, &user.Role.Code, &user.Role.Name, &user.Blocked, &user.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role.ID = user.RoleID
+	return user, nil
+}
+
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+
+	if affected == 0 {
+		return apperror.UserNotFoundErr
+	}
+
+	return nil
+}
+
+// buildUserFilter Собирает WHERE с плейсхолдерами, значения идут только через аргументы.
+// Удаленные пользователем аккаунты до очистки в выдачу не попадают
+func buildUserFilter(filter model.UserFilter) (string, []any) {
+	conds := []string{"u.deleted_at IS NULL"}
+	var args []any
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Email != "" {
+		add("u.email ILIKE $%d", "%"+escapeLike(filter.Email)+"%")
+	}
+
+	if filter.Role != "" {
+		add("r.code = $%d", filter.Role)
+	}
+
+	if filter.Blocked != nil {
+		add("u.blocked = $%d", *filter.Blocked)
+	}
+
+	if filter.CreatedFrom != nil {
+		add("u.created_at >= $%d", *filter.CreatedFrom)
+	}
+
+	if filter.CreatedTo != nil {
+		add("u.created_at < $%d", *filter.CreatedTo)
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBuildUserFilter(t *testing.T) {
+	blocked := false
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args := buildUserFilter(model.UserFilter{
+		Email:       "50%_off",
+		Role:        "user",
+		Blocked:     &blocked,
+		CreatedFrom: &from,
+	})
+
+	assert.Equal(t, " WHERE u.deleted_at IS NULL AND u.email ILIKE $1 AND r.code = $2 AND u.blocked = $3 AND u.created_at >= $4", where)
+	assert.Equal(t, []any{`%50\%\_off%`, "user", false, from}, args)
+
+	where, args = buildUserFilter(model.UserFilter{})
+	assert.Equal(t, " WHERE u.deleted_at IS NULL", where)
+	assert.Empty(t, args)
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+)
+
+type Usecase struct {
+	Rep            repository.UserAdminRepository
+	SessionCache   domcache.SessionCache
+	AccessDenylist domcache.AccessTokenDenylist
+	LoginThrottle  domcache.LoginThrottle
+	Audit          domaudit.AuditLogger
+}
+
+func NewAdminUsecase(rep repository.UserAdminRepository, sessionCache domcache.SessionCache, accessDenylist domcache.AccessTokenDenylist, loginThrottle domcache.LoginThrottle, auditLogger domaudit.AuditLogger) usecase.AdminUserUsecase {
+	return &Usecase{
+		Rep:            rep,
+		SessionCache:   sessionCache,
+		AccessDenylist: accessDenylist,
+		LoginThrottle:  loginThrottle,
+		Audit:          auditLogger,
+	}
+}
+
+func (u *Usecase) ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	return u.Rep.List(ctx, filter)
+}
+
+func (u *Usecase) GetUser(ctx context.Context, id int64) (*model.User, error) {
+	return u.Rep.GetById(ctx, id)
+}
+
+func (u *Usecase) BlockUser(ctx context.Context, actorID, id int64) error {
+	if actorID == id {
+		return apperror.SelfActionErr
+	}
+
+	if err := u.Rep.SetBlocked(ctx, id, true); err != nil {
+		return err
+	}
+
+	// Заблокированный пользователь выходит со всех устройств сразу
+	if err := u.SessionCache.DeleteAllUserSessions(ctx, id); err != nil {
+		return err
+	}
+
+	if err := u.AccessDenylist.RevokeAllUserTokens(ctx, id); err != nil {
+		return err
+	}
+
+	service.LoggerFromContext(ctx).Info("user blocked", "event", "user_blocked", "user_id", id, "actor_id", actorID)
+	u.recordAudit(ctx, actorID, id, constants.AuditAdminBlock, nil)
+	return nil
+}
+
+func (u *Usecase) UnblockUser(ctx context.Context, actorID, id int64) error {
+	if err := u.Rep.SetBlocked(ctx, id, false); err != nil {
+		return err
+	}
+
+	service.LoggerFromContext(ctx).Info("user unblocked", "event", "user_unblocked", "user_id", id, "actor_id", actorID)
+	u.recordAudit(ctx, actorID, id, constants.AuditAdminUnblock, nil)
+	return nil
+}
+
+func (u *Usecase) ChangeRole(ctx context.Context, actorID, id int64, roleCode string) error {
+	// Чтобы администратор случайно не снял с себя права
+	if actorID == id {
+		return apperror.SelfActionErr
+	}
+
+	if err := u.Rep.UpdateRole(ctx, id, roleCode); err != nil {
+		return err
+	}
+
+	// Роль зашита в access-токен, гасим старые токены. Refresh выдаст токен уже с новой ролью
+	if err := u.AccessDenylist.RevokeAllUserTokens(ctx, id); err != nil {
+		return err
+	}
+
+	service.LoggerFromContext(ctx).Info("user role changed", "event", "user_role_changed", "user_id", id, "actor_id", actorID, "role", roleCode)
+	u.recordAudit(ctx, actorID, id, constants.AuditAdminChangeRole, map[string]any{"role": roleCode})
+	return nil
+}
+
+func (u *Usecase) UnlockLogin(ctx context.Context, actorID, id int64) error {
+	user, err := u.Rep.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = u.LoginThrottle.Unlock(ctx, user.Email); err != nil {
+		return err
+	}
+
+	service.LoggerFromContext(ctx).Info("user login unlocked", "event", "login_unlocked", "user_id", id, "actor_id", actorID)
+	u.recordAudit(ctx, actorID, id, constants.AuditAdminUnlock, nil)
+	return nil
+}
+
+// recordAudit Событие пишется на пользователя, над которым совершено действие, администратор - в metadata.actor_id
+func (u *Usecase) recordAudit(ctx context.Context, actorID, id int64, action string, metadata map[string]any) {
+	if u.Audit == nil {
+		return
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]any, 1)
+	}
+	metadata["actor_id"] = actorID
+
+	u.Audit.Log(ctx, model.AuditEvent{UserID: id, Action: action, Metadata: metadata})
+}
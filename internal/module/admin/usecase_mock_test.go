@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	adminID  int64 = 1
+	targetID int64 = 7
+
+	customErr = errors.New("custom error")
+)
+
+type MockUserAdminRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserAdminRepository) List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	args := m.Called(ctx, filter)
+	users, ok := args.Get(0).([]*model.User)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+
+	return users, args.Int(1), args.Error(2)
+}
+
+func (m *MockUserAdminRepository) GetById(ctx context.Context, id int64) (*model.User, error) {
+	args := m.Called(ctx, id)
+	user, ok := args.Get(0).(*model.User)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return user, args.Error(1)
+}
+
+func (m *MockUserAdminRepository) SetBlocked(ctx context.Context, id int64, blocked bool) error {
+	args := m.Called(ctx, id, blocked)
+	return args.Error(0)
+}
+
+func (m *MockUserAdminRepository) UpdateRole(ctx context.Context, id int64, roleCode string) error {
+	args := m.Called(ctx, id, roleCode)
+	return args.Error(0)
+}
+
+type MockAdminUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAdminUsecase) ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	args := m.Called(ctx, filter)
+	users, ok := args.Get(0).([]*model.User)
+	if !ok {
+		return nil, 0, args.Error(2)
+	}
+
+	return users, args.Int(1), args.Error(2)
+}
+
+func (m *MockAdminUsecase) GetUser(ctx context.Context, id int64) (*model.User, error) {
+	args := m.Called(ctx, id)
+	user, ok := args.Get(0).(*model.User)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return user, args.Error(1)
+}
+
+func (m *MockAdminUsecase) BlockUser(ctx context.Context, actorID, id int64) error {
+	args := m.Called(ctx, actorID, id)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecase) UnblockUser(ctx context.Context, actorID, id int64) error {
+	args := m.Called(ctx, actorID, id)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecase) ChangeRole(ctx context.Context, actorID, id int64, roleCode string) error {
+	args := m.Called(ctx, actorID, id, roleCode)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecase) UnlockLogin(ctx context.Context, actorID, id int64) error {
+	args := m.Called(ctx, actorID, id)
+	return args.Error(0)
+}
@@ -0,0 +1,185 @@
+package admin
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestBlockUser(t *testing.T) {
+	cases := []struct {
+		name       string
+		actorID    int64
+		setupMocks func(*MockUserAdminRepository, *mocks.MockSessionCache, *mocks.MockAccessTokenDenylist)
+		wantErr    error
+	}{
+		{
+			name:    "success",
+			actorID: adminID,
+			setupMocks: func(repo *MockUserAdminRepository, cs *mocks.MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
+				repo.On("SetBlocked", mock.Anything, targetID, true).Return(nil)
+				cs.On("DeleteAllUserSessions", mock.Anything, targetID).Return(nil)
+				dl.On("RevokeAllUserTokens", mock.Anything, targetID).Return(nil)
+			},
+		},
+		{
+			name:       "block yourself",
+			actorID:    targetID,
+			setupMocks: func(*MockUserAdminRepository, *mocks.MockSessionCache, *mocks.MockAccessTokenDenylist) {},
+			wantErr:    apperror.SelfActionErr,
+		},
+		{
+			name:    "user not found",
+			actorID: adminID,
+			setupMocks: func(repo *MockUserAdminRepository, _ *mocks.MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				repo.On("SetBlocked", mock.Anything, targetID, true).Return(apperror.UserNotFoundErr)
+			},
+			wantErr: apperror.UserNotFoundErr,
+		},
+		{
+			name:    "delete sessions error",
+			actorID: adminID,
+			setupMocks: func(repo *MockUserAdminRepository, cs *mocks.MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				repo.On("SetBlocked", mock.Anything, targetID, true).Return(nil)
+				cs.On("DeleteAllUserSessions", mock.Anything, targetID).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name:    "revoke tokens error",
+			actorID: adminID,
+			setupMocks: func(repo *MockUserAdminRepository, cs *mocks.MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
+				repo.On("SetBlocked", mock.Anything, targetID, true).Return(nil)
+				cs.On("DeleteAllUserSessions", mock.Anything, targetID).Return(nil)
+				dl.On("RevokeAllUserTokens", mock.Anything, targetID).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserAdminRepository)
+			cs := new(mocks.MockSessionCache)
+			dl := new(mocks.MockAccessTokenDenylist)
+			tc.setupMocks(repo, cs, dl)
+
+			uc := NewAdminUsecase(repo, cs, dl, new(mocks.MockLoginThrottle), nil)
+			err := uc.BlockUser(context.Background(), tc.actorID, targetID)
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			cs.AssertExpectations(t)
+			dl.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnblockUser(t *testing.T) {
+	repo := new(MockUserAdminRepository)
+	repo.On("SetBlocked", mock.Anything, targetID, false).Return(nil)
+
+	// Событие пишется на пользователя, администратор - в metadata
+	audit := new(mocks.MockAuditLogger)
+	audit.On("Log", mock.Anything, model.AuditEvent{
+		UserID:   targetID,
+		Action:   constants.AuditAdminUnblock,
+		Metadata: map[string]any{"actor_id": adminID},
+	}).Return().Once()
+
+	uc := NewAdminUsecase(repo, new(mocks.MockSessionCache), new(mocks.MockAccessTokenDenylist), new(mocks.MockLoginThrottle), audit)
+	assert.NoError(t, uc.UnblockUser(context.Background(), adminID, targetID))
+	repo.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestChangeRole(t *testing.T) {
+	cases := []struct {
+		name       string
+		actorID    int64
+		setupMocks func(*MockUserAdminRepository, *mocks.MockAccessTokenDenylist)
+		wantErr    error
+	}{
+		{
+			name:    "success",
+			actorID: adminID,
+			setupMocks: func(repo *MockUserAdminRepository, dl *mocks.MockAccessTokenDenylist) {
+				repo.On("UpdateRole", mock.Anything, targetID, "admin").Return(nil)
+				dl.On("RevokeAllUserTokens", mock.Anything, targetID).Return(nil)
+			},
+		},
+		{
+			name:       "change own role",
+			actorID:    targetID,
+			setupMocks: func(*MockUserAdminRepository, *mocks.MockAccessTokenDenylist) {},
+			wantErr:    apperror.SelfActionErr,
+		},
+		{
+			name:    "unknown role",
+			actorID: adminID,
+			setupMocks: func(repo *MockUserAdminRepository, _ *mocks.MockAccessTokenDenylist) {
+				repo.On("UpdateRole", mock.Anything, targetID, "admin").Return(apperror.RoleNotFoundErr)
+			},
+			wantErr: apperror.RoleNotFoundErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserAdminRepository)
+			dl := new(mocks.MockAccessTokenDenylist)
+			tc.setupMocks(repo, dl)
+
+			uc := NewAdminUsecase(repo, new(mocks.MockSessionCache), dl, new(mocks.MockLoginThrottle), nil)
+			err := uc.ChangeRole(context.Background(), tc.actorID, targetID, "admin")
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			dl.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnlockLogin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo := new(MockUserAdminRepository)
+		throttle := new(mocks.MockLoginThrottle)
+		repo.On("GetById", mock.Anything, targetID).Return(&model.User{ID: targetID, Email: "user@test.com"}, nil)
+		throttle.On("Unlock", mock.Anything, "user@test.com").Return(nil)
+
+		uc := NewAdminUsecase(repo, new(mocks.MockSessionCache), new(mocks.MockAccessTokenDenylist), throttle, nil)
+		assert.NoError(t, uc.UnlockLogin(context.Background(), adminID, targetID))
+
+		repo.AssertExpectations(t)
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		repo := new(MockUserAdminRepository)
+		throttle := new(mocks.MockLoginThrottle)
+		repo.On("GetById", mock.Anything, targetID).Return(nil, apperror.UserNotFoundErr)
+
+		uc := NewAdminUsecase(repo, new(mocks.MockSessionCache), new(mocks.MockAccessTokenDenylist), throttle, nil)
+		assert.ErrorIs(t, uc.UnlockLogin(context.Background(), adminID, targetID), apperror.UserNotFoundErr)
+		throttle.AssertNotCalled(t, "Unlock", mock.Anything, mock.Anything)
+	})
+}
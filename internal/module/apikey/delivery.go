@@ -0,0 +1,100 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+type APIKeyHandler struct {
+	Usecase usecase.APIKeyUsecase
+}
+
+func (a *APIKeyHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	var createRequest CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&createRequest); err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err := createRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("API key validation error: %v", err), lgr)
+		return
+	}
+
+	key, plainKey, err := a.Usecase.Create(r.Context(), principal.UserID, principal.Permissions, createRequest.Name, createRequest.Scopes, createRequest.ExpiresAt)
+	if err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Create API key error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusCreated, CreateAPIKeyResponse{APIKey: key, Key: plainKey})
+}
+
+func (a *APIKeyHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	keys, err := a.Usecase.List(r.Context(), principal.UserID)
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("List API keys error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, APIKeysResponse{Items: keys})
+}
+
+func (a *APIKeyHandler) RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid api key id", lgr)
+		return
+	}
+
+	if err = a.Usecase.Revoke(r.Context(), principal.UserID, id); err != nil {
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("Revoke API key error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "api key revoked")
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperror.APIKeyNotFoundErr):
+		return http.StatusNotFound
+	case errors.Is(err, apperror.ScopeNotGrantedErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,134 @@
+package apikey
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testPrincipal = &model.Principal{UserID: userID, Role: "admin", SessionID: "session-id", Permissions: []string{"users:read"}}
+
+func withPrincipal(r *http.Request) *http.Request {
+	return r.WithContext(middleware.SetPrincipalToContext(r.Context(), testPrincipal))
+}
+
+func TestCreateHandler(t *testing.T) {
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name       string
+		body       string
+		setupMock  func(*mocks.MockAPIKeyUsecase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "created",
+			body: `{"name":"ci","scopes":["users:read"]}`,
+			setupMock: func(uc *mocks.MockAPIKeyUsecase) {
+				key := &model.APIKey{ID: 7, Name: "ci", Prefix: "sk_abcdefgh", KeyHash: "hash", Scopes: []string{"users:read"}, CreatedAt: createdAt}
+				uc.On("Create", mock.Anything, userID, testPrincipal.Permissions, "ci", []string{"users:read"}, (*time.Time)(nil)).Return(key, "sk_abcdefgh-rest", nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":7,"name":"ci","prefix":"sk_abcdefgh","scopes":["users:read"],"created_at":"2026-01-01T00:00:00Z","key":"sk_abcdefgh-rest"}`,
+		},
+		{
+			name:       "empty name",
+			body:       `{"scopes":["users:read"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "expiry in the past",
+			body:       `{"name":"ci","expires_at":"2020-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "scope not granted",
+			body: `{"name":"ci","scopes":["users:manage"]}`,
+			setupMock: func(uc *mocks.MockAPIKeyUsecase) {
+				uc.On("Create", mock.Anything, userID, testPrincipal.Permissions, "ci", []string{"users:manage"}, (*time.Time)(nil)).Return(nil, "", apperror.ScopeNotGrantedErr)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := new(mocks.MockAPIKeyUsecase)
+			if tc.setupMock != nil {
+				tc.setupMock(uc)
+			}
+
+			rec := httptest.NewRecorder()
+			req := withPrincipal(httptest.NewRequest(http.MethodPost, "/auth/api-keys", strings.NewReader(tc.body)))
+			NewAPIKeyHandler(uc).CreateHandler(rec, req)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+			}
+			uc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		setupMock  func(*mocks.MockAPIKeyUsecase)
+		wantStatus int
+	}{
+		{
+			name: "revoked",
+			id:   "7",
+			setupMock: func(uc *mocks.MockAPIKeyUsecase) {
+				uc.On("Revoke", mock.Anything, userID, int64(7)).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "not found",
+			id:   "8",
+			setupMock: func(uc *mocks.MockAPIKeyUsecase) {
+				uc.On("Revoke", mock.Anything, userID, int64(8)).Return(apperror.APIKeyNotFoundErr)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "bad id",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := new(mocks.MockAPIKeyUsecase)
+			if tc.setupMock != nil {
+				tc.setupMock(uc)
+			}
+
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", tc.id)
+			req := withPrincipal(httptest.NewRequest(http.MethodDelete, "/auth/api-keys/"+tc.id, nil))
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+
+			rec := httptest.NewRecorder()
+			NewAPIKeyHandler(uc).RevokeHandler(rec, req)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			uc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package apikey
+
+import (
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"time"
+	"unicode/utf8"
+)
+
+const maxNameLen = 100
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r CreateAPIKeyRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is empty")
+	}
+
+	if utf8.RuneCountInString(r.Name) > maxNameLen {
+		return errors.New("name is too long")
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+
+	return nil
+}
+
+// CreateAPIKeyResponse Единственный ответ, в котором есть сам ключ
+type CreateAPIKeyResponse struct {
+	*model.APIKey
+	Key string `json:"key"`
+}
+
+type APIKeysResponse struct {
+	Items []*model.APIKey `json:"items"`
+}
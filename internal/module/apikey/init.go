@@ -0,0 +1,17 @@
+package apikey
+
+import (
+	"database/sql"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+)
+
+func InitAPIKeyModule(db *sql.DB, auditLogger domaudit.AuditLogger) *APIKeyHandler {
+	apiKeyRepo := NewAPIKeyRepository(db)
+	apiKeyUsecase := NewAPIKeyUsecase(apiKeyRepo, auditLogger)
+	return NewAPIKeyHandler(apiKeyUsecase)
+}
+
+func NewAPIKeyHandler(usecase usecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{Usecase: usecase}
+}
@@ -0,0 +1,144 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/lib/pq"
+	"time"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) repository.APIKeyRepository {
+	return &Repository{DB: db}
+}
+
+func (a *Repository) Create(ctx context.Context, key *model.APIKey) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	err := a.DB.QueryRowContext(ctxTimeout, `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), utcOrNil(key.ExpiresAt)).
+		Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create api key error: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Repository) ListByUser(ctx context.Context, userID int64) ([]*model.APIKey, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	rows, err := a.DB.QueryContext(ctxTimeout, `
+		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys error: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*model.APIKey, 0)
+	for rows.Next() {
+		key := &model.APIKey{}
+		if err = rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan api key error: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list api keys error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a *Repository) Revoke(ctx context.Context, userID, id int64) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	result, err := a.DB.ExecContext(ctxTimeout,
+		"UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL",
+		time.Now().UTC(), id, userID)
+	if err != nil {
+		return fmt.Errorf("revoke api key error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revoke api key error: %w", err)
+	}
+
+	if affected == 0 {
+		return apperror.APIKeyNotFoundErr
+	}
+
+	return nil
+}
+
+func (a *Repository) GetByHash(ctx context.Context, keyHash string) (*model.APIKeyOwner, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	owner := &model.APIKeyOwner{}
+	key := &owner.Key
+	var email sql.NullString
+	err := a.DB.QueryRowContext(ctxTimeout, `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.scopes, k.expires_at, k.last_used_at, k.created_at,
+		       k.revoked_at IS NOT NULL, u.email, COALESCE(r.code, ''), u.blocked OR u.deleted_at IS NOT NULL
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		LEFT JOIN roles r ON r.id = u.role_id
+		WHERE k.key_hash = $1`, keyHash).
+		Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt,
+			&owner.Revoked, &email, &owner.Role, &owner.Blocked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.APIKeyNotFoundErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get api key error: %w", err)
+	}
+
+	owner.Email = email.String
+	return owner, nil
+}
+
+func (a *Repository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	usedAt = usedAt.UTC()
+	_, err := a.DB.ExecContext(ctxTimeout, `
+		UPDATE api_keys SET last_used_at = $1
+		WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)`,
+		usedAt, id, usedAt.Add(-time.Minute))
+	if err != nil {
+		return fmt.Errorf("touch api key error: %w", err)
+	}
+
+	return nil
+}
+
+func utcOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC()
+}
@@ -0,0 +1,128 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"slices"
+	"strings"
+	"time"
+)
+
+// displayPrefixLen Сколько символов ключа хранится открыто, чтобы узнать его в списке
+const displayPrefixLen = len(constants.APIKeyPrefix) + 8
+
+type Usecase struct {
+	Rep   repository.APIKeyRepository
+	Audit domaudit.AuditLogger
+	Now   func() time.Time
+}
+
+func NewAPIKeyUsecase(rep repository.APIKeyRepository, auditLogger domaudit.AuditLogger) usecase.APIKeyUsecase {
+	return &Usecase{
+		Rep:   rep,
+		Audit: auditLogger,
+		Now:   time.Now,
+	}
+}
+
+func (u *Usecase) Create(ctx context.Context, userID int64, permissions []string, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	// Ключ не может дать больше, чем есть у роли владельца
+	for _, scope := range scopes {
+		if !slices.Contains(permissions, scope) {
+			return nil, "", apperror.ScopeNotGrantedErr
+		}
+	}
+
+	token, err := hasher.GenerateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	plainKey := constants.APIKeyPrefix + token
+	key := &model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plainKey[:displayPrefixLen],
+		KeyHash:   hasher.Sha256Hex(plainKey),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		ExpiresAt: expiresAt,
+	}
+
+	if err = u.Rep.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{
+		UserID:   userID,
+		Action:   constants.AuditAPIKeyCreate,
+		Metadata: map[string]any{"api_key_id": key.ID, "prefix": key.Prefix, "scopes": key.Scopes},
+	})
+	return key, plainKey, nil
+}
+
+func (u *Usecase) List(ctx context.Context, userID int64) ([]*model.APIKey, error) {
+	return u.Rep.ListByUser(ctx, userID)
+}
+
+func (u *Usecase) Revoke(ctx context.Context, userID, id int64) error {
+	if err := u.Rep.Revoke(ctx, userID, id); err != nil {
+		return err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{
+		UserID:   userID,
+		Action:   constants.AuditAPIKeyRevoke,
+		Metadata: map[string]any{"api_key_id": id},
+	})
+	return nil
+}
+
+func (u *Usecase) Authenticate(ctx context.Context, plainKey string) (*model.Principal, error) {
+	if !strings.HasPrefix(plainKey, constants.APIKeyPrefix) {
+		return nil, apperror.InvalidAPIKeyErr
+	}
+
+	owner, err := u.Rep.GetByHash(ctx, hasher.Sha256Hex(plainKey))
+	if errors.Is(err, apperror.APIKeyNotFoundErr) {
+		return nil, apperror.InvalidAPIKeyErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.Now()
+	key := owner.Key
+	if owner.Revoked || owner.Blocked || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, apperror.InvalidAPIKeyErr
+	}
+
+	// Сбой записи last_used_at не должен ломать запрос
+	if err = u.Rep.TouchLastUsed(ctx, key.ID, now); err != nil {
+		service.LoggerFromContext(ctx).Warn("api key last used update failed", "api_key_id", key.ID, "error", err)
+	}
+
+	return &model.Principal{
+		UserID:   key.UserID,
+		Email:    owner.Email,
+		Role:     owner.Role,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+func (u *Usecase) recordAudit(ctx context.Context, event model.AuditEvent) {
+	if u.Audit == nil {
+		return
+	}
+
+	u.Audit.Log(ctx, event)
+}
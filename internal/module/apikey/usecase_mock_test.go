@@ -0,0 +1,39 @@
+package apikey
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) ListByUser(ctx context.Context, userID int64) ([]*model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	keys, _ := args.Get(0).([]*model.APIKey)
+	return keys, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, userID, id int64) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKeyOwner, error) {
+	args := m.Called(ctx, keyHash)
+	owner, _ := args.Get(0).(*model.APIKeyOwner)
+	return owner, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
@@ -0,0 +1,149 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	userID   = int64(42)
+	plainKey = "sk_test-plain-key"
+)
+
+var now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newUsecase(repo *MockAPIKeyRepository, auditLogger *mocks.MockAuditLogger) *Usecase {
+	uc := NewAPIKeyUsecase(repo, auditLogger).(*Usecase)
+	uc.Now = func() time.Time { return now }
+	return uc
+}
+
+func TestCreate(t *testing.T) {
+	permissions := []string{"users:read", "audit:read"}
+
+	t.Run("key is shown once and stored as hash", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+		auditLogger := new(mocks.MockAuditLogger)
+		var stored *model.APIKey
+		repo.On("Create", mock.Anything, mock.AnythingOfType("*model.APIKey")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*model.APIKey)
+			stored.ID = 7
+		}).Return(nil)
+		auditLogger.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+			return e.Action == constants.AuditAPIKeyCreate && e.UserID == userID && e.Metadata["api_key_id"] == int64(7)
+		})).Return()
+
+		expiresAt := now.Add(24 * time.Hour)
+		key, plain, err := newUsecase(repo, auditLogger).Create(context.Background(), userID, permissions, "ci", []string{"users:read", "users:read"}, &expiresAt)
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(plain, constants.APIKeyPrefix))
+		assert.Equal(t, plain[:displayPrefixLen], key.Prefix)
+		assert.Equal(t, hasher.Sha256Hex(plain), stored.KeyHash)
+		assert.NotContains(t, stored.KeyHash, plain)
+		assert.Equal(t, []string{"users:read"}, key.Scopes)
+		assert.Equal(t, userID, key.UserID)
+		assert.Equal(t, &expiresAt, key.ExpiresAt)
+
+		repo.AssertExpectations(t)
+		auditLogger.AssertExpectations(t)
+	})
+
+	t.Run("scope outside of role", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+
+		_, _, err := newUsecase(repo, nil).Create(context.Background(), userID, permissions, "ci", []string{"users:manage"}, nil)
+
+		assert.ErrorIs(t, err, apperror.ScopeNotGrantedErr)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	t.Run("audited", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+		auditLogger := new(mocks.MockAuditLogger)
+		repo.On("Revoke", mock.Anything, userID, int64(7)).Return(nil)
+		auditLogger.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+			return e.Action == constants.AuditAPIKeyRevoke && e.UserID == userID
+		})).Return()
+
+		require.NoError(t, newUsecase(repo, auditLogger).Revoke(context.Background(), userID, 7))
+		auditLogger.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := new(MockAPIKeyRepository)
+		repo.On("Revoke", mock.Anything, userID, int64(7)).Return(apperror.APIKeyNotFoundErr)
+
+		assert.ErrorIs(t, newUsecase(repo, new(mocks.MockAuditLogger)).Revoke(context.Background(), userID, 7), apperror.APIKeyNotFoundErr)
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	past := now.Add(-time.Minute)
+	owner := func(mutate func(*model.APIKeyOwner)) *model.APIKeyOwner {
+		o := &model.APIKeyOwner{
+			Key:   model.APIKey{ID: 7, UserID: userID, Prefix: plainKey[:displayPrefixLen], Scopes: []string{"users:read"}},
+			Email: "user@test.com",
+			Role:  "admin",
+		}
+		if mutate != nil {
+			mutate(o)
+		}
+		return o
+	}
+
+	cases := []struct {
+		name      string
+		key       string
+		owner     *model.APIKeyOwner
+		repoErr   error
+		touchErr  error
+		wantErr   error
+		wantTouch bool
+	}{
+		{name: "valid", key: plainKey, owner: owner(nil), wantTouch: true},
+		{name: "last used update failure is ignored", key: plainKey, owner: owner(nil), touchErr: errors.New("db down"), wantTouch: true},
+		{name: "jwt is not a key", key: "eyJhbGciOi", wantErr: apperror.InvalidAPIKeyErr},
+		{name: "unknown", key: plainKey, repoErr: apperror.APIKeyNotFoundErr, wantErr: apperror.InvalidAPIKeyErr},
+		{name: "revoked", key: plainKey, owner: owner(func(o *model.APIKeyOwner) { o.Revoked = true }), wantErr: apperror.InvalidAPIKeyErr},
+		{name: "blocked owner", key: plainKey, owner: owner(func(o *model.APIKeyOwner) { o.Blocked = true }), wantErr: apperror.InvalidAPIKeyErr},
+		{name: "expired", key: plainKey, owner: owner(func(o *model.APIKeyOwner) { o.Key.ExpiresAt = &past }), wantErr: apperror.InvalidAPIKeyErr},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(MockAPIKeyRepository)
+			if strings.HasPrefix(tc.key, constants.APIKeyPrefix) {
+				repo.On("GetByHash", mock.Anything, hasher.Sha256Hex(tc.key)).Return(tc.owner, tc.repoErr)
+			}
+			if tc.wantTouch {
+				repo.On("TouchLastUsed", mock.Anything, int64(7), now).Return(tc.touchErr)
+			}
+
+			principal, err := newUsecase(repo, nil).Authenticate(context.Background(), tc.key)
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Nil(t, principal)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &model.Principal{UserID: userID, Email: "user@test.com", Role: "admin", APIKeyID: 7, Scopes: []string{"users:read"}}, principal)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
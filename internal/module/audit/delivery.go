@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+var csvHeader = []string{"id", "user_id", "action", "ip", "user_agent", "trace_id", "metadata", "created_at"}
+
+type AuditHandler struct {
+	Usecase usecase.AuditUsecase
+}
+
+// ActivityHandler Последние события безопасности текущего пользователя
+func (a *AuditHandler) ActivityHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	filter := model.AuditFilter{UserID: principal.UserID}
+	if err := parsePage(r.URL.Query(), &filter); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Filter validation error: %v", err), lgr)
+		return
+	}
+
+	page, err := a.Usecase.ListEvents(r.Context(), filter)
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("List activity error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, newActivityResponse(page))
+}
+
+func (a *AuditHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err == nil {
+		err = parsePage(r.URL.Query(), &filter)
+	}
+
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Filter validation error: %v", err), lgr)
+		return
+	}
+
+	page, err := a.Usecase.ListEvents(r.Context(), filter)
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("List audit error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, newListAuditResponse(page))
+}
+
+// ExportHandler Выгрузка всех событий по фильтрам в CSV или NDJSON (format). Ответ пишется потоком,
+// поэтому ошибка посреди выгрузки только обрывает файл и пишется в лог
+func (a *AuditHandler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	// Кто выгрузил журнал, тоже важно для проверок
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Filter validation error: %v", err), lgr)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatCSV
+	}
+
+	var (
+		contentType string
+		newWriter   func(io.Writer) eventWriter
+	)
+
+	switch format {
+	case exportFormatCSV:
+		contentType, newWriter = "text/csv; charset=utf-8", newCSVEventWriter
+	case exportFormatNDJSON:
+		contentType, newWriter = "application/x-ndjson", newNDJSONEventWriter
+	default:
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported export format: %s", format), lgr)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	writer := newWriter(w)
+	count := 0
+	err = a.Usecase.ExportEvents(r.Context(), filter, func(event model.AuditEvent) error {
+		count++
+		return writer.Write(event)
+	})
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err != nil {
+		lgr.Error("audit export failed", "error", err, "exported", count)
+		return
+	}
+
+	lgr.Info("audit exported", "event", "audit_exported", "actor_id", principal.UserID, "format", format, "count", count)
+}
+
+type eventWriter interface {
+	Write(event model.AuditEvent) error
+	Flush() error
+}
+
+type csvEventWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+// newCSVEventWriter Заголовок пишется и для пустой выгрузки
+func newCSVEventWriter(w io.Writer) eventWriter {
+	return &csvEventWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvEventWriter) Write(event model.AuditEvent) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	metadata, err := marshalMetadata(event.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return c.w.Write([]string{
+		strconv.FormatInt(event.ID, 10),
+		strconv.FormatInt(event.UserID, 10),
+		csvSafe(event.Action),
+		csvSafe(event.IP),
+		csvSafe(event.UserAgent),
+		csvSafe(event.TraceID),
+		metadata,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func (c *csvEventWriter) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvEventWriter) writeHeader() error {
+	if c.wroteHeader {
+		return nil
+	}
+
+	c.wroteHeader = true
+	return c.w.Write(csvHeader)
+}
+
+// csvSafe User-Agent приходит от клиента: значение, начинающееся с формулы, табличный редактор выполнит при открытии
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+type ndjsonEventWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEventWriter(w io.Writer) eventWriter {
+	return &ndjsonEventWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonEventWriter) Write(event model.AuditEvent) error {
+	return n.enc.Encode(event)
+}
+
+func (n *ndjsonEventWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const userID int64 = 7
+
+var createdAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newAuditRouter(uc *MockAuditUsecase) http.Handler {
+	handler := NewAuditHandler(uc)
+
+	r := chi.NewRouter()
+	r.Get("/auth/activity", handler.ActivityHandler)
+	r.Get("/admin/audit", handler.ListHandler)
+	r.Get("/admin/audit/export", handler.ExportHandler)
+	return r
+}
+
+func auditRequest(target string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	ctx := middleware.SetPrincipalToContext(context.Background(), &model.Principal{UserID: userID, Role: "admin"})
+	return req.WithContext(ctx)
+}
+
+func TestActivityHandler(t *testing.T) {
+	t.Run("own events without metadata", func(t *testing.T) {
+		uc := new(MockAuditUsecase)
+		uc.On("ListEvents", mock.Anything, model.AuditFilter{UserID: userID, Limit: 5}).Return(model.AuditPage{
+			Items: []model.AuditEvent{{
+				ID:        3,
+				UserID:    userID,
+				Action:    "admin_block",
+				IP:        "203.0.113.7",
+				UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+				Metadata:  map[string]any{"actor_id": 1},
+				CreatedAt: createdAt,
+			}},
+			Next: &model.AuditCursor{CreatedAt: createdAt, ID: 3},
+		}, nil)
+
+		rec := httptest.NewRecorder()
+		newAuditRouter(uc).ServeHTTP(rec, auditRequest("/auth/activity?limit=5&user_id=1"))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"items":[{"id":3,"action":"admin_block","ip":"203.0.113.7","device":{"browser":"Chrome","os":"Windows","type":"desktop"},"created_at":"2025-01-02T03:04:05Z"}],"next_cursor":"`+encodeCursor(&model.AuditCursor{CreatedAt: createdAt, ID: 3})+`"}`, rec.Body.String())
+		assert.NotContains(t, rec.Body.String(), "actor_id")
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newAuditRouter(new(MockAuditUsecase)).ServeHTTP(rec, auditRequest("/auth/activity?cursor=bad"))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestListHandler(t *testing.T) {
+	cursor := &model.AuditCursor{CreatedAt: createdAt, ID: 10}
+
+	t.Run("filters and cursor", func(t *testing.T) {
+		from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		uc := new(MockAuditUsecase)
+		uc.On("ListEvents", mock.Anything, mock.MatchedBy(func(f model.AuditFilter) bool {
+			return f.UserID == 3 && f.Action == "login_failure" && f.IP == "2001:db8::1" &&
+				f.From != nil && f.From.Equal(from) && f.To == nil &&
+				f.After != nil && f.After.ID == 10 && f.After.CreatedAt.Equal(createdAt) && f.Limit == 100
+		})).Return(model.AuditPage{}, nil)
+
+		rec := httptest.NewRecorder()
+		target := "/admin/audit?user_id=3&action=login_failure&ip=2001:0db8::1&from=2025-01-01T00:00:00Z&limit=500&cursor=" + encodeCursor(cursor)
+		newAuditRouter(uc).ServeHTTP(rec, auditRequest(target))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"items":[]}`, rec.Body.String())
+		uc.AssertExpectations(t)
+	})
+
+	for _, query := range []string{"user_id=abc", "ip=nope", "from=yesterday", "limit=0"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newAuditRouter(new(MockAuditUsecase)).ServeHTTP(rec, auditRequest("/admin/audit?"+query))
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+
+	t.Run("usecase error", func(t *testing.T) {
+		uc := new(MockAuditUsecase)
+		uc.On("ListEvents", mock.Anything, mock.Anything).Return(model.AuditPage{}, customErr)
+
+		rec := httptest.NewRecorder()
+		newAuditRouter(uc).ServeHTTP(rec, auditRequest("/admin/audit"))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestExportHandler(t *testing.T) {
+	events := []model.AuditEvent{
+		{ID: 2, UserID: userID, Action: "login_failure", IP: "203.0.113.7", UserAgent: "=HYPERLINK(\"x\")", Metadata: map[string]any{"reason": "wrong_password"}, CreatedAt: createdAt},
+		{ID: 1, Action: "login_failure", CreatedAt: createdAt},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		uc := new(MockAuditUsecase)
+		uc.On("ExportEvents", mock.Anything, model.AuditFilter{Action: "login_failure"}, mock.Anything).Return(events, nil)
+
+		rec := httptest.NewRecorder()
+		newAuditRouter(uc).ServeHTTP(rec, auditRequest("/admin/audit/export?action=login_failure"))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment;")
+		assert.Equal(t, strings.Join([]string{
+			"id,user_id,action,ip,user_agent,trace_id,metadata,created_at",
+			`2,7,login_failure,203.0.113.7,"'=HYPERLINK(""x"")",,"{""reason"":""wrong_password""}",2025-01-02T03:04:05Z`,
+			"1,0,login_failure,,,,{},2025-01-02T03:04:05Z",
+			"",
+		}, "\n"), rec.Body.String())
+	})
+
+	t.Run("empty csv has header", func(t *testing.T) {
+		uc := new(MockAuditUsecase)
+		uc.On("ExportEvents", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+		rec := httptest.NewRecorder()
+		newAuditRouter(uc).ServeHTTP(rec, auditRequest("/admin/audit/export"))
+
+		assert.Equal(t, "id,user_id,action,ip,user_agent,trace_id,metadata,created_at\n", rec.Body.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		uc := new(MockAuditUsecase)
+		uc.On("ExportEvents", mock.Anything, mock.Anything, mock.Anything).Return(events[1:], nil)
+
+		rec := httptest.NewRecorder()
+		newAuditRouter(uc).ServeHTTP(rec, auditRequest("/admin/audit/export?format=ndjson"))
+
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"id":1,"action":"login_failure","created_at":"2025-01-02T03:04:05Z"}`, rec.Body.String())
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newAuditRouter(new(MockAuditUsecase)).ServeHTTP(rec, auditRequest("/admin/audit/export?format=xml"))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/pkg/useragent"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ActivityItem Событие в истории пользователя. Metadata не отдаем: там могут быть данные администратора
+type ActivityItem struct {
+	ID        int64            `json:"id"`
+	Action    string           `json:"action"`
+	IP        string           `json:"ip,omitempty"`
+	Device    useragent.Device `json:"device"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type ActivityResponse struct {
+	Items      []ActivityItem `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type ListAuditResponse struct {
+	Items      []model.AuditEvent `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func newActivityResponse(page model.AuditPage) ActivityResponse {
+	items := make([]ActivityItem, 0, len(page.Items))
+	for _, event := range page.Items {
+		items = append(items, ActivityItem{
+			ID:        event.ID,
+			Action:    event.Action,
+			IP:        event.IP,
+			Device:    useragent.Parse(event.UserAgent),
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return ActivityResponse{Items: items, NextCursor: encodeCursor(page.Next)}
+}
+
+func newListAuditResponse(page model.AuditPage) ListAuditResponse {
+	items := page.Items
+	if items == nil {
+		items = []model.AuditEvent{}
+	}
+
+	return ListAuditResponse{Items: items, NextCursor: encodeCursor(page.Next)}
+}
+
+// encodeCursor Курсор непрозрачный для клиента: base64 от "<unix nano>.<id>"
+func encodeCursor(cursor *model.AuditCursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	raw := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + "." + strconv.FormatInt(cursor.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(value string) (*model.AuditCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return nil, errors.New("invalid cursor")
+	}
+
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	cursorID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &model.AuditCursor{CreatedAt: time.Unix(0, createdAt).UTC(), ID: cursorID}, nil
+}
+
+// parsePage Общие для истории и журнала параметры: limit и cursor
+func parsePage(query url.Values, filter *model.AuditFilter) error {
+	filter.Limit = defaultListLimit
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("invalid limit: %s", v)
+		}
+		filter.Limit = min(limit, maxListLimit)
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return err
+		}
+		filter.After = cursor
+	}
+
+	return nil
+}
+
+// parseAuditFilter Фильтры из query: user_id, action, ip, from, to (RFC 3339)
+func parseAuditFilter(query url.Values) (model.AuditFilter, error) {
+	filter := model.AuditFilter{Action: query.Get("action")}
+
+	if v := query.Get("user_id"); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || userID <= 0 {
+			return filter, fmt.Errorf("invalid user_id: %s", v)
+		}
+		filter.UserID = userID
+	}
+
+	if v := query.Get("ip"); v != "" {
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid ip: %s", v)
+		}
+		filter.IP = addr.String()
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %s", v)
+		}
+		filter.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %s", v)
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
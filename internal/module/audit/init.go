@@ -0,0 +1,17 @@
+package audit
+
+import (
+	"database/sql"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+)
+
+// InitAuditModule Чтение журнала. Запись идет через AsyncLogger, он создается в bootstrap
+func InitAuditModule(db *sql.DB) *AuditHandler {
+	auditRepo := NewAuditRepository(db)
+	auditUsecase := NewAuditUsecase(auditRepo)
+	return NewAuditHandler(auditUsecase)
+}
+
+func NewAuditHandler(usecase usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{Usecase: usecase}
+}
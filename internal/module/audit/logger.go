@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBufferSize    = 1024
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	writeTimeout         = 5 * time.Second
+)
+
+// AsyncLogger Копит события в буфере и пишет их пакетами в фоне.
+// Если буфер полон, событие теряется с предупреждением в логе: запрос важнее журнала
+type AsyncLogger struct {
+	Rep           repository.AuditRepository
+	Logs          *slog.Logger
+	batchSize     int
+	flushInterval time.Duration
+	events        chan model.AuditEvent
+	quit          chan struct{}
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+var _ domaudit.AuditLogger = (*AsyncLogger)(nil)
+
+// NewAsyncLogger Сразу запускает фоновую запись. Перед остановкой сервиса нужно вызвать Close
+func NewAsyncLogger(rep repository.AuditRepository, cfg config.Audit, logs *slog.Logger) *AsyncLogger {
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+
+	a := &AsyncLogger{
+		Rep:           rep,
+		Logs:          logs,
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		events:        make(chan model.AuditEvent, cfg.BufferSize),
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	go a.run()
+	return a
+}
+
+func (a *AsyncLogger) Log(ctx context.Context, event model.AuditEvent) {
+	if event.IP == "" {
+		event.IP, _ = req.ClientIPFromContext(ctx)
+	}
+
+	if event.UserAgent == "" {
+		event.UserAgent = req.UserAgentFromContext(ctx)
+	}
+
+	if event.TraceID == "" {
+		event.TraceID = service.TraceIDFromContext(ctx)
+	}
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	select {
+	case a.events <- event:
+	default:
+		a.Logs.Warn("audit buffer is full, event dropped", "action", event.Action, "user_id", event.UserID)
+	}
+}
+
+// Close Дописывает накопленные события. События, пришедшие после Close, не пишутся
+func (a *AsyncLogger) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() { close(a.quit) })
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncLogger) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.AuditEvent, 0, a.batchSize)
+	for {
+		select {
+		case event := <-a.events:
+			batch = append(batch, event)
+			if len(batch) >= a.batchSize {
+				batch = a.flush(batch)
+			}
+		case <-ticker.C:
+			batch = a.flush(batch)
+		case <-a.quit:
+			a.drain(batch)
+			return
+		}
+	}
+}
+
+func (a *AsyncLogger) drain(batch []model.AuditEvent) {
+	for {
+		select {
+		case event := <-a.events:
+			batch = append(batch, event)
+			if len(batch) >= a.batchSize {
+				batch = a.flush(batch)
+			}
+		default:
+			a.flush(batch)
+			return
+		}
+	}
+}
+
+// flush Возвращает пустой пакет для переиспользования. При ошибке события теряются, повторять запись не пытаемся
+func (a *AsyncLogger) flush(batch []model.AuditEvent) []model.AuditEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if err := a.Rep.InsertBatch(ctx, batch); err != nil {
+		a.Logs.Error("failed to write audit events", "error", err, "count", len(batch))
+	}
+
+	return batch[:0]
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRepository Запоминает пакеты. block держит запись, чтобы заполнить буфер
+type fakeRepository struct {
+	mu      sync.Mutex
+	batches [][]model.AuditEvent
+	block   chan struct{}
+}
+
+func (f *fakeRepository) InsertBatch(_ context.Context, events []model.AuditEvent) error {
+	if f.block != nil {
+		<-f.block
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]model.AuditEvent(nil), events...))
+	return nil
+}
+
+func (f *fakeRepository) List(context.Context, model.AuditFilter) ([]model.AuditEvent, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) batchSizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sizes := make([]int, 0, len(f.batches))
+	for _, b := range f.batches {
+		sizes = append(sizes, len(b))
+	}
+
+	return sizes
+}
+
+func (f *fakeRepository) events() []model.AuditEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var events []model.AuditEvent
+	for _, b := range f.batches {
+		events = append(events, b...)
+	}
+
+	return events
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestAsyncLogger_BatchBySize(t *testing.T) {
+	repo := &fakeRepository{}
+	logger := NewAsyncLogger(repo, config.Audit{BatchSize: 2, FlushInterval: time.Hour}, discardLogger)
+
+	for i := 0; i < 5; i++ {
+		logger.Log(context.Background(), model.AuditEvent{UserID: int64(i + 1), Action: "login_success"})
+	}
+
+	assert.Eventually(t, func() bool { return len(repo.batchSizes()) == 2 }, time.Second, 5*time.Millisecond)
+
+	// Остаток дописывается при остановке
+	require.NoError(t, logger.Close(context.Background()))
+	assert.Equal(t, []int{2, 2, 1}, repo.batchSizes())
+}
+
+func TestAsyncLogger_FlushByInterval(t *testing.T) {
+	repo := &fakeRepository{}
+	logger := NewAsyncLogger(repo, config.Audit{BatchSize: 100, FlushInterval: 10 * time.Millisecond}, discardLogger)
+	defer logger.Close(context.Background())
+
+	logger.Log(context.Background(), model.AuditEvent{UserID: 1, Action: "logout"})
+
+	assert.Eventually(t, func() bool { return len(repo.events()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestAsyncLogger_DropWhenBufferFull(t *testing.T) {
+	repo := &fakeRepository{block: make(chan struct{})}
+	logger := NewAsyncLogger(repo, config.Audit{BufferSize: 1, BatchSize: 1, FlushInterval: time.Hour}, discardLogger)
+
+	// Первое событие висит в записи, второе ждет в буфере, остальные теряются без ожидания
+	logger.Log(context.Background(), model.AuditEvent{UserID: 1, Action: "refresh"})
+	assert.Eventually(t, func() bool { return len(logger.events) == 0 }, time.Second, time.Millisecond)
+	for i := 0; i < 3; i++ {
+		logger.Log(context.Background(), model.AuditEvent{UserID: 2, Action: "refresh"})
+	}
+
+	close(repo.block)
+	require.NoError(t, logger.Close(context.Background()))
+	assert.Len(t, repo.events(), 2)
+}
+
+func TestAsyncLogger_EnrichFromContext(t *testing.T) {
+	repo := &fakeRepository{}
+	logger := NewAsyncLogger(repo, config.Audit{}, discardLogger)
+
+	ctx := req.WithClientIP(context.Background(), "203.0.113.7")
+	ctx = req.WithUserAgent(ctx, "Mozilla/5.0")
+	ctx = service.WithTraceID(ctx, "trace-1")
+
+	logger.Log(ctx, model.AuditEvent{UserID: 1, Action: "register"})
+	// Явно переданный IP важнее контекста
+	logger.Log(ctx, model.AuditEvent{UserID: 1, Action: "login_success", IP: "198.51.100.1"})
+	require.NoError(t, logger.Close(context.Background()))
+
+	events := repo.events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "203.0.113.7", events[0].IP)
+	assert.Equal(t, "Mozilla/5.0", events[0].UserAgent)
+	assert.Equal(t, "trace-1", events[0].TraceID)
+	assert.False(t, events[0].CreatedAt.IsZero())
+	assert.Equal(t, "198.51.100.1", events[1].IP)
+}
@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"strings"
+	"time"
+)
+
+const insertColumns = 7
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) repository.AuditRepository {
+	return &Repository{DB: db}
+}
+
+// InsertBatch Одна вставка на весь пакет
+func (a *Repository) InsertBatch(ctx context.Context, events []model.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	values := make([]string, 0, len(events))
+	args := make([]any, 0, len(events)*insertColumns)
+	for i, event := range events {
+		metadata, err := marshalMetadata(event.Metadata)
+		if err != nil {
+			return fmt.Errorf("marshal audit metadata: %w", err)
+		}
+
+		n := i * insertColumns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+		args = append(args, nullableUserID(event.UserID), event.Action, nullableString(event.IP), nullableString(event.UserAgent),
+			nullableString(event.TraceID), metadata, event.CreatedAt.UTC())
+	}
+
+	query := "INSERT INTO logs (user_id, action, ip, user_agent, trace_id, metadata, created_at) VALUES " + strings.Join(values, ", ")
+	if _, err := a.DB.ExecContext(ctxTimeout, query, args...); err != nil {
+		return fmt.Errorf("insert audit events error: %w", err)
+	}
+
+	return nil
+}
+
+func (a *Repository) List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	where, args := buildAuditFilter(filter)
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf("%s%s ORDER BY created_at DESC, id DESC LIMIT $%d", selectAuditQuery, where, len(args))
+
+	rows, err := a.DB.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]model.AuditEvent, 0, filter.Limit)
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return events, nil
+}
+
+const selectAuditQuery = "SELECT id, COALESCE(user_id, 0), action, COALESCE(ip, ''), COALESCE(user_agent, ''), COALESCE(trace_id, ''), metadata, created_at FROM logs"
+
+func scanAuditEvent(rows *sql.Rows) (model.AuditEvent, error) {
+	var (
+		event    model.AuditEvent
+		metadata []byte
+	)
+
+	err := rows.Scan(&event.ID, &event.UserID, &event.Action, &event.IP, &event.UserAgent, &event.TraceID, &metadata, &event.CreatedAt)
+	if err != nil {
+		return event, err
+	}
+
+	if err = json.Unmarshal(metadata, &event.Metadata); err != nil {
+		return event, fmt.Errorf("unmarshal metadata: %w", err)
+	}
+
+	if len(event.Metadata) == 0 {
+		event.Metadata = nil
+	}
+
+	return event, nil
+}
+
+// buildAuditFilter created_at хранится без часового пояса в UTC, поэтому границы тоже приводим к UTC
+func buildAuditFilter(filter model.AuditFilter) (string, []any) {
+	var conds []string
+	var args []any
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		add("user_id = $%d", filter.UserID)
+	}
+
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+
+	if filter.IP != "" {
+		add("ip = $%d", filter.IP)
+	}
+
+	if filter.From != nil {
+		add("created_at >= $%d", filter.From.UTC())
+	}
+
+	if filter.To != nil {
+		add("created_at < $%d", filter.To.UTC())
+	}
+
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt.UTC(), filter.After.ID)
+		conds = append(conds, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func marshalMetadata(metadata map[string]any) (string, error) {
+	if len(metadata) == 0 {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func nullableUserID(userID int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: userID, Valid: userID != 0}
+}
+
+func nullableString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
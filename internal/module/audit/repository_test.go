@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBuildAuditFilter(t *testing.T) {
+	from := time.Date(2025, 1, 1, 3, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	after := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	where, args := buildAuditFilter(model.AuditFilter{
+		UserID: 7,
+		Action: "login_failure",
+		IP:     "203.0.113.7",
+		From:   &from,
+		After:  &model.AuditCursor{CreatedAt: after, ID: 42},
+		Limit:  20,
+	})
+
+	assert.Equal(t, " WHERE user_id = $1 AND action = $2 AND ip = $3 AND created_at >= $4 AND (created_at, id) < ($5, $6)", where)
+	// Граница в UTC, как и created_at в таблице
+	assert.Equal(t, []any{int64(7), "login_failure", "203.0.113.7", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), after, int64(42)}, args)
+
+	where, args = buildAuditFilter(model.AuditFilter{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+}
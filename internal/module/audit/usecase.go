@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+)
+
+// exportPageSize Сколько событий выгрузка читает из БД за один запрос
+const exportPageSize = 500
+
+type Usecase struct {
+	Rep repository.AuditRepository
+}
+
+func NewAuditUsecase(rep repository.AuditRepository) usecase.AuditUsecase {
+	return &Usecase{Rep: rep}
+}
+
+func (u *Usecase) ListEvents(ctx context.Context, filter model.AuditFilter) (model.AuditPage, error) {
+	limit := filter.Limit
+
+	// Лишняя запись показывает, есть ли следующая страница
+	filter.Limit = limit + 1
+	events, err := u.Rep.List(ctx, filter)
+	if err != nil {
+		return model.AuditPage{}, err
+	}
+
+	if len(events) <= limit {
+		return model.AuditPage{Items: events}, nil
+	}
+
+	events = events[:limit]
+	return model.AuditPage{Items: events, Next: cursorOf(events[limit-1])}, nil
+}
+
+func (u *Usecase) ExportEvents(ctx context.Context, filter model.AuditFilter, write func(model.AuditEvent) error) error {
+	filter.Limit = exportPageSize
+	for {
+		events, err := u.Rep.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err = write(event); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < exportPageSize {
+			return nil
+		}
+
+		filter.After = cursorOf(events[len(events)-1])
+	}
+}
+
+func cursorOf(event model.AuditEvent) *model.AuditCursor {
+	return &model.AuditCursor{CreatedAt: event.CreatedAt, ID: event.ID}
+}
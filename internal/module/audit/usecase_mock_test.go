@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+var customErr = errors.New("custom error")
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) InsertBatch(ctx context.Context, events []model.AuditEvent) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	args := m.Called(ctx, filter)
+	events, ok := args.Get(0).([]model.AuditEvent)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return events, args.Error(1)
+}
+
+type MockAuditUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAuditUsecase) ListEvents(ctx context.Context, filter model.AuditFilter) (model.AuditPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(model.AuditPage), args.Error(1)
+}
+
+func (m *MockAuditUsecase) ExportEvents(ctx context.Context, filter model.AuditFilter, write func(model.AuditEvent) error) error {
+	args := m.Called(ctx, filter, write)
+	events, _ := args.Get(0).([]model.AuditEvent)
+	for _, event := range events {
+		if err := write(event); err != nil {
+			return err
+		}
+	}
+
+	return args.Error(1)
+}
+
+// newEvents count событий от новых к старым, id по убыванию
+func newEvents(count int, start time.Time) []model.AuditEvent {
+	events := make([]model.AuditEvent, 0, count)
+	for i := 0; i < count; i++ {
+		events = append(events, model.AuditEvent{
+			ID:        int64(count - i),
+			UserID:    7,
+			Action:    "login_success",
+			CreatedAt: start.Add(-time.Duration(i) * time.Second),
+		})
+	}
+
+	return events
+}
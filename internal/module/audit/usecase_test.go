@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestListEvents(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		found    int
+		wantLen  int
+		wantNext *model.AuditCursor
+	}{
+		{name: "last page", found: 2, wantLen: 2},
+		{name: "exactly limit", found: 3, wantLen: 3},
+		// Четвертая запись только показывает, что есть следующая страница
+		{name: "has next page", found: 4, wantLen: 3, wantNext: &model.AuditCursor{CreatedAt: start.Add(-2 * time.Second), ID: 2}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockAuditRepository)
+			repo.On("List", mock.Anything, model.AuditFilter{UserID: 7, Limit: 4}).Return(newEvents(tc.found, start), nil)
+
+			page, err := NewAuditUsecase(repo).ListEvents(context.Background(), model.AuditFilter{UserID: 7, Limit: 3})
+			require.NoError(t, err)
+			assert.Len(t, page.Items, tc.wantLen)
+			assert.Equal(t, tc.wantNext, page.Next)
+			repo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := new(MockAuditRepository)
+		repo.On("List", mock.Anything, mock.Anything).Return(nil, customErr)
+
+		_, err := NewAuditUsecase(repo).ListEvents(context.Background(), model.AuditFilter{Limit: 3})
+		assert.ErrorIs(t, err, customErr)
+	})
+}
+
+func TestExportEvents(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := newEvents(exportPageSize, start)
+	last := first[exportPageSize-1]
+
+	repo := new(MockAuditRepository)
+	repo.On("List", mock.Anything, model.AuditFilter{Action: "logout", Limit: exportPageSize}).Return(first, nil).Once()
+	repo.On("List", mock.Anything, model.AuditFilter{
+		Action: "logout",
+		Limit:  exportPageSize,
+		After:  &model.AuditCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+	}).Return(newEvents(1, last.CreatedAt.Add(-time.Second)), nil).Once()
+
+	var written int
+	err := NewAuditUsecase(repo).ExportEvents(context.Background(), model.AuditFilter{Action: "logout", Limit: 10}, func(model.AuditEvent) error {
+		written++
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, exportPageSize+1, written)
+	repo.AssertExpectations(t)
+}
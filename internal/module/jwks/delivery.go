@@ -0,0 +1,18 @@
+package jwks
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"net/http"
+)
+
+type JWKSHandler struct {
+	TokenService usecase.TokenService
+}
+
+// KeysHandler Отдает публичные ключи, чтобы другие сервисы могли проверять access-токены
+func (h *JWKSHandler) KeysHandler(w http.ResponseWriter, r *http.Request) {
+	// Клиенты кешируют набор ключей, при неизвестном kid перезапрашивают
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respond.WithSuccessJSON(w, http.StatusOK, h.TokenService.JWKS())
+}
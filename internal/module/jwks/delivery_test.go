@@ -0,0 +1,34 @@
+package jwks
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeysHandler(t *testing.T) {
+	tokenSvc := new(mocks.MockTokenService)
+	tokenSvc.On("JWKS").Return(model.JWKSet{Keys: []model.JWK{
+		{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: "kid-1", N: "n", E: "AQAB"},
+	}})
+
+	handler := InitJWKSModule(tokenSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	handler.KeysHandler(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.NotEmpty(t, res.Header.Get("Cache-Control"))
+	assert.JSONEq(t, `{"keys":[{"kty":"RSA","use":"sig","alg":"RS256","kid":"kid-1","n":"n","e":"AQAB"}]}`, string(body))
+	tokenSvc.AssertExpectations(t)
+}
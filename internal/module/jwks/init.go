@@ -0,0 +1,7 @@
+package jwks
+
+import "github.com/Elaman1/full-project-mock/internal/domain/usecase"
+
+func InitJWKSModule(tokenService usecase.TokenService) *JWKSHandler {
+	return &JWKSHandler{TokenService: tokenService}
+}
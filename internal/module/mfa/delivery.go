@@ -0,0 +1,140 @@
+package mfa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"log/slog"
+	"net/http"
+)
+
+type MFAHandler struct {
+	Usecase usecase.MFAUsecase
+}
+
+func (m *MFAHandler) EnrollHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var enrollRequest EnrollRequest
+	principal, ok := requestParams(w, r, &enrollRequest, lgr)
+	if !ok {
+		return
+	}
+
+	ip, _ := req.GetClientMeta(r)
+	enrollment, err := m.Usecase.Enroll(r.Context(), principal.UserID, principal.Email, enrollRequest.CurrentPassword, ip)
+	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("MFA enroll error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, enrollment)
+}
+
+func (m *MFAHandler) ConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var reauthRequest ReauthRequest
+	principal, ok := requestParams(w, r, &reauthRequest, lgr)
+	if !ok {
+		return
+	}
+
+	ip, _ := req.GetClientMeta(r)
+	codes, err := m.Usecase.Confirm(r.Context(), principal.UserID, reauthRequest.CurrentPassword, reauthRequest.Code, ip)
+	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("MFA confirm error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (m *MFAHandler) DisableHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var reauthRequest ReauthRequest
+	principal, ok := requestParams(w, r, &reauthRequest, lgr)
+	if !ok {
+		return
+	}
+
+	ip, _ := req.GetClientMeta(r)
+	if err := m.Usecase.Disable(r.Context(), principal.UserID, reauthRequest.CurrentPassword, reauthRequest.Code, ip); err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("MFA disable error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "mfa disabled")
+}
+
+func (m *MFAHandler) RecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var reauthRequest ReauthRequest
+	principal, ok := requestParams(w, r, &reauthRequest, lgr)
+	if !ok {
+		return
+	}
+
+	ip, _ := req.GetClientMeta(r)
+	codes, err := m.Usecase.RegenerateRecoveryCodes(r.Context(), principal.UserID, reauthRequest.CurrentPassword, reauthRequest.Code, ip)
+	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, errorStatus(err), fmt.Sprintf("MFA recovery codes error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// requestParams Общая часть ручек с телом запроса: пользователь из контекста, разбор и проверка тела
+func requestParams(w http.ResponseWriter, r *http.Request, request interface{ Validate() error }, lgr *slog.Logger) (*model.Principal, bool) {
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return nil, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return nil, false
+	}
+
+	if err := request.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("MFA validation error: %v", err), lgr)
+		return nil, false
+	}
+
+	return principal, true
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperror.InvalidMFACodeErr),
+		errors.Is(err, apperror.WrongPasswordErr):
+		return http.StatusBadRequest
+	case errors.Is(err, apperror.TooManyLoginAttemptsErr):
+		return http.StatusTooManyRequests
+	case errors.Is(err, apperror.MFAAlreadyEnabledErr),
+		errors.Is(err, apperror.MFANotEnabledErr),
+		errors.Is(err, apperror.MFANotEnrolledErr):
+		return http.StatusConflict
+	case errors.Is(err, apperror.MFANotConfiguredErr):
+		return http.StatusNotImplemented
+	case errors.Is(err, apperror.UserNotFoundErr):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
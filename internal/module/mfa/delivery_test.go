@@ -0,0 +1,238 @@
+package mfa
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mfaRequest(body string, principal *model.Principal) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/auth/mfa", strings.NewReader(body))
+	if principal == nil {
+		return req
+	}
+
+	return req.WithContext(middleware.SetPrincipalToContext(context.Background(), principal))
+}
+
+func TestEnrollHandler(t *testing.T) {
+	principal := &model.Principal{UserID: userID, Email: "user@test.com"}
+
+	t.Run("success", func(t *testing.T) {
+		uc := new(mocks.MockMFAUsecase)
+		uc.On("Enroll", mock.Anything, userID, "user@test.com", "Secret-Pass1", mock.Anything).
+			Return(model.MFAEnrollment{Secret: testSecret, URI: "otpauth://totp/x"}, nil)
+
+		rec := httptest.NewRecorder()
+		NewMFAHandler(uc).EnrollHandler(rec, mfaRequest(`{"current_password":"Secret-Pass1"}`, principal))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"secret":"JBSWY3DPEHPK3PXP","otpauth_uri":"otpauth://totp/x"}`, rec.Body.String())
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		uc := new(mocks.MockMFAUsecase)
+		uc.On("Enroll", mock.Anything, userID, "user@test.com", "Secret-Pass1", mock.Anything).
+			Return(model.MFAEnrollment{}, apperror.MFANotConfiguredErr)
+
+		rec := httptest.NewRecorder()
+		NewMFAHandler(uc).EnrollHandler(rec, mfaRequest(`{"current_password":"Secret-Pass1"}`, principal))
+
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
+
+	t.Run("missing password", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewMFAHandler(new(mocks.MockMFAUsecase)).EnrollHandler(rec, mfaRequest(`{}`, principal))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("locked out", func(t *testing.T) {
+		uc := new(mocks.MockMFAUsecase)
+		uc.On("Enroll", mock.Anything, userID, "user@test.com", "Secret-Pass1", mock.Anything).
+			Return(model.MFAEnrollment{}, &apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: 90 * time.Second})
+
+		rec := httptest.NewRecorder()
+		NewMFAHandler(uc).EnrollHandler(rec, mfaRequest(`{"current_password":"Secret-Pass1"}`, principal))
+
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "90", rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewMFAHandler(new(mocks.MockMFAUsecase)).EnrollHandler(rec, mfaRequest(`{"current_password":"Secret-Pass1"}`, nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestConfirmHandler(t *testing.T) {
+	principal := &model.Principal{UserID: userID}
+
+	tests := []struct {
+		name         string
+		body         string
+		principal    *model.Principal
+		mockSetup    func(m *mocks.MockMFAUsecase)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "unauthorized",
+			body:         `{"current_password":"Secret-Pass1","code":"123456"}`,
+			mockSetup:    func(*mocks.MockMFAUsecase) {},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "empty code",
+			body:         `{"current_password":"Secret-Pass1","code":""}`,
+			principal:    principal,
+			mockSetup:    func(*mocks.MockMFAUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"MFA validation error: code is empty"}`,
+		},
+		{
+			name:         "missing password",
+			body:         `{"code":"123456"}`,
+			principal:    principal,
+			mockSetup:    func(*mocks.MockMFAUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"MFA validation error: current_password is empty"}`,
+		},
+		{
+			name:      "invalid code",
+			body:      `{"current_password":"Secret-Pass1","code":"123456"}`,
+			principal: principal,
+			mockSetup: func(m *mocks.MockMFAUsecase) {
+				m.On("Confirm", mock.Anything, userID, "Secret-Pass1", "123456", mock.Anything).Return(nil, apperror.InvalidMFACodeErr)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"MFA confirm error: invalid mfa code"}`,
+		},
+		{
+			name:      "not enrolled",
+			body:      `{"current_password":"Secret-Pass1","code":"123456"}`,
+			principal: principal,
+			mockSetup: func(m *mocks.MockMFAUsecase) {
+				m.On("Confirm", mock.Anything, userID, "Secret-Pass1", "123456", mock.Anything).Return(nil, apperror.MFANotEnrolledErr)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:      "success",
+			body:      `{"current_password":"Secret-Pass1","code":"123456"}`,
+			principal: principal,
+			mockSetup: func(m *mocks.MockMFAUsecase) {
+				m.On("Confirm", mock.Anything, userID, "Secret-Pass1", "123456", mock.Anything).Return([]string{"aaaaa-bbbbb", "ccccc-ddddd"}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"recovery_codes":["aaaaa-bbbbb","ccccc-ddddd"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := new(mocks.MockMFAUsecase)
+			tt.mockSetup(uc)
+
+			rec := httptest.NewRecorder()
+			NewMFAHandler(uc).ConfirmHandler(rec, mfaRequest(tt.body, tt.principal))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+
+			uc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDisableHandler(t *testing.T) {
+	principal := &model.Principal{UserID: userID}
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(uc *mocks.MockMFAUsecase)
+		expectedStatus int
+		retryAfter     string
+	}{
+		{
+			name: "success",
+			body: `{"current_password":"Secret-Pass1","code":"abcde-fghij"}`,
+			setup: func(uc *mocks.MockMFAUsecase) {
+				uc.On("Disable", mock.Anything, userID, "Secret-Pass1", "abcde-fghij", mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing password",
+			body:           `{"code":"123456"}`,
+			setup:          func(*mocks.MockMFAUsecase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "wrong password",
+			body: `{"current_password":"wrong","code":"123456"}`,
+			setup: func(uc *mocks.MockMFAUsecase) {
+				uc.On("Disable", mock.Anything, userID, "wrong", "123456", mock.Anything).Return(apperror.WrongPasswordErr)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "locked out",
+			body: `{"current_password":"Secret-Pass1","code":"123456"}`,
+			setup: func(uc *mocks.MockMFAUsecase) {
+				uc.On("Disable", mock.Anything, userID, "Secret-Pass1", "123456", mock.Anything).
+					Return(&apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			retryAfter:     "2",
+		},
+		{
+			name: "not enabled",
+			body: `{"current_password":"Secret-Pass1","code":"123456"}`,
+			setup: func(uc *mocks.MockMFAUsecase) {
+				uc.On("Disable", mock.Anything, userID, "Secret-Pass1", "123456", mock.Anything).Return(apperror.MFANotEnabledErr)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := new(mocks.MockMFAUsecase)
+			tt.setup(uc)
+
+			rec := httptest.NewRecorder()
+			NewMFAHandler(uc).DisableHandler(rec, mfaRequest(tt.body, principal))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.retryAfter, rec.Header().Get("Retry-After"))
+			uc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecoveryCodesHandler(t *testing.T) {
+	uc := new(mocks.MockMFAUsecase)
+	uc.On("RegenerateRecoveryCodes", mock.Anything, userID, "Secret-Pass1", "123456", mock.Anything).Return([]string{"aaaaa-bbbbb"}, nil)
+
+	rec := httptest.NewRecorder()
+	NewMFAHandler(uc).RecoveryCodesHandler(rec, mfaRequest(`{"current_password":"Secret-Pass1","code":"123456"}`, &model.Principal{UserID: userID}))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"recovery_codes":["aaaaa-bbbbb"]}`, rec.Body.String())
+}
@@ -0,0 +1,46 @@
+package mfa
+
+import "errors"
+
+type CodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (r CodeRequest) Validate() error {
+	if r.Code == "" {
+		return errors.New("code is empty")
+	}
+
+	return nil
+}
+
+// EnrollRequest Привязка аутентификатора требует текущий пароль
+type EnrollRequest struct {
+	CurrentPassword string `json:"current_password"`
+}
+
+func (r EnrollRequest) Validate() error {
+	if r.CurrentPassword == "" {
+		return errors.New("current_password is empty")
+	}
+
+	return nil
+}
+
+// ReauthRequest Включение и отключение MFA, смена кодов восстановления требуют текущий пароль и код
+type ReauthRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+func (r ReauthRequest) Validate() error {
+	if err := (EnrollRequest{CurrentPassword: r.CurrentPassword}).Validate(); err != nil {
+		return err
+	}
+
+	return CodeRequest{Code: r.Code}.Validate()
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
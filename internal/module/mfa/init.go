@@ -0,0 +1,21 @@
+package mfa
+
+import (
+	"database/sql"
+	"github.com/Elaman1/full-project-mock/internal/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// InitMFAModule crypter может быть nil, тогда включить MFA нельзя, но коды восстановления работают
+func InitMFAModule(db *sql.DB, redisDB *redis.Client, userRep repository.UserRepository, crypter SecretCrypter, reauth *service.Reauthenticator, issuer string) *MFAHandler {
+	mfaRepo := NewMFARepository(db)
+	mfaUsecase := NewMFAUsecase(mfaRepo, userRep, crypter, cache.NewTOTPStepRedis(redisDB), reauth, issuer)
+	return NewMFAHandler(mfaUsecase)
+}
+
+func NewMFAHandler(usecase usecase.MFAUsecase) *MFAHandler {
+	return &MFAHandler{Usecase: usecase}
+}
@@ -0,0 +1,149 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"time"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) repository.MFARepository {
+	return &Repository{DB: db}
+}
+
+func (m *Repository) Get(ctx context.Context, userID int64) (*model.UserMFA, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	state := &model.UserMFA{}
+	err := m.DB.QueryRowContext(ctxTimeout, "SELECT COALESCE(mfa_secret, ''), mfa_enabled_at FROM users WHERE id = $1", userID).
+		Scan(&state.Secret, &state.EnabledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.UserNotFoundErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	return state, nil
+}
+
+// SavePendingSecret Секрет уже включенного MFA не перезаписываем
+func (m *Repository) SavePendingSecret(ctx context.Context, userID int64, encryptedSecret string) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := m.DB.ExecContext(ctxTimeout, "UPDATE users SET mfa_secret = $2 WHERE id = $1 AND mfa_enabled_at IS NULL", userID, encryptedSecret)
+	if err != nil {
+		return fmt.Errorf("save mfa secret error: %w", err)
+	}
+
+	return checkAffected(res, apperror.MFAAlreadyEnabledErr)
+}
+
+func (m *Repository) Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE users SET mfa_enabled_at = now() WHERE id = $1 AND mfa_secret IS NOT NULL AND mfa_enabled_at IS NULL", userID)
+		if err != nil {
+			return fmt.Errorf("enable mfa error: %w", err)
+		}
+
+		if err = checkAffected(res, apperror.MFAAlreadyEnabledErr); err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	})
+}
+
+func (m *Repository) Disable(ctx context.Context, userID int64) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE users SET mfa_secret = NULL, mfa_enabled_at = NULL WHERE id = $1", userID)
+		if err != nil {
+			return fmt.Errorf("disable mfa error: %w", err)
+		}
+
+		if err = checkAffected(res, apperror.UserNotFoundErr); err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, nil)
+	})
+}
+
+func (m *Repository) ReplaceRecoveryCodes(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	})
+}
+
+func (m *Repository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := m.DB.ExecContext(ctxTimeout, "UPDATE mfa_recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL", userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code error: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected error: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (m *Repository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx error: %w", err)
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replaceRecoveryCodes Старые коды, в том числе неиспользованные, удаляются
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("delete recovery codes error: %w", err)
+	}
+
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash); err != nil {
+			return fmt.Errorf("insert recovery code error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func checkAffected(res sql.Result, notAffectedErr error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+
+	if affected == 0 {
+		return notAffectedErr
+	}
+
+	return nil
+}
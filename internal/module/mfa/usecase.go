@@ -0,0 +1,273 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/totp"
+	"strings"
+	"time"
+)
+
+const recoveryCodesCount = 10
+
+// usedStepTTL Код нельзя повторить, пока он принимается с учетом расхождения часов
+const usedStepTTL = (2*totp.Skew + 1) * totp.Period
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// SecretCrypter Шифрование TOTP-секрета перед записью в БД
+type SecretCrypter interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+type Usecase struct {
+	Rep repository.MFARepository
+	// UserRep Пароль и email для подтверждения текущим паролем
+	UserRep   repository.UserRepository
+	Crypter   SecretCrypter // nil, если ключ шифрования не задан
+	UsedSteps domcache.TOTPStepCache
+	// Reauth Счетчик общий с /login/mfa: перебор здесь блокирует и вход
+	Reauth *service.Reauthenticator
+	Issuer string
+	Now    func() time.Time
+}
+
+func NewMFAUsecase(rep repository.MFARepository, userRep repository.UserRepository, crypter SecretCrypter, usedSteps domcache.TOTPStepCache, reauth *service.Reauthenticator, issuer string) usecase.MFAUsecase {
+	return &Usecase{
+		Rep:       rep,
+		UserRep:   userRep,
+		Crypter:   crypter,
+		UsedSteps: usedSteps,
+		Reauth:    reauth,
+		Issuer:    issuer,
+		Now:       time.Now,
+	}
+}
+
+func (u *Usecase) Enroll(ctx context.Context, userID int64, account, password, clientIP string) (model.MFAEnrollment, error) {
+	if u.Crypter == nil {
+		return model.MFAEnrollment{}, apperror.MFANotConfiguredErr
+	}
+
+	// Иначе украденный access токен позволяет привязать свой аутентификатор
+	if err := u.reauthenticate(ctx, userID, password, clientIP, nil); err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	state, err := u.Rep.Get(ctx, userID)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	if state.EnabledAt != nil {
+		return model.MFAEnrollment{}, apperror.MFAAlreadyEnabledErr
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	encrypted, err := u.Crypter.Encrypt(secret)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	// Повторный enroll до подтверждения просто заменяет секрет
+	if err = u.Rep.SavePendingSecret(ctx, userID, encrypted); err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	return model.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(u.Issuer, account, secret),
+	}, nil
+}
+
+func (u *Usecase) Confirm(ctx context.Context, userID int64, password, code, clientIP string) ([]string, error) {
+	state, err := u.Rep.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.EnabledAt != nil {
+		return nil, apperror.MFAAlreadyEnabledErr
+	}
+
+	if state.Secret == "" {
+		return nil, apperror.MFANotEnrolledErr
+	}
+
+	// Код принимаем только TOTP: так проверяем, что приложение настроено верно
+	err = u.reauthenticate(ctx, userID, password, clientIP, func(ctx context.Context) error {
+		return u.verifyTOTP(ctx, userID, state.Secret, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.Rep.Enable(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	service.LoggerFromContext(ctx).Info("mfa enabled", "event", "mfa_enabled", "user_id", userID)
+	return codes, nil
+}
+
+func (u *Usecase) Disable(ctx context.Context, userID int64, password, code, clientIP string) error {
+	if err := u.reauthenticate(ctx, userID, password, clientIP, u.codeVerifier(userID, code)); err != nil {
+		return err
+	}
+
+	if err := u.Rep.Disable(ctx, userID); err != nil {
+		return err
+	}
+
+	service.LoggerFromContext(ctx).Info("mfa disabled", "event", "mfa_disabled", "user_id", userID)
+	return nil
+}
+
+func (u *Usecase) RegenerateRecoveryCodes(ctx context.Context, userID int64, password, code, clientIP string) ([]string, error) {
+	if err := u.reauthenticate(ctx, userID, password, clientIP, u.codeVerifier(userID, code)); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.Rep.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	service.LoggerFromContext(ctx).Info("mfa recovery codes regenerated", "event", "mfa_recovery_codes_regenerated", "user_id", userID)
+	return codes, nil
+}
+
+func (u *Usecase) VerifyCode(ctx context.Context, userID int64, code string) error {
+	state, err := u.Rep.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if state.EnabledAt == nil {
+		return apperror.MFANotEnabledErr
+	}
+
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		return u.verifyTOTP(ctx, userID, state.Secret, code)
+	}
+
+	ok, err := u.Rep.UseRecoveryCode(ctx, userID, hasher.Sha256Hex(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return apperror.InvalidMFACodeErr
+	}
+
+	service.LoggerFromContext(ctx).Warn("mfa recovery code used", "event", "mfa_recovery_code_used", "user_id", userID)
+	return nil
+}
+
+// reauthenticate Украденного access токена недостаточно: нужен текущий пароль, а для опасных действий и код
+func (u *Usecase) reauthenticate(ctx context.Context, userID int64, password, clientIP string, secondFactor func(ctx context.Context) error) error {
+	user, err := u.UserRep.GetById(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return u.Reauth.Verify(ctx, user, password, clientIP, secondFactor)
+}
+
+func (u *Usecase) codeVerifier(userID int64, code string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return u.VerifyCode(ctx, userID, code)
+	}
+}
+
+func (u *Usecase) verifyTOTP(ctx context.Context, userID int64, encryptedSecret, code string) error {
+	if u.Crypter == nil {
+		return apperror.MFANotConfiguredErr
+	}
+
+	secret, err := u.Crypter.Decrypt(encryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret, code, u.Now())
+	if !ok {
+		return apperror.InvalidMFACodeErr
+	}
+
+	// Перехваченный код нельзя использовать второй раз
+	fresh, err := u.UsedSteps.MarkUsed(ctx, userID, step, usedStepTTL)
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		return apperror.InvalidMFACodeErr
+	}
+
+	return nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// generateRecoveryCodes Коды вида xxxxx-xxxxx, в БД уходят только хеши
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, errors.New("failed to generate recovery code")
+		}
+
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hasher.Sha256Hex(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode Пользователь может ввести код без дефиса или заглавными буквами
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
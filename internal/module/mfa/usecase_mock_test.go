@@ -0,0 +1,91 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+var (
+	userID int64 = 7
+
+	userEmail = "user@test.com"
+	password  = "Secret-Pass1"
+	clientIP  = "10.0.0.1"
+
+	customErr = errors.New("custom error")
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) Get(ctx context.Context, userID int64) (*model.UserMFA, error) {
+	args := m.Called(ctx, userID)
+	state, _ := args.Get(0).(*model.UserMFA)
+	return state, args.Error(1)
+}
+
+func (m *MockMFARepository) SavePendingSecret(ctx context.Context, userID int64, encryptedSecret string) error {
+	args := m.Called(ctx, userID, encryptedSecret)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Enable(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Disable(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockUserRepository MFA нужен только GetById
+type MockUserRepository struct {
+	repository.UserRepository
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetById(ctx context.Context, id int64) (*model.User, error) {
+	args := m.Called(ctx, id)
+	user, _ := args.Get(0).(*model.User)
+	return user, args.Error(1)
+}
+
+type MockTOTPStepCache struct {
+	mock.Mock
+}
+
+func (m *MockTOTPStepCache) MarkUsed(ctx context.Context, userID, step int64, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, userID, step, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+// plainCrypter Шифрование в тестах не проверяем, только то, что оно вызывается
+type plainCrypter struct{}
+
+func (plainCrypter) Encrypt(plaintext string) (string, error) {
+	return "enc:" + plaintext, nil
+}
+
+func (plainCrypter) Decrypt(ciphertext string) (string, error) {
+	if len(ciphertext) < 4 || ciphertext[:4] != "enc:" {
+		return "", errors.New("bad ciphertext")
+	}
+
+	return ciphertext[4:], nil
+}
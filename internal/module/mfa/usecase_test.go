@@ -0,0 +1,445 @@
+package mfa
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/totp"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+var testNow = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestUsecase(repo *MockMFARepository, steps *MockTOTPStepCache) *Usecase {
+	return &Usecase{
+		Rep:       repo,
+		Crypter:   plainCrypter{},
+		UsedSteps: steps,
+		Issuer:    "Full Project",
+		Now:       func() time.Time { return testNow },
+	}
+}
+
+func currentCode(t *testing.T) string {
+	t.Helper()
+
+	code, err := totp.Code(testSecret, totp.Step(testNow))
+	require.NoError(t, err)
+	return code
+}
+
+func enabledState() *model.UserMFA {
+	enabledAt := testNow.Add(-time.Hour)
+	return &model.UserMFA{Secret: "enc:" + testSecret, EnabledAt: &enabledAt}
+}
+
+func TestEnroll(t *testing.T) {
+	t.Run("saves encrypted secret", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+		throttle.On("Reset", mock.Anything, userEmail).Return(nil)
+		repo.On("Get", mock.Anything, userID).Return(&model.UserMFA{}, nil)
+		repo.On("SavePendingSecret", mock.Anything, userID, mock.MatchedBy(func(s string) bool {
+			return strings.HasPrefix(s, "enc:")
+		})).Return(nil)
+
+		enrollment, err := newReauthUsecase(t, repo, nil, throttle).Enroll(context.Background(), userID, userEmail, password, clientIP)
+		require.NoError(t, err)
+		assert.NotEmpty(t, enrollment.Secret)
+
+		uri, err := url.Parse(enrollment.URI)
+		require.NoError(t, err)
+		assert.Equal(t, enrollment.Secret, uri.Query().Get("secret"))
+		assert.Equal(t, "Full Project", uri.Query().Get("issuer"))
+
+		repo.AssertCalled(t, "SavePendingSecret", mock.Anything, userID, "enc:"+enrollment.Secret)
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("wrong password is counted", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+		throttle.On("RegisterFailure", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+
+		_, err := newReauthUsecase(t, repo, nil, throttle).Enroll(context.Background(), userID, userEmail, "wrong-password", clientIP)
+		assert.ErrorIs(t, err, apperror.WrongPasswordErr)
+		repo.AssertNotCalled(t, "SavePendingSecret", mock.Anything, mock.Anything, mock.Anything)
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+		throttle.On("Reset", mock.Anything, userEmail).Return(nil)
+		repo.On("Get", mock.Anything, userID).Return(enabledState(), nil)
+
+		_, err := newReauthUsecase(t, repo, nil, throttle).Enroll(context.Background(), userID, userEmail, password, clientIP)
+		assert.ErrorIs(t, err, apperror.MFAAlreadyEnabledErr)
+		repo.AssertNotCalled(t, "SavePendingSecret", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("encryption key not configured", func(t *testing.T) {
+		uc := newTestUsecase(new(MockMFARepository), nil)
+		uc.Crypter = nil
+
+		_, err := uc.Enroll(context.Background(), userID, userEmail, password, clientIP)
+		assert.ErrorIs(t, err, apperror.MFANotConfiguredErr)
+	})
+}
+
+func TestConfirm(t *testing.T) {
+	recoveryCode := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+
+	tests := []struct {
+		name      string
+		state     *model.UserMFA
+		password  string
+		code      func(t *testing.T) string
+		setup     func(repo *MockMFARepository, steps *MockTOTPStepCache, throttle *mocks.MockLoginThrottle)
+		wantErr   error
+		wantCodes bool
+	}{
+		{
+			name:     "enables mfa and returns recovery codes",
+			state:    &model.UserMFA{Secret: "enc:" + testSecret},
+			password: password,
+			code:     currentCode,
+			setup: func(repo *MockMFARepository, steps *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				steps.On("MarkUsed", mock.Anything, userID, totp.Step(testNow), usedStepTTL).Return(true, nil)
+				throttle.On("Reset", mock.Anything, userEmail).Return(nil)
+				repo.On("Enable", mock.Anything, userID, mock.MatchedBy(func(hashes []string) bool {
+					return len(hashes) == recoveryCodesCount
+				})).Return(nil)
+			},
+			wantCodes: true,
+		},
+		{
+			name:     "not enrolled",
+			state:    &model.UserMFA{},
+			password: password,
+			code:     currentCode,
+			setup:    func(*MockMFARepository, *MockTOTPStepCache, *mocks.MockLoginThrottle) {},
+			wantErr:  apperror.MFANotEnrolledErr,
+		},
+		{
+			name:     "already enabled",
+			state:    enabledState(),
+			password: password,
+			code:     currentCode,
+			setup:    func(*MockMFARepository, *MockTOTPStepCache, *mocks.MockLoginThrottle) {},
+			wantErr:  apperror.MFAAlreadyEnabledErr,
+		},
+		{
+			name:     "wrong password is counted",
+			state:    &model.UserMFA{Secret: "enc:" + testSecret},
+			password: "wrong-password",
+			code:     currentCode,
+			setup: func(_ *MockMFARepository, _ *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				throttle.On("RegisterFailure", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:     "wrong code is counted",
+			state:    &model.UserMFA{Secret: "enc:" + testSecret},
+			password: password,
+			code:     func(*testing.T) string { return "000000" },
+			setup: func(_ *MockMFARepository, _ *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				throttle.On("RegisterFailure", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.InvalidMFACodeErr,
+		},
+		{
+			name:     "enable error",
+			state:    &model.UserMFA{Secret: "enc:" + testSecret},
+			password: password,
+			code:     currentCode,
+			setup: func(repo *MockMFARepository, steps *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				steps.On("MarkUsed", mock.Anything, userID, totp.Step(testNow), usedStepTTL).Return(true, nil)
+				throttle.On("Reset", mock.Anything, userEmail).Return(nil)
+				repo.On("Enable", mock.Anything, userID, mock.Anything).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockMFARepository)
+			steps := new(MockTOTPStepCache)
+			throttle := new(mocks.MockLoginThrottle)
+			repo.On("Get", mock.Anything, userID).Return(tt.state, nil)
+			tt.setup(repo, steps, throttle)
+
+			codes, err := newReauthUsecase(t, repo, steps, throttle).Confirm(context.Background(), userID, tt.password, tt.code(t), clientIP)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, codes)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, codes, recoveryCodesCount)
+				for _, code := range codes {
+					assert.Regexp(t, recoveryCode, code)
+				}
+			}
+
+			repo.AssertExpectations(t)
+			steps.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyCode(t *testing.T) {
+	const recovery = "abcde-fghij"
+
+	tests := []struct {
+		name    string
+		state   *model.UserMFA
+		code    func(t *testing.T) string
+		setup   func(repo *MockMFARepository, steps *MockTOTPStepCache)
+		wantErr error
+	}{
+		{
+			name:  "valid totp",
+			state: enabledState(),
+			code:  currentCode,
+			setup: func(_ *MockMFARepository, steps *MockTOTPStepCache) {
+				steps.On("MarkUsed", mock.Anything, userID, totp.Step(testNow), usedStepTTL).Return(true, nil)
+			},
+		},
+		{
+			name:  "replayed totp",
+			state: enabledState(),
+			code:  currentCode,
+			setup: func(_ *MockMFARepository, steps *MockTOTPStepCache) {
+				steps.On("MarkUsed", mock.Anything, userID, totp.Step(testNow), usedStepTTL).Return(false, nil)
+			},
+			wantErr: apperror.InvalidMFACodeErr,
+		},
+		{
+			name:  "recovery code is normalized",
+			state: enabledState(),
+			code:  func(*testing.T) string { return " ABCDE-FGHIJ " },
+			setup: func(repo *MockMFARepository, _ *MockTOTPStepCache) {
+				repo.On("UseRecoveryCode", mock.Anything, userID, hasher.Sha256Hex("abcdefghij")).Return(true, nil)
+			},
+		},
+		{
+			name:  "used recovery code",
+			state: enabledState(),
+			code:  func(*testing.T) string { return recovery },
+			setup: func(repo *MockMFARepository, _ *MockTOTPStepCache) {
+				repo.On("UseRecoveryCode", mock.Anything, userID, hasher.Sha256Hex("abcdefghij")).Return(false, nil)
+			},
+			wantErr: apperror.InvalidMFACodeErr,
+		},
+		{
+			name:    "mfa not enabled",
+			state:   &model.UserMFA{Secret: "enc:" + testSecret},
+			code:    currentCode,
+			setup:   func(*MockMFARepository, *MockTOTPStepCache) {},
+			wantErr: apperror.MFANotEnabledErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockMFARepository)
+			steps := new(MockTOTPStepCache)
+			repo.On("Get", mock.Anything, userID).Return(tt.state, nil)
+			tt.setup(repo, steps)
+
+			err := newTestUsecase(repo, steps).VerifyCode(context.Background(), userID, tt.code(t))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			steps.AssertExpectations(t)
+		})
+	}
+}
+
+// newReauthUsecase Пароль пользователя - password, счетчик попыток ожидает вызовы из теста
+func newReauthUsecase(t *testing.T, repo *MockMFARepository, steps *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) *Usecase {
+	t.Helper()
+
+	hashed, err := hasher.HashPassword(password)
+	require.NoError(t, err)
+
+	userRep := new(MockUserRepository)
+	userRep.On("GetById", mock.Anything, userID).Return(&model.User{ID: userID, Email: userEmail, Password: hashed}, nil)
+
+	uc := newTestUsecase(repo, steps)
+	uc.UserRep = userRep
+	uc.Reauth = service.NewReauthenticator(throttle, validator.PasswordPolicy{})
+	return uc
+}
+
+func TestDisable(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		code     func(t *testing.T) string
+		setup    func(repo *MockMFARepository, steps *MockTOTPStepCache, throttle *mocks.MockLoginThrottle)
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			password: password,
+			code:     currentCode,
+			setup: func(repo *MockMFARepository, steps *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, userID).Return(enabledState(), nil)
+				steps.On("MarkUsed", mock.Anything, userID, totp.Step(testNow), usedStepTTL).Return(true, nil)
+				throttle.On("Reset", mock.Anything, userEmail).Return(nil)
+				repo.On("Disable", mock.Anything, userID).Return(nil)
+			},
+		},
+		{
+			name:     "wrong password is counted",
+			password: "wrong-password",
+			code:     currentCode,
+			setup: func(_ *MockMFARepository, _ *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				throttle.On("RegisterFailure", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:     "password longer than policy is counted",
+			password: strings.Repeat("a", validator.DefaultMaxPasswordLength+1),
+			code:     currentCode,
+			setup: func(_ *MockMFARepository, _ *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				throttle.On("RegisterFailure", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:     "wrong code is counted and keeps mfa",
+			password: password,
+			code:     func(*testing.T) string { return "000000" },
+			setup: func(repo *MockMFARepository, _ *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, userID).Return(enabledState(), nil)
+				throttle.On("RegisterFailure", mock.Anything, userEmail, clientIP).Return(time.Minute, nil)
+			},
+			wantErr: apperror.InvalidMFACodeErr,
+		},
+		{
+			name:     "locked out before password check",
+			password: password,
+			code:     currentCode,
+			setup: func(_ *MockMFARepository, _ *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(90*time.Second, nil)
+			},
+			wantErr: apperror.TooManyLoginAttemptsErr,
+		},
+		{
+			name:     "not enabled is not counted",
+			password: password,
+			code:     currentCode,
+			setup: func(repo *MockMFARepository, _ *MockTOTPStepCache, throttle *mocks.MockLoginThrottle) {
+				throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, userID).Return(&model.UserMFA{}, nil)
+			},
+			wantErr: apperror.MFANotEnabledErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockMFARepository)
+			steps := new(MockTOTPStepCache)
+			throttle := new(mocks.MockLoginThrottle)
+			tt.setup(repo, steps, throttle)
+
+			err := newReauthUsecase(t, repo, steps, throttle).Disable(context.Background(), userID, tt.password, tt.code(t), clientIP)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Disable", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			steps.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+
+	t.Run("lockout carries retry after", func(t *testing.T) {
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, userEmail, clientIP).Return(90*time.Second, nil)
+
+		err := newReauthUsecase(t, new(MockMFARepository), nil, throttle).Disable(context.Background(), userID, password, "123456", clientIP)
+
+		var retryErr *apperror.RetryAfterError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, 90*time.Second, retryErr.RetryAfter)
+	})
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		steps := new(MockTOTPStepCache)
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+		repo.On("Get", mock.Anything, userID).Return(enabledState(), nil)
+		steps.On("MarkUsed", mock.Anything, userID, totp.Step(testNow), usedStepTTL).Return(true, nil)
+		throttle.On("Reset", mock.Anything, userEmail).Return(nil)
+
+		var saved []string
+		repo.On("ReplaceRecoveryCodes", mock.Anything, userID, mock.Anything).
+			Run(func(args mock.Arguments) { saved = args.Get(2).([]string) }).
+			Return(nil)
+
+		codes, err := newReauthUsecase(t, repo, steps, throttle).RegenerateRecoveryCodes(context.Background(), userID, password, currentCode(t), clientIP)
+		require.NoError(t, err)
+		require.Len(t, codes, recoveryCodesCount)
+
+		// В БД только хеши выданных кодов
+		for i, code := range codes {
+			assert.Equal(t, hasher.Sha256Hex(normalizeRecoveryCode(code)), saved[i])
+		}
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("wrong password keeps codes", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+		throttle.On("RegisterFailure", mock.Anything, userEmail, clientIP).Return(time.Duration(0), nil)
+
+		codes, err := newReauthUsecase(t, repo, nil, throttle).RegenerateRecoveryCodes(context.Background(), userID, "wrong-password", "123456", clientIP)
+		assert.ErrorIs(t, err, apperror.WrongPasswordErr)
+		assert.Nil(t, codes)
+		repo.AssertNotCalled(t, "ReplaceRecoveryCodes", mock.Anything, mock.Anything, mock.Anything)
+		throttle.AssertExpectations(t)
+	})
+}
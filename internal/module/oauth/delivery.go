@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"time"
+)
+
+// stateCookie Хеш state в браузере, начавшем вход. Без нее чужой state из подсунутой ссылки не примется (login CSRF)
+const stateCookie = "oauth_state"
+
+type OAuthHandler struct {
+	Usecase  usecase.OAuthUsecase
+	StateTTL time.Duration // cookie живет столько же, сколько state в Redis
+}
+
+// AuthorizeHandler Клиент отправляет пользователя по authorization_url на страницу входа провайдера
+func (o *OAuthHandler) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	authURL, state, err := o.Usecase.Authorize(r.Context(), chi.URLParam(r, "provider"))
+	if errors.Is(err, apperror.OAuthProviderNotFoundErr) {
+		respond.WithError(w, http.StatusNotFound, fmt.Sprintf("OAuth authorize error: %v", err), lgr)
+		return
+	}
+
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("OAuth authorize error: %v", err), lgr)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    hasher.Sha256Hex(state),
+		Path:     "/oauth",
+		MaxAge:   int(o.StateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	respond.WithSuccessJSON(w, http.StatusOK, AuthorizeResponse{AuthorizationURL: authURL})
+}
+
+func (o *OAuthHandler) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var callbackRequest CallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&callbackRequest); err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err := callbackRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("OAuth callback validation error: %v", err), lgr)
+		return
+	}
+
+	// state одноразовый, поэтому cookie удаляется при любом исходе
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/oauth", MaxAge: -1, HttpOnly: true, Secure: true, SameSite: http.SameSiteLaxMode})
+	if !stateMatchesCookie(r, callbackRequest.State) {
+		respond.WithError(w, http.StatusUnauthorized, fmt.Sprintf("OAuth login error: %v", apperror.InvalidTokenErr), lgr)
+		return
+	}
+
+	ip, userAgent := req.GetClientMeta(r)
+	result, httpStatus, err := o.Usecase.Callback(r.Context(), chi.URLParam(r, "provider"), callbackRequest.State, callbackRequest.Code, ip, userAgent)
+	if err != nil {
+		respond.WithError(w, httpStatus, fmt.Sprintf("OAuth login error: %v", err), lgr)
+		return
+	}
+
+	if result.MFARequired {
+		respond.WithSuccessJSON(w, httpStatus, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		return
+	}
+
+	respond.WithSuccessJSON(w, httpStatus, map[string]string{
+		"access_token":  result.AccessToken,
+		"refresh_token": result.RefreshToken,
+	})
+}
+
+func stateMatchesCookie(r *http.Request, state string) bool {
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(hasher.Sha256Hex(state))) == 1
+}
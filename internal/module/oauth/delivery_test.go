@@ -0,0 +1,150 @@
+package oauth
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newOAuthRouter(uc *MockOAuthUsecase) http.Handler {
+	handler := NewOAuthHandler(uc, 0)
+
+	r := chi.NewRouter()
+	r.Get("/oauth/{provider}/authorize", handler.AuthorizeHandler)
+	r.Post("/oauth/{provider}/callback", handler.CallbackHandler)
+	return r
+}
+
+func TestAuthorizeHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		uc := new(MockOAuthUsecase)
+		uc.On("Authorize", mock.Anything, "google").Return("https://accounts.example.com/auth?state=x", "x", nil)
+
+		rec := httptest.NewRecorder()
+		newOAuthRouter(uc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/oauth/google/authorize", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"authorization_url":"https://accounts.example.com/auth?state=x"}`, rec.Body.String())
+
+		// В cookie только хеш state, и скрипты страницы ее не видят
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, stateCookie, cookies[0].Name)
+		assert.Equal(t, hasher.Sha256Hex("x"), cookies[0].Value)
+		assert.Equal(t, int(defaultStateTTL.Seconds()), cookies[0].MaxAge)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		uc := new(MockOAuthUsecase)
+		uc.On("Authorize", mock.Anything, "nope").Return("", "", apperror.OAuthProviderNotFoundErr)
+
+		rec := httptest.NewRecorder()
+		newOAuthRouter(uc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/oauth/nope/authorize", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, rec.Result().Cookies())
+	})
+}
+
+func TestCallbackHandler(t *testing.T) {
+	stateHash := hasher.Sha256Hex("s")
+
+	cases := []struct {
+		name       string
+		body       string
+		cookie     string
+		setupMock  func(*MockOAuthUsecase)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "tokens",
+			body:   `{"code":"c","state":"s"}`,
+			cookie: stateHash,
+			setupMock: func(uc *MockOAuthUsecase) {
+				uc.On("Callback", mock.Anything, "google", "s", "c", mock.Anything, mock.Anything).
+					Return(model.LoginResult{AccessToken: "access", RefreshToken: "refresh"}, http.StatusOK, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"access_token":"access","refresh_token":"refresh"}`,
+		},
+		{
+			name:   "mfa required",
+			body:   `{"code":"c","state":"s"}`,
+			cookie: stateHash,
+			setupMock: func(uc *MockOAuthUsecase) {
+				uc.On("Callback", mock.Anything, "google", "s", "c", mock.Anything, mock.Anything).
+					Return(model.LoginResult{MFARequired: true, MFAToken: "mfa"}, http.StatusOK, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"mfa_required":true,"mfa_token":"mfa"}`,
+		},
+		{
+			name:   "invalid state",
+			body:   `{"code":"c","state":"s"}`,
+			cookie: stateHash,
+			setupMock: func(uc *MockOAuthUsecase) {
+				uc.On("Callback", mock.Anything, "google", "s", "c", mock.Anything, mock.Anything).
+					Return(model.LoginResult{}, http.StatusUnauthorized, apperror.InvalidTokenErr)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "no state cookie",
+			body:       `{"code":"c","state":"s"}`,
+			setupMock:  func(*MockOAuthUsecase) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "state cookie from another login",
+			body:       `{"code":"c","state":"s"}`,
+			cookie:     hasher.Sha256Hex("attacker"),
+			setupMock:  func(*MockOAuthUsecase) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing state",
+			body:       `{"code":"c"}`,
+			setupMock:  func(*MockOAuthUsecase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := new(MockOAuthUsecase)
+			tc.setupMock(uc)
+
+			r := httptest.NewRequest(http.MethodPost, "/oauth/google/callback", strings.NewReader(tc.body))
+			if tc.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: stateCookie, Value: tc.cookie})
+			}
+
+			rec := httptest.NewRecorder()
+			newOAuthRouter(uc).ServeHTTP(rec, r)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+			}
+			if tc.wantStatus != http.StatusBadRequest {
+				cookies := rec.Result().Cookies()
+				require.Len(t, cookies, 1)
+				assert.Equal(t, stateCookie, cookies[0].Name)
+				assert.Negative(t, cookies[0].MaxAge)
+			}
+			uc.AssertExpectations(t)
+		})
+	}
+}
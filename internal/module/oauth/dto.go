@@ -0,0 +1,26 @@
+package oauth
+
+import "errors"
+
+type AuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// CallbackRequest code и state из query, с которыми провайдер вернул пользователя на redirect_url
+type CallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+func (r CallbackRequest) Validate() error {
+	if r.Code == "" || r.State == "" {
+		return errors.New("code or state is empty")
+	}
+
+	return nil
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
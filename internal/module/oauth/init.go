@@ -0,0 +1,46 @@
+package oauth
+
+import (
+	"database/sql"
+	"github.com/Elaman1/full-project-mock/internal/cache"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domoauth "github.com/Elaman1/full-project-mock/internal/domain/oauth"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/pkg/oidc"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+func InitOAuthModule(db *sql.DB, redisDB *redis.Client, users usecase.UserUsecase, auditLogger domaudit.AuditLogger, cfg config.OAuth) *OAuthHandler {
+	identityRepo := NewIdentityRepository(db)
+	oauthUsecase := NewOAuthUsecase(NewProviders(cfg), cache.NewOAuthStateRedis(redisDB), identityRepo, users, auditLogger, cfg.StateTTL)
+	return NewOAuthHandler(oauthUsecase, cfg.StateTTL)
+}
+
+// NewProviders Провайдеры из конфига. Discovery выполнится при первом входе
+func NewProviders(cfg config.OAuth) map[string]domoauth.Provider {
+	providers := make(map[string]domoauth.Provider, len(cfg.Providers))
+	for name, p := range cfg.Providers {
+		providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			AuthURL:      p.AuthURL,
+			TokenURL:     p.TokenURL,
+			JWKSURL:      p.JWKSURL,
+		}, nil)
+	}
+
+	return providers
+}
+
+func NewOAuthHandler(usecase usecase.OAuthUsecase, stateTTL time.Duration) *OAuthHandler {
+	if stateTTL == 0 {
+		stateTTL = defaultStateTTL
+	}
+
+	return &OAuthHandler{Usecase: usecase, StateTTL: stateTTL}
+}
@@ -0,0 +1,110 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"time"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) repository.IdentityRepository {
+	return &Repository{DB: db}
+}
+
+func (i *Repository) GetUserID(ctx context.Context, provider, subject string) (int64, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var userID int64
+	err := i.DB.QueryRowContext(ctxTimeout, "SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2", provider, subject).
+		Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, apperror.IdentityNotFoundErr
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("get identity error: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (i *Repository) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := i.DB.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx error: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctxTimeout, "SELECT EXISTS(SELECT 1 FROM users WHERE lower(email) = lower($1))", user.Email).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check email error: %w", err)
+	}
+
+	if exists {
+		return apperror.ExistsEmailErr
+	}
+
+	err = tx.QueryRowContext(ctxTimeout,
+		"INSERT INTO users (email, password, name, role_id, email_verified_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		user.Email, user.Password, user.Username, user.RoleID, user.EmailVerifiedAt,
+	).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create user error: %w", err)
+	}
+
+	identity.UserID = user.ID
+	err = tx.QueryRowContext(ctxTimeout,
+		"INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		identity.UserID, identity.Provider, identity.Subject, identity.Email,
+	).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create identity error: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+
+	return nil
+}
+
+func (i *Repository) ListByUser(ctx context.Context, userID int64) ([]model.UserIdentity, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	rows, err := i.DB.QueryContext(ctxTimeout,
+		"SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at FROM user_identities WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("list identities error: %w", err)
+	}
+	defer rows.Close()
+
+	identities := make([]model.UserIdentity, 0)
+	for rows.Next() {
+		var identity model.UserIdentity
+		if err = rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan identity error: %w", err)
+		}
+
+		identities = append(identities, identity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list identities error: %w", err)
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,204 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	domoauth "github.com/Elaman1/full-project-mock/internal/domain/oauth"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/oidc"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultStateTTL = 10 * time.Minute
+
+// usernameUnsafe Для имени нового пользователя оставляем только безопасные символы из email
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+type Usecase struct {
+	Providers map[string]domoauth.Provider
+	States    domcache.OAuthStateCache
+	Rep       repository.IdentityRepository
+	// Users Выдача токенов и MFA - как при обычном логине
+	Users    usecase.UserUsecase
+	Audit    domaudit.AuditLogger
+	StateTTL time.Duration
+}
+
+func NewOAuthUsecase(providers map[string]domoauth.Provider, states domcache.OAuthStateCache, rep repository.IdentityRepository, users usecase.UserUsecase, auditLogger domaudit.AuditLogger, stateTTL time.Duration) usecase.OAuthUsecase {
+	if stateTTL == 0 {
+		stateTTL = defaultStateTTL
+	}
+
+	return &Usecase{
+		Providers: providers,
+		States:    states,
+		Rep:       rep,
+		Users:     users,
+		Audit:     auditLogger,
+		StateTTL:  stateTTL,
+	}
+}
+
+func (u *Usecase) Authorize(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := u.Providers[providerName]
+	if !ok {
+		return "", "", apperror.OAuthProviderNotFoundErr
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = u.States.Save(ctx, hasher.Sha256Hex(state), model.OAuthState{Provider: providerName, Nonce: nonce, CodeVerifier: verifier}, u.StateTTL)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+func (u *Usecase) Callback(ctx context.Context, providerName, state, code, clientIP, ua string) (model.LoginResult, int, error) {
+	provider, ok := u.Providers[providerName]
+	if !ok {
+		return model.LoginResult{}, http.StatusNotFound, apperror.OAuthProviderNotFoundErr
+	}
+
+	// state одноразовый: повтор callback или подделанный state не пройдут
+	saved, err := u.States.Consume(ctx, hasher.Sha256Hex(state))
+	if errors.Is(err, domcache.ErrNotFound) {
+		return model.LoginResult{}, http.StatusUnauthorized, apperror.InvalidTokenErr
+	}
+
+	if err != nil {
+		return model.LoginResult{}, http.StatusInternalServerError, err
+	}
+
+	if saved.Provider != providerName {
+		return model.LoginResult{}, http.StatusUnauthorized, apperror.InvalidTokenErr
+	}
+
+	claims, err := provider.Exchange(ctx, code, saved.CodeVerifier, saved.Nonce)
+	if err != nil {
+		service.LoggerFromContext(ctx).Warn("oauth code exchange failed",
+			"event", "oauth_exchange_failed",
+			"provider", providerName,
+			"error", err,
+			"ip", clientIP,
+		)
+		return model.LoginResult{}, http.StatusUnauthorized, fmt.Errorf("%w: %v", apperror.InvalidTokenErr, err)
+	}
+
+	userID, err := u.Rep.GetUserID(ctx, providerName, claims.Subject)
+	if errors.Is(err, apperror.IdentityNotFoundErr) {
+		var httpStatus int
+		userID, httpStatus, err = u.register(ctx, providerName, claims)
+		if err != nil {
+			return model.LoginResult{}, httpStatus, err
+		}
+	} else if err != nil {
+		return model.LoginResult{}, http.StatusInternalServerError, err
+	}
+
+	return u.Users.LoginWithIdentity(ctx, userID, providerName, clientIP, ua)
+}
+
+// register Новый аккаунт провайдера создает пользователя. К существующему пользователю с тем же email
+// аккаунт не привязываем: иначе тот, кто завел этот email у провайдера, получил бы чужой аккаунт
+func (u *Usecase) register(ctx context.Context, providerName string, claims oidc.Claims) (int64, int, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return 0, http.StatusBadRequest, apperror.OAuthEmailRequiredErr
+	}
+
+	username, err := newUsername(claims.Email)
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+
+	// Пароля нет: войти по паролю можно будет после сброса через /password/forgot
+	password, err := hasher.GenerateToken()
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+
+	pwd, err := hasher.HashPassword(password)
+	if err != nil {
+		return 0, http.StatusInternalServerError, fmt.Errorf("произошла ошибка при хешировании пароля")
+	}
+
+	// Email подтвердил провайдер
+	verifiedAt := time.Now()
+	user := &model.User{
+		Email:           claims.Email,
+		Username:        username,
+		Password:        pwd,
+		RoleID:          constants.DefaultUserRoleID,
+		EmailVerifiedAt: &verifiedAt,
+	}
+	identity := &model.UserIdentity{Provider: providerName, Subject: claims.Subject, Email: claims.Email}
+
+	err = u.Rep.CreateUserWithIdentity(ctx, user, identity)
+	if errors.Is(err, apperror.ExistsEmailErr) {
+		return 0, http.StatusConflict, err
+	}
+
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+
+	service.LoggerFromContext(ctx).Info("user registered via oauth", "event", "oauth_register", "user_id", user.ID, "provider", providerName)
+	if u.Audit != nil {
+		u.Audit.Log(ctx, model.AuditEvent{UserID: user.ID, Action: constants.AuditRegister, Metadata: map[string]any{"provider": providerName}})
+	}
+
+	return user.ID, http.StatusOK, nil
+}
+
+// newUsername Имя должно быть уникальным, поэтому к части email до @ добавляем случайный суффикс
+func newUsername(email string) (string, error) {
+	local, _, _ := strings.Cut(email, "@")
+	local = usernameUnsafe.ReplaceAllString(local, "")
+	if local == "" {
+		local = "user"
+	}
+
+	if len(local) > 64 {
+		local = local[:64]
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return local + "_" + hex.EncodeToString(suffix), nil
+}
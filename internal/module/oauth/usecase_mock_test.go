@@ -0,0 +1,85 @@
+package oauth
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/stretchr/testify/mock"
+	"sync"
+	"time"
+)
+
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) GetUserID(ctx context.Context, provider, subject string) (int64, error) {
+	args := m.Called(ctx, provider, subject)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockIdentityRepository) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	args := m.Called(ctx, user, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) ListByUser(ctx context.Context, userID int64) ([]model.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	identities, _ := args.Get(0).([]model.UserIdentity)
+	return identities, args.Error(1)
+}
+
+// MockUserUsecase Из UserUsecase OAuth использует только LoginWithIdentity
+type MockUserUsecase struct {
+	usecase.UserUsecase
+	mock.Mock
+}
+
+func (m *MockUserUsecase) LoginWithIdentity(ctx context.Context, userID int64, provider, clientIP, ua string) (model.LoginResult, int, error) {
+	args := m.Called(ctx, userID, provider, clientIP, ua)
+	return args.Get(0).(model.LoginResult), args.Int(1), args.Error(2)
+}
+
+type MockOAuthUsecase struct {
+	mock.Mock
+}
+
+func (m *MockOAuthUsecase) Authorize(ctx context.Context, provider string) (string, string, error) {
+	args := m.Called(ctx, provider)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockOAuthUsecase) Callback(ctx context.Context, provider, state, code, clientIP, ua string) (model.LoginResult, int, error) {
+	args := m.Called(ctx, provider, state, code, clientIP, ua)
+	return args.Get(0).(model.LoginResult), args.Int(1), args.Error(2)
+}
+
+// memoryStateCache Одноразовые state в памяти, как в Redis
+type memoryStateCache struct {
+	mu     sync.Mutex
+	states map[string]model.OAuthState
+}
+
+func newMemoryStateCache() *memoryStateCache {
+	return &memoryStateCache{states: make(map[string]model.OAuthState)}
+}
+
+func (c *memoryStateCache) Save(_ context.Context, hashedState string, state model.OAuthState, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[hashedState] = state
+	return nil
+}
+
+func (c *memoryStateCache) Consume(_ context.Context, hashedState string) (model.OAuthState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.states[hashedState]
+	if !ok {
+		return model.OAuthState{}, cache.ErrNotFound
+	}
+	delete(c.states, hashedState)
+	return state, nil
+}
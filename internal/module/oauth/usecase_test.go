@@ -0,0 +1,204 @@
+package oauth
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	domoauth "github.com/Elaman1/full-project-mock/internal/domain/oauth"
+	"github.com/Elaman1/full-project-mock/pkg/oidc"
+	"github.com/Elaman1/full-project-mock/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+const (
+	providerName    = "test"
+	clientIP        = "127.0.0.1"
+	clientUserAgent = "testClient"
+	code            = "test-code"
+)
+
+var identity = oidctest.Identity{Subject: "248289761001", Email: "jane.doe@example.com", EmailVerified: true, Name: "Jane Doe"}
+
+// newTestUsecase Провайдер - локальный OpenID-сервер, хранилище state в памяти
+func newTestUsecase(t *testing.T, server *oidctest.Server, repo *MockIdentityRepository, users *MockUserUsecase) *Usecase {
+	t.Helper()
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "https://app.example.com/oauth/test",
+	}, server.Client())
+
+	return NewOAuthUsecase(map[string]domoauth.Provider{providerName: provider}, newMemoryStateCache(), repo, users, nil, 0).(*Usecase)
+}
+
+// authorize Проходит первый шаг и регистрирует у провайдера code для пользователя id
+func authorize(t *testing.T, uc *Usecase, server *oidctest.Server, id oidctest.Identity) string {
+	t.Helper()
+
+	authURL, state, err := uc.Authorize(context.Background(), providerName)
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+
+	query := parsed.Query()
+	require.Equal(t, state, query.Get("state"))
+	server.AddCode(code, query.Get("nonce"), query.Get("code_challenge"), id)
+	return state
+}
+
+func TestCallback(t *testing.T) {
+	server := oidctest.NewServer("test-client", "test-secret")
+	defer server.Close()
+
+	tokens := model.LoginResult{AccessToken: "access", RefreshToken: "refresh"}
+
+	cases := []struct {
+		name       string
+		identity   oidctest.Identity
+		setupMocks func(*MockIdentityRepository, *MockUserUsecase)
+		want       model.LoginResult
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name:     "linked identity",
+			identity: identity,
+			setupMocks: func(repo *MockIdentityRepository, users *MockUserUsecase) {
+				repo.On("GetUserID", mock.Anything, providerName, identity.Subject).Return(int64(7), nil)
+				users.On("LoginWithIdentity", mock.Anything, int64(7), providerName, clientIP, clientUserAgent).Return(tokens, http.StatusOK, nil)
+			},
+			want:       tokens,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "new user",
+			identity: identity,
+			setupMocks: func(repo *MockIdentityRepository, users *MockUserUsecase) {
+				repo.On("GetUserID", mock.Anything, providerName, identity.Subject).Return(int64(0), apperror.IdentityNotFoundErr)
+				repo.On("CreateUserWithIdentity", mock.Anything,
+					mock.MatchedBy(func(u *model.User) bool {
+						return u.Email == identity.Email && u.EmailVerifiedAt != nil && u.Password != "" &&
+							len(u.Username) == len("jane.doe_")+8 && u.Username[:9] == "jane.doe_"
+					}),
+					&model.UserIdentity{Provider: providerName, Subject: identity.Subject, Email: identity.Email},
+				).Run(func(args mock.Arguments) {
+					args.Get(1).(*model.User).ID = 42
+				}).Return(nil)
+				users.On("LoginWithIdentity", mock.Anything, int64(42), providerName, clientIP, clientUserAgent).Return(tokens, http.StatusOK, nil)
+			},
+			want:       tokens,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "email is taken by local account",
+			identity: identity,
+			setupMocks: func(repo *MockIdentityRepository, _ *MockUserUsecase) {
+				repo.On("GetUserID", mock.Anything, providerName, identity.Subject).Return(int64(0), apperror.IdentityNotFoundErr)
+				repo.On("CreateUserWithIdentity", mock.Anything, mock.Anything, mock.Anything).Return(apperror.ExistsEmailErr)
+			},
+			wantStatus: http.StatusConflict,
+			wantErr:    apperror.ExistsEmailErr,
+		},
+		{
+			name:     "unverified email",
+			identity: oidctest.Identity{Subject: "1", Email: "jane.doe@example.com"},
+			setupMocks: func(repo *MockIdentityRepository, _ *MockUserUsecase) {
+				repo.On("GetUserID", mock.Anything, providerName, "1").Return(int64(0), apperror.IdentityNotFoundErr)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    apperror.OAuthEmailRequiredErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(MockIdentityRepository)
+			users := new(MockUserUsecase)
+			tc.setupMocks(repo, users)
+
+			uc := newTestUsecase(t, server, repo, users)
+			state := authorize(t, uc, server, tc.identity)
+
+			result, status, err := uc.Callback(context.Background(), providerName, state, code, clientIP, clientUserAgent)
+
+			assert.Equal(t, tc.want, result)
+			assert.Equal(t, tc.wantStatus, status)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			users.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCallback_Rejected(t *testing.T) {
+	server := oidctest.NewServer("test-client", "test-secret")
+	defer server.Close()
+
+	t.Run("state is single use", func(t *testing.T) {
+		repo := new(MockIdentityRepository)
+		repo.On("GetUserID", mock.Anything, providerName, identity.Subject).Return(int64(7), nil).Once()
+		users := new(MockUserUsecase)
+		users.On("LoginWithIdentity", mock.Anything, int64(7), providerName, clientIP, clientUserAgent).Return(model.LoginResult{}, http.StatusOK, nil).Once()
+
+		uc := newTestUsecase(t, server, repo, users)
+		state := authorize(t, uc, server, identity)
+
+		_, _, err := uc.Callback(context.Background(), providerName, state, code, clientIP, clientUserAgent)
+		require.NoError(t, err)
+
+		_, status, err := uc.Callback(context.Background(), providerName, state, code, clientIP, clientUserAgent)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.ErrorIs(t, err, apperror.InvalidTokenErr)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		uc := newTestUsecase(t, server, new(MockIdentityRepository), new(MockUserUsecase))
+
+		_, status, err := uc.Callback(context.Background(), providerName, "forged", code, clientIP, clientUserAgent)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.ErrorIs(t, err, apperror.InvalidTokenErr)
+	})
+
+	t.Run("state of another provider", func(t *testing.T) {
+		uc := newTestUsecase(t, server, new(MockIdentityRepository), new(MockUserUsecase))
+		state := authorize(t, uc, server, identity)
+		uc.Providers["other"] = uc.Providers[providerName]
+
+		_, status, err := uc.Callback(context.Background(), "other", state, code, clientIP, clientUserAgent)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.ErrorIs(t, err, apperror.InvalidTokenErr)
+	})
+
+	t.Run("code rejected by provider", func(t *testing.T) {
+		uc := newTestUsecase(t, server, new(MockIdentityRepository), new(MockUserUsecase))
+		state := authorize(t, uc, server, identity)
+
+		_, status, err := uc.Callback(context.Background(), providerName, state, "wrong-code", clientIP, clientUserAgent)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.ErrorIs(t, err, apperror.InvalidTokenErr)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		uc := newTestUsecase(t, server, new(MockIdentityRepository), new(MockUserUsecase))
+
+		_, _, err := uc.Authorize(context.Background(), "nope")
+		assert.ErrorIs(t, err, apperror.OAuthProviderNotFoundErr)
+
+		_, status, err := uc.Callback(context.Background(), "nope", "state", code, clientIP, clientUserAgent)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.ErrorIs(t, err, apperror.OAuthProviderNotFoundErr)
+	})
+}
@@ -0,0 +1,113 @@
+package oauthserver
+
+import (
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/req"
+	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"log/slog"
+	"net/http"
+)
+
+type OAuthServerHandler struct {
+	Usecase usecase.OAuthServerUsecase
+}
+
+// TokenHandler Token endpoint (RFC 6749, 3.2). Пока поддерживается только client_credentials
+func (o *OAuthServerHandler) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	// Ответ с токеном нельзя кешировать (RFC 6749, 5.1)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	tokenRequest, err := parseTokenRequest(r)
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, errInvalidRequest, err.Error(), lgr)
+		return
+	}
+
+	if tokenRequest.GrantType != grantTypeClientCredentials {
+		writeTokenError(w, http.StatusBadRequest, errUnsupportedGrantType, "", lgr)
+		return
+	}
+
+	ip, userAgent := req.GetClientMeta(r)
+	client := tokenRequest.Client
+	token, httpStatus, err := o.Usecase.ClientCredentials(r.Context(), client.ClientID, client.ClientSecret, tokenRequest.Scope, ip, userAgent)
+	if err != nil {
+		writeUsecaseError(w, httpStatus, err, client, lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, httpStatus, token)
+}
+
+// IntrospectHandler RFC 7662. Для неизвестного или отозванного токена - 200 и active=false
+func (o *OAuthServerHandler) IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	actionRequest, err := parseTokenActionRequest(r)
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, errInvalidRequest, err.Error(), lgr)
+		return
+	}
+
+	ip, userAgent := req.GetClientMeta(r)
+	client := actionRequest.Client
+	info, httpStatus, err := o.Usecase.Introspect(r.Context(), client.ClientID, client.ClientSecret, actionRequest.Token, actionRequest.TokenTypeHint, ip, userAgent)
+	if err != nil {
+		writeUsecaseError(w, httpStatus, err, client, lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, httpStatus, info)
+}
+
+// RevokeHandler RFC 7009. Ответ 200 с пустым телом, даже если токен неизвестен
+func (o *OAuthServerHandler) RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	actionRequest, err := parseTokenActionRequest(r)
+	if err != nil {
+		writeTokenError(w, http.StatusBadRequest, errInvalidRequest, err.Error(), lgr)
+		return
+	}
+
+	ip, userAgent := req.GetClientMeta(r)
+	client := actionRequest.Client
+	httpStatus, err := o.Usecase.Revoke(r.Context(), client.ClientID, client.ClientSecret, actionRequest.Token, actionRequest.TokenTypeHint, ip, userAgent)
+	if err != nil {
+		writeUsecaseError(w, httpStatus, err, client, lgr)
+		return
+	}
+
+	w.WriteHeader(httpStatus)
+}
+
+// writeUsecaseError Внутренние ошибки наружу не отдаются
+func writeUsecaseError(w http.ResponseWriter, httpStatus int, err error, client ClientAuth, lgr *slog.Logger) {
+	switch {
+	case errors.Is(err, apperror.InvalidClientErr):
+		if client.Basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		writeTokenError(w, httpStatus, errInvalidClient, err.Error(), lgr)
+	case errors.Is(err, apperror.InvalidScopeErr):
+		writeTokenError(w, httpStatus, errInvalidScope, err.Error(), lgr)
+	case errors.Is(err, apperror.UnauthorizedClientErr):
+		writeTokenError(w, httpStatus, errUnauthorizedClient, err.Error(), lgr)
+	default:
+		lgr.Error("OAuth server error", "error", err)
+		writeTokenError(w, http.StatusInternalServerError, errServerError, "", lgr)
+	}
+}
+
+// writeTokenError Формат ошибки из RFC 6749, 5.2 вместо общего ErrorResponse
+func writeTokenError(w http.ResponseWriter, code int, errCode, description string, lgr *slog.Logger) {
+	respond.WithErrorJSON(w, code, TokenErrorResponse{Error: errCode, ErrorDescription: description}, lgr)
+}
@@ -0,0 +1,219 @@
+package oauthserver
+
+import (
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func tokenRequest(form url.Values, basicID, basicSecret string) *http.Request {
+	return formRequest("/oauth/token", form, basicID, basicSecret)
+}
+
+func formRequest(target string, form url.Values, basicID, basicSecret string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if basicID != "" {
+		r.SetBasicAuth(url.QueryEscape(basicID), url.QueryEscape(basicSecret))
+	}
+	return r
+}
+
+func TestTokenHandler(t *testing.T) {
+	token := model.ClientToken{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, Scope: "users:read"}
+
+	cases := []struct {
+		name        string
+		request     *http.Request
+		setupMock   func(*MockOAuthServerUsecase)
+		wantStatus  int
+		wantBody    string
+		wantWWWAuth bool
+	}{
+		{
+			name:    "client_secret_basic",
+			request: tokenRequest(url.Values{"grant_type": {"client_credentials"}, "scope": {"users:read"}}, "billing:eu", "p@ss+word"),
+			setupMock: func(uc *MockOAuthServerUsecase) {
+				uc.On("ClientCredentials", mock.Anything, "billing:eu", "p@ss+word", "users:read", mock.Anything, mock.Anything).Return(token, http.StatusOK, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"access_token":"access","token_type":"Bearer","expires_in":900,"scope":"users:read"}`,
+		},
+		{
+			name:    "client_secret_post",
+			request: tokenRequest(url.Values{"grant_type": {"client_credentials"}, "client_id": {"billing"}, "client_secret": {"secret"}}, "", ""),
+			setupMock: func(uc *MockOAuthServerUsecase) {
+				uc.On("ClientCredentials", mock.Anything, "billing", "secret", "", mock.Anything, mock.Anything).Return(token, http.StatusOK, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:    "invalid client with basic",
+			request: tokenRequest(url.Values{"grant_type": {"client_credentials"}}, "billing", "wrong"),
+			setupMock: func(uc *MockOAuthServerUsecase) {
+				uc.On("ClientCredentials", mock.Anything, "billing", "wrong", "", mock.Anything, mock.Anything).Return(model.ClientToken{}, http.StatusUnauthorized, apperror.InvalidClientErr)
+			},
+			wantStatus:  http.StatusUnauthorized,
+			wantBody:    `{"error":"invalid_client","error_description":"invalid client credentials"}`,
+			wantWWWAuth: true,
+		},
+		{
+			name:    "invalid scope",
+			request: tokenRequest(url.Values{"grant_type": {"client_credentials"}, "scope": {"root"}}, "billing", "secret"),
+			setupMock: func(uc *MockOAuthServerUsecase) {
+				uc.On("ClientCredentials", mock.Anything, "billing", "secret", "root", mock.Anything, mock.Anything).Return(model.ClientToken{}, http.StatusBadRequest, apperror.InvalidScopeErr)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_scope","error_description":"requested scope is not allowed"}`,
+		},
+		{
+			name:    "internal error is not exposed",
+			request: tokenRequest(url.Values{"grant_type": {"client_credentials"}}, "billing", "secret"),
+			setupMock: func(uc *MockOAuthServerUsecase) {
+				uc.On("ClientCredentials", mock.Anything, "billing", "secret", "", mock.Anything, mock.Anything).Return(model.ClientToken{}, http.StatusInternalServerError, errors.New("db is down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"server_error"}`,
+		},
+		{
+			name:       "unsupported grant type",
+			request:    tokenRequest(url.Values{"grant_type": {"password"}}, "billing", "secret"),
+			setupMock:  func(*MockOAuthServerUsecase) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"unsupported_grant_type"}`,
+		},
+		{
+			name:       "missing grant type",
+			request:    tokenRequest(url.Values{}, "billing", "secret"),
+			setupMock:  func(*MockOAuthServerUsecase) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"grant_type is required"}`,
+		},
+		{
+			name:       "two authentication methods",
+			request:    tokenRequest(url.Values{"grant_type": {"client_credentials"}, "client_secret": {"secret"}}, "billing", "secret"),
+			setupMock:  func(*MockOAuthServerUsecase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "client_id differs from header",
+			request:    tokenRequest(url.Values{"grant_type": {"client_credentials"}, "client_id": {"other"}}, "billing", "secret"),
+			setupMock:  func(*MockOAuthServerUsecase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "credentials in query are ignored",
+			request: func() *http.Request {
+				r := tokenRequest(url.Values{"grant_type": {"client_credentials"}}, "", "")
+				r.URL.RawQuery = "client_id=billing&client_secret=secret"
+				return r
+			}(),
+			setupMock: func(uc *MockOAuthServerUsecase) {
+				uc.On("ClientCredentials", mock.Anything, "", "", "", mock.Anything, mock.Anything).Return(model.ClientToken{}, http.StatusUnauthorized, apperror.InvalidClientErr)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := new(MockOAuthServerUsecase)
+			tc.setupMock(uc)
+
+			rec := httptest.NewRecorder()
+			NewOAuthServerHandler(uc).TokenHandler(rec, tc.request)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+			}
+			assert.Equal(t, tc.wantWWWAuth, rec.Header().Get("WWW-Authenticate") != "")
+			uc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIntrospectHandler(t *testing.T) {
+	t.Run("active token", func(t *testing.T) {
+		uc := new(MockOAuthServerUsecase)
+		uc.On("Introspect", mock.Anything, "gateway", "secret", "token", constants.TokenTypeRefresh, mock.Anything, mock.Anything).
+			Return(model.TokenIntrospection{Active: true, TokenType: constants.TokenTypeRefresh, Sub: "42", Exp: 1700000000}, http.StatusOK, nil)
+
+		rec := httptest.NewRecorder()
+		r := formRequest("/oauth/introspect", url.Values{"token": {"token"}, "token_type_hint": {"refresh_token"}}, "gateway", "secret")
+		NewOAuthServerHandler(uc).IntrospectHandler(rec, r)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"active":true,"token_type":"refresh_token","sub":"42","exp":1700000000}`, rec.Body.String())
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("inactive token", func(t *testing.T) {
+		uc := new(MockOAuthServerUsecase)
+		uc.On("Introspect", mock.Anything, "gateway", "secret", "token", "", mock.Anything, mock.Anything).
+			Return(model.TokenIntrospection{}, http.StatusOK, nil)
+
+		rec := httptest.NewRecorder()
+		NewOAuthServerHandler(uc).IntrospectHandler(rec, formRequest("/oauth/introspect", url.Values{"token": {"token"}}, "gateway", "secret"))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"active":false}`, rec.Body.String())
+	})
+
+	t.Run("client without scope", func(t *testing.T) {
+		uc := new(MockOAuthServerUsecase)
+		uc.On("Introspect", mock.Anything, "billing", "secret", "token", "", mock.Anything, mock.Anything).
+			Return(model.TokenIntrospection{}, http.StatusForbidden, apperror.UnauthorizedClientErr)
+
+		rec := httptest.NewRecorder()
+		NewOAuthServerHandler(uc).IntrospectHandler(rec, formRequest("/oauth/introspect", url.Values{"token": {"token"}}, "billing", "secret"))
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "unauthorized_client")
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		uc := new(MockOAuthServerUsecase)
+
+		rec := httptest.NewRecorder()
+		NewOAuthServerHandler(uc).IntrospectHandler(rec, formRequest("/oauth/introspect", url.Values{}, "gateway", "secret"))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		uc.AssertNotCalled(t, "Introspect", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRevokeHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		uc := new(MockOAuthServerUsecase)
+		uc.On("Revoke", mock.Anything, "billing", "secret", "token", "", mock.Anything, mock.Anything).Return(http.StatusOK, nil)
+
+		rec := httptest.NewRecorder()
+		r := formRequest("/oauth/revoke", url.Values{"token": {"token"}, "client_id": {"billing"}, "client_secret": {"secret"}}, "", "")
+		NewOAuthServerHandler(uc).RevokeHandler(rec, r)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("invalid client", func(t *testing.T) {
+		uc := new(MockOAuthServerUsecase)
+		uc.On("Revoke", mock.Anything, "billing", "wrong", "token", "", mock.Anything, mock.Anything).Return(http.StatusUnauthorized, apperror.InvalidClientErr)
+
+		rec := httptest.NewRecorder()
+		NewOAuthServerHandler(uc).RevokeHandler(rec, formRequest("/oauth/revoke", url.Values{"token": {"token"}}, "billing", "wrong"))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+	})
+}
@@ -0,0 +1,122 @@
+package oauthserver
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const grantTypeClientCredentials = "client_credentials"
+
+// Коды ошибок token endpoint (RFC 6749, 5.2)
+const (
+	errInvalidRequest       = "invalid_request"
+	errInvalidClient        = "invalid_client"
+	errInvalidScope         = "invalid_scope"
+	errUnauthorizedClient   = "unauthorized_client"
+	errUnsupportedGrantType = "unsupported_grant_type"
+	errServerError          = "server_error"
+)
+
+// ClientAuth Учетные данные клиента из запроса
+type ClientAuth struct {
+	ClientID     string
+	ClientSecret string
+	// Basic Клиент передал секрет в заголовке Authorization, при ошибке нужен WWW-Authenticate
+	Basic bool
+}
+
+type TokenRequest struct {
+	GrantType string
+	Scope     string
+	Client    ClientAuth
+}
+
+// TokenActionRequest Запрос интроспекции (RFC 7662) или отзыва (RFC 7009)
+type TokenActionRequest struct {
+	Token         string
+	TokenTypeHint string
+	Client        ClientAuth
+}
+
+type TokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+func parseTokenRequest(r *http.Request) (TokenRequest, error) {
+	client, err := parseClientAuth(r)
+	if err != nil {
+		return TokenRequest{}, err
+	}
+
+	tokenRequest := TokenRequest{
+		GrantType: r.PostForm.Get("grant_type"),
+		Scope:     r.PostForm.Get("scope"),
+		Client:    client,
+	}
+
+	if tokenRequest.GrantType == "" {
+		return TokenRequest{}, errors.New("grant_type is required")
+	}
+
+	return tokenRequest, nil
+}
+
+func parseTokenActionRequest(r *http.Request) (TokenActionRequest, error) {
+	client, err := parseClientAuth(r)
+	if err != nil {
+		return TokenActionRequest{}, err
+	}
+
+	actionRequest := TokenActionRequest{
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+		Client:        client,
+	}
+
+	if actionRequest.Token == "" {
+		return TokenActionRequest{}, errors.New("token is required")
+	}
+
+	return actionRequest, nil
+}
+
+// parseClientAuth Параметры только из тела application/x-www-form-urlencoded.
+// Клиент аутентифицируется через client_secret_basic или client_secret_post, но не обоими сразу
+func parseClientAuth(r *http.Request) (ClientAuth, error) {
+	if err := r.ParseForm(); err != nil {
+		return ClientAuth{}, errors.New("invalid form body")
+	}
+
+	client := ClientAuth{
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+
+	basicID, basicSecret, ok := r.BasicAuth()
+	if !ok {
+		return client, nil
+	}
+
+	if client.ClientSecret != "" {
+		return ClientAuth{}, errors.New("multiple client authentication methods")
+	}
+
+	// В Basic client_id и секрет дополнительно закодированы как form-urlencoded (RFC 6749, 2.3.1)
+	clientID, err := url.QueryUnescape(basicID)
+	if err != nil {
+		return ClientAuth{}, errors.New("invalid client_id encoding")
+	}
+
+	clientSecret, err := url.QueryUnescape(basicSecret)
+	if err != nil {
+		return ClientAuth{}, errors.New("invalid client_secret encoding")
+	}
+
+	if client.ClientID != "" && client.ClientID != clientID {
+		return ClientAuth{}, errors.New("client_id does not match authorization header")
+	}
+
+	return ClientAuth{ClientID: clientID, ClientSecret: clientSecret, Basic: true}, nil
+}
@@ -0,0 +1,19 @@
+package oauthserver
+
+import (
+	"database/sql"
+	"github.com/Elaman1/full-project-mock/internal/cache"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitOAuthServerModule(db *sql.DB, redisDB *redis.Client, tokenService usecase.TokenService, accessDenylist domcache.AccessTokenDenylist, auditLogger domaudit.AuditLogger) *OAuthServerHandler {
+	oauthServerUsecase := NewOAuthServerUsecase(NewOAuthClientRepository(db), tokenService, cache.NewSessionRedisRepository(redisDB), accessDenylist, auditLogger)
+	return NewOAuthServerHandler(oauthServerUsecase)
+}
+
+func NewOAuthServerHandler(usecase usecase.OAuthServerUsecase) *OAuthServerHandler {
+	return &OAuthServerHandler{Usecase: usecase}
+}
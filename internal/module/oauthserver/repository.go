@@ -0,0 +1,41 @@
+package oauthserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/lib/pq"
+	"time"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewOAuthClientRepository(db *sql.DB) repository.OAuthClientRepository {
+	return &Repository{DB: db}
+}
+
+func (o *Repository) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	client := &model.OAuthClient{}
+	err := o.DB.QueryRowContext(ctxTimeout, `
+		SELECT id, client_id, secret_hash, name, scopes, disabled, created_at
+		FROM oauth_clients WHERE client_id = $1`, clientID).
+		Scan(&client.ID, &client.ClientID, &client.SecretHash, &client.Name, pq.Array(&client.Scopes), &client.Disabled, &client.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.OAuthClientNotFoundErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get oauth client error: %w", err)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,376 @@
+package oauthserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tokenTypeBearer = "Bearer"
+
+type Usecase struct {
+	Rep          repository.OAuthClientRepository
+	TokenService usecase.TokenService
+	// SessionCache и AccessDenylist - те же хранилища, что у логина, по ним проверяются и отзываются токены
+	SessionCache   domcache.SessionCache
+	AccessDenylist domcache.AccessTokenDenylist
+	Audit          domaudit.AuditLogger
+}
+
+func NewOAuthServerUsecase(rep repository.OAuthClientRepository, tokenService usecase.TokenService, sessionCache domcache.SessionCache, accessDenylist domcache.AccessTokenDenylist, auditLogger domaudit.AuditLogger) usecase.OAuthServerUsecase {
+	return &Usecase{
+		Rep:            rep,
+		TokenService:   tokenService,
+		SessionCache:   sessionCache,
+		AccessDenylist: accessDenylist,
+		Audit:          auditLogger,
+	}
+}
+
+func (u *Usecase) ClientCredentials(ctx context.Context, clientID, clientSecret, scope, clientIP, ua string) (model.ClientToken, int, error) {
+	client, httpStatus, err := u.authenticateClient(ctx, clientID, clientSecret, clientIP, ua)
+	if err != nil {
+		return model.ClientToken{}, httpStatus, err
+	}
+
+	scopes, err := grantScopes(client.Scopes, scope)
+	if err != nil {
+		return model.ClientToken{}, http.StatusBadRequest, err
+	}
+
+	accessToken, expiresIn, err := u.TokenService.GenerateClientToken(client.ClientID, scopes)
+	if err != nil {
+		return model.ClientToken{}, http.StatusInternalServerError, err
+	}
+
+	granted := strings.Join(scopes, " ")
+	u.recordAudit(ctx, model.AuditEvent{
+		Action:    constants.AuditClientToken,
+		IP:        clientIP,
+		UserAgent: ua,
+		Metadata:  map[string]any{"client_id": client.ClientID, "scope": granted},
+	})
+	return model.ClientToken{
+		AccessToken: accessToken,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int64(expiresIn.Seconds()),
+		Scope:       granted,
+	}, http.StatusOK, nil
+}
+
+// Introspect Ответ по токену не зависит от подсказки, она только задает порядок проверки.
+// Для шлюза access и refresh различаются по token_type
+func (u *Usecase) Introspect(ctx context.Context, clientID, clientSecret, token, tokenTypeHint, clientIP, ua string) (model.TokenIntrospection, int, error) {
+	client, httpStatus, err := u.authenticateClient(ctx, clientID, clientSecret, clientIP, ua)
+	if err != nil {
+		return model.TokenIntrospection{}, httpStatus, err
+	}
+
+	// Иначе любой сервис мог бы перебирать чужие токены
+	if !slices.Contains(client.Scopes, constants.ScopeTokensIntrospect) {
+		return model.TokenIntrospection{}, http.StatusForbidden, apperror.UnauthorizedClientErr
+	}
+
+	lookups := []func(context.Context, string) (model.TokenIntrospection, error){u.introspectAccess, u.introspectRefresh}
+	if tokenTypeHint == constants.TokenTypeRefresh {
+		slices.Reverse(lookups)
+	}
+
+	for _, lookup := range lookups {
+		info, lookupErr := lookup(ctx, token)
+		if lookupErr != nil {
+			return model.TokenIntrospection{}, http.StatusInternalServerError, lookupErr
+		}
+
+		if info.Active {
+			return info, http.StatusOK, nil
+		}
+	}
+
+	return model.TokenIntrospection{Active: false}, http.StatusOK, nil
+}
+
+// Revoke Клиент отзывает только выданные ему токены, токены пользователей - только со scope tokens:revoke (RFC 7009, 2.1).
+// Чужой токен не отзывается, ответ 403
+func (u *Usecase) Revoke(ctx context.Context, clientID, clientSecret, token, tokenTypeHint, clientIP, ua string) (int, error) {
+	client, httpStatus, err := u.authenticateClient(ctx, clientID, clientSecret, clientIP, ua)
+	if err != nil {
+		return httpStatus, err
+	}
+
+	type revoker struct {
+		tokenType string
+		revoke    func(context.Context, *model.OAuthClient, string) (int64, bool, error)
+	}
+
+	revokers := []revoker{{constants.TokenTypeAccess, u.revokeAccess}, {constants.TokenTypeRefresh, u.revokeRefresh}}
+	if tokenTypeHint == constants.TokenTypeRefresh {
+		slices.Reverse(revokers)
+	}
+
+	for _, r := range revokers {
+		userID, revoked, revokeErr := r.revoke(ctx, client, token)
+		if errors.Is(revokeErr, apperror.UnauthorizedClientErr) {
+			return http.StatusForbidden, revokeErr
+		}
+
+		if revokeErr != nil {
+			return http.StatusInternalServerError, revokeErr
+		}
+
+		if revoked {
+			u.recordAudit(ctx, model.AuditEvent{
+				UserID:    userID,
+				Action:    constants.AuditTokenRevoke,
+				IP:        clientIP,
+				UserAgent: ua,
+				Metadata:  map[string]any{"client_id": client.ClientID, "token_type": r.tokenType},
+			})
+			return http.StatusOK, nil
+		}
+	}
+
+	// Неизвестный, истекший или уже отозванный токен - тоже успех (RFC 7009, 2.2)
+	return http.StatusOK, nil
+}
+
+// authenticateClient Неудачная аутентификация пишется в аудит, ответ для всех причин одинаковый
+func (u *Usecase) authenticateClient(ctx context.Context, clientID, clientSecret, clientIP, ua string) (*model.OAuthClient, int, error) {
+	client, err := u.authenticate(ctx, clientID, clientSecret)
+	if errors.Is(err, apperror.InvalidClientErr) {
+		u.recordAudit(ctx, model.AuditEvent{
+			Action:    constants.AuditClientAuthFailure,
+			IP:        clientIP,
+			UserAgent: ua,
+			Metadata:  map[string]any{"client_id": clientID},
+		})
+		return nil, http.StatusUnauthorized, err
+	}
+
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return client, http.StatusOK, nil
+}
+
+// authenticate Неизвестный клиент, неверный секрет и отключенный клиент неразличимы для вызывающего
+func (u *Usecase) authenticate(ctx context.Context, clientID, clientSecret string) (*model.OAuthClient, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, apperror.InvalidClientErr
+	}
+
+	client, err := u.Rep.GetByClientID(ctx, clientID)
+	if errors.Is(err, apperror.OAuthClientNotFoundErr) {
+		return nil, apperror.InvalidClientErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Сравниваем хеши за постоянное время
+	if subtle.ConstantTimeCompare([]byte(hasher.Sha256Hex(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, apperror.InvalidClientErr
+	}
+
+	if client.Disabled {
+		return nil, apperror.InvalidClientErr
+	}
+
+	return client, nil
+}
+
+// introspectAccess Проверка та же, что в AuthMiddleware: подпись, срок и denylist
+func (u *Usecase) introspectAccess(ctx context.Context, token string) (model.TokenIntrospection, error) {
+	claims, userID, ok := u.parseAccess(token)
+	if !ok {
+		return model.TokenIntrospection{}, nil
+	}
+
+	issuedAt := claims.IssuedTime()
+	revoked, err := u.AccessDenylist.IsRevoked(ctx, claims.ID, claims.SessionID, userID, issuedAt)
+	if err != nil || revoked {
+		return model.TokenIntrospection{}, err
+	}
+
+	return model.TokenIntrospection{
+		Active:    true,
+		TokenType: constants.TokenTypeAccess,
+		Sub:       claims.Subject,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+		Exp:       claims.ExpiresAt.Unix(),
+		Iat:       issuedAt.Unix(),
+		Jti:       claims.ID,
+	}, nil
+}
+
+func (u *Usecase) introspectRefresh(ctx context.Context, token string) (model.TokenIntrospection, error) {
+	session, err := u.findRefreshSession(ctx, token)
+	if err != nil || session == nil {
+		return model.TokenIntrospection{}, err
+	}
+
+	return model.TokenIntrospection{
+		Active:    true,
+		TokenType: constants.TokenTypeRefresh,
+		Sub:       strconv.FormatInt(session.UserID, 10),
+		// iat не отдаем: время выдачи текущей ротации в сессии не хранится, CreatedAt - время логина
+		Exp: session.ExpiresAt.Unix(),
+	}, nil
+}
+
+func (u *Usecase) revokeAccess(ctx context.Context, client *model.OAuthClient, token string) (int64, bool, error) {
+	claims, userID, ok := u.parseAccess(token)
+	if !ok {
+		return 0, false, nil
+	}
+
+	if !canRevoke(client, claims.ClientID) {
+		return 0, false, apperror.UnauthorizedClientErr
+	}
+
+	if err := u.AccessDenylist.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return 0, false, err
+	}
+
+	return userID, true, nil
+}
+
+// revokeRefresh Как завершение сессии: гасится вся цепочка ротаций и access-токены сессии (RFC 7009, 2.1)
+func (u *Usecase) revokeRefresh(ctx context.Context, client *model.OAuthClient, token string) (int64, bool, error) {
+	session, err := u.findRefreshSession(ctx, token)
+	if err != nil || session == nil {
+		return 0, false, err
+	}
+
+	// Refresh-токены выдаются только пользователям
+	if !canRevoke(client, "") {
+		return 0, false, apperror.UnauthorizedClientErr
+	}
+
+	// sid access-токенов - семейство, у сессий без семейства - сам TokenID
+	sid := session.TokenID
+	if session.FamilyID != "" {
+		sid = session.FamilyID
+		err = u.SessionCache.RevokeFamily(ctx, session.UserID, session.FamilyID)
+	} else {
+		err = u.SessionCache.DeleteSession(ctx, session.UserID, session.TokenID)
+		if err == nil {
+			err = u.SessionCache.DeleteRefreshTokenId(ctx, session.TokenHash)
+		}
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Отзываются access-токены всех ротаций, а не только последней
+	if err = u.AccessDenylist.RevokeSession(ctx, sid); err != nil {
+		return 0, false, err
+	}
+
+	return session.UserID, true, nil
+}
+
+// canRevoke tokenClientID - client_id токена сервиса, пустой у токенов пользователей
+func canRevoke(client *model.OAuthClient, tokenClientID string) bool {
+	if tokenClientID != "" {
+		return tokenClientID == client.ClientID
+	}
+
+	return slices.Contains(client.Scopes, constants.ScopeTokensRevoke)
+}
+
+// parseAccess У токена сервиса нет пользователя, userID тогда 0
+func (u *Usecase) parseAccess(token string) (model.AccessClaims, int64, bool) {
+	claims, err := u.TokenService.ParseToken(token)
+	if err != nil || claims.ExpiresAt == nil || claims.Subject == "" {
+		return model.AccessClaims{}, 0, false
+	}
+
+	if claims.ClientID != "" {
+		return claims, 0, claims.Subject == claims.ClientID
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return model.AccessClaims{}, 0, false
+	}
+
+	return claims, userID, true
+}
+
+// findRefreshSession Живая сессия по refresh токену, nil - если токен неизвестен или истек
+func (u *Usecase) findRefreshSession(ctx context.Context, token string) (*domcache.RefreshSession, error) {
+	hashedToken := hasher.Sha256Hex(token)
+	tokenID, err := u.SessionCache.GetRefreshTokenId(ctx, hashedToken)
+	if errors.Is(err, domcache.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := u.SessionCache.GetSession(ctx, tokenID)
+	if errors.Is(err, domcache.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Индекс по хешу мог пережить ротацию: токен должен совпадать с текущим токеном сессии
+	if session.TokenHash != hashedToken || !session.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+
+	return session, nil
+}
+
+// grantScopes Без scope в запросе выдаются все разрешенные клиенту (RFC 6749, 3.3).
+// Хотя бы один неразрешенный scope - отказ, урезать запрос молча не будем
+func grantScopes(allowed []string, requested string) ([]string, error) {
+	fields := strings.Fields(requested)
+	if len(fields) == 0 {
+		return allowed, nil
+	}
+
+	granted := make([]string, 0, len(fields))
+	for _, scope := range fields {
+		if !slices.Contains(allowed, scope) {
+			return nil, apperror.InvalidScopeErr
+		}
+
+		if !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	return granted, nil
+}
+
+// recordAudit У сервисов нет пользователя, клиент пишется в metadata.client_id
+func (u *Usecase) recordAudit(ctx context.Context, event model.AuditEvent) {
+	if u.Audit == nil {
+		return
+	}
+
+	u.Audit.Log(ctx, event)
+}
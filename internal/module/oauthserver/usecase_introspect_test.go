@@ -0,0 +1,430 @@
+package oauthserver
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const (
+	accessToken    = "header.payload.signature"
+	refreshToken   = "plain-refresh-token"
+	refreshTokenID = "refresh-token-id"
+	familyID       = "family-id"
+)
+
+var (
+	issuedAt  = time.Now().Truncate(time.Second)
+	expiresAt = issuedAt.Add(15 * time.Minute)
+)
+
+func userClaims() model.AccessClaims {
+	return model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			Subject:   "42",
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Role:      "user",
+		SessionID: familyID,
+	}
+}
+
+func refreshSession() *domcache.RefreshSession {
+	return &domcache.RefreshSession{
+		UserID:     42,
+		TokenID:    refreshTokenID,
+		TokenHash:  hasher.Sha256Hex(refreshToken),
+		FamilyID:   familyID,
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		LastUsedAt: issuedAt,
+	}
+}
+
+// gatewayClient Клиент со scope для интроспекции
+func gatewayClient() *model.OAuthClient {
+	client := newClient()
+	client.Scopes = append(client.Scopes, constants.ScopeTokensIntrospect)
+	return client
+}
+
+// revokerClient Клиент, которому можно отзывать токены пользователей
+func revokerClient() *model.OAuthClient {
+	client := newClient()
+	client.Scopes = append(client.Scopes, constants.ScopeTokensRevoke)
+	return client
+}
+
+type tokenMocks struct {
+	repo     *MockOAuthClientRepository
+	tokens   *mocks.MockTokenService
+	sessions *mocks.MockSessionCache
+	denylist *mocks.MockAccessTokenDenylist
+}
+
+func newTokenMocks() tokenMocks {
+	return tokenMocks{
+		repo:     new(MockOAuthClientRepository),
+		tokens:   new(mocks.MockTokenService),
+		sessions: new(mocks.MockSessionCache),
+		denylist: new(mocks.MockAccessTokenDenylist),
+	}
+}
+
+func (m tokenMocks) usecase() *Usecase {
+	return NewOAuthServerUsecase(m.repo, m.tokens, m.sessions, m.denylist, nil).(*Usecase)
+}
+
+func (m tokenMocks) assertExpectations(t *testing.T) {
+	m.repo.AssertExpectations(t)
+	m.tokens.AssertExpectations(t)
+	m.sessions.AssertExpectations(t)
+	m.denylist.AssertExpectations(t)
+}
+
+// notAccessToken Токен не разбирается как JWT
+func notAccessToken(m tokenMocks, token string) {
+	m.tokens.On("ParseToken", token).Return(model.AccessClaims{}, errors.New("malformed token"))
+}
+
+func TestIntrospect(t *testing.T) {
+	clientClaims := model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "client-jti",
+			Subject:   clientID,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		ClientID: clientID,
+		Scope:    "users:read",
+	}
+
+	cases := []struct {
+		name       string
+		token      string
+		hint       string
+		setupMocks func(tokenMocks)
+		want       model.TokenIntrospection
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name:  "user access token",
+			token: accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(userClaims(), nil)
+				m.denylist.On("IsRevoked", mock.Anything, "jti", familyID, int64(42), issuedAt).Return(false, nil)
+			},
+			want: model.TokenIntrospection{
+				Active: true, TokenType: constants.TokenTypeAccess, Sub: "42",
+				Exp: expiresAt.Unix(), Iat: issuedAt.Unix(), Jti: "jti",
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "client access token",
+			token: accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(clientClaims, nil)
+				m.denylist.On("IsRevoked", mock.Anything, "client-jti", "", int64(0), issuedAt).Return(false, nil)
+			},
+			want: model.TokenIntrospection{
+				Active: true, TokenType: constants.TokenTypeAccess, Sub: clientID, ClientID: clientID, Scope: "users:read",
+				Exp: expiresAt.Unix(), Iat: issuedAt.Unix(), Jti: "client-jti",
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "revoked access token",
+			token: accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(userClaims(), nil)
+				m.denylist.On("IsRevoked", mock.Anything, "jti", familyID, int64(42), issuedAt).Return(true, nil)
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(accessToken)).Return("", domcache.ErrNotFound)
+			},
+			want:       model.TokenIntrospection{Active: false},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "refresh token with hint",
+			token: refreshToken,
+			hint:  constants.TokenTypeRefresh,
+			setupMocks: func(m tokenMocks) {
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return(refreshTokenID, nil)
+				m.sessions.On("GetSession", mock.Anything, refreshTokenID).Return(refreshSession(), nil)
+			},
+			want: model.TokenIntrospection{
+				Active: true, TokenType: constants.TokenTypeRefresh, Sub: "42",
+				Exp: refreshSession().ExpiresAt.Unix(),
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "refresh token without hint",
+			token: refreshToken,
+			setupMocks: func(m tokenMocks) {
+				notAccessToken(m, refreshToken)
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return(refreshTokenID, nil)
+				m.sessions.On("GetSession", mock.Anything, refreshTokenID).Return(refreshSession(), nil)
+			},
+			want: model.TokenIntrospection{
+				Active: true, TokenType: constants.TokenTypeRefresh, Sub: "42",
+				Exp: refreshSession().ExpiresAt.Unix(),
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "rotated refresh token",
+			token: refreshToken,
+			hint:  constants.TokenTypeRefresh,
+			setupMocks: func(m tokenMocks) {
+				rotated := refreshSession()
+				rotated.TokenHash = hasher.Sha256Hex("newer-token")
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return(refreshTokenID, nil)
+				m.sessions.On("GetSession", mock.Anything, refreshTokenID).Return(rotated, nil)
+				notAccessToken(m, refreshToken)
+			},
+			want:       model.TokenIntrospection{Active: false},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "unknown token",
+			token: "garbage",
+			setupMocks: func(m tokenMocks) {
+				notAccessToken(m, "garbage")
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex("garbage")).Return("", domcache.ErrNotFound)
+			},
+			want:       model.TokenIntrospection{Active: false},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "session store error",
+			token: refreshToken,
+			hint:  constants.TokenTypeRefresh,
+			setupMocks: func(m tokenMocks) {
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return("", errors.New("redis is down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTokenMocks()
+			m.repo.On("GetByClientID", mock.Anything, clientID).Return(gatewayClient(), nil)
+			tc.setupMocks(m)
+
+			info, status, err := m.usecase().Introspect(context.Background(), clientID, clientSecret, tc.token, tc.hint, clientIP, clientUserAgent)
+
+			assert.Equal(t, tc.want, info)
+			assert.Equal(t, tc.wantStatus, status)
+			if tc.wantStatus == http.StatusOK {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestIntrospect_ClientNotAllowed(t *testing.T) {
+	t.Run("without introspect scope", func(t *testing.T) {
+		m := newTokenMocks()
+		m.repo.On("GetByClientID", mock.Anything, clientID).Return(newClient(), nil)
+
+		_, status, err := m.usecase().Introspect(context.Background(), clientID, clientSecret, accessToken, "", clientIP, clientUserAgent)
+
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.ErrorIs(t, err, apperror.UnauthorizedClientErr)
+		m.tokens.AssertNotCalled(t, "ParseToken", mock.Anything)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		m := newTokenMocks()
+		m.repo.On("GetByClientID", mock.Anything, clientID).Return(gatewayClient(), nil)
+
+		_, status, err := m.usecase().Introspect(context.Background(), clientID, "wrong", accessToken, "", clientIP, clientUserAgent)
+
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.ErrorIs(t, err, apperror.InvalidClientErr)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	clientClaims := func(owner string) model.AccessClaims {
+		return model.AccessClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "client-jti",
+				Subject:   owner,
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+			ClientID: owner,
+		}
+	}
+
+	cases := []struct {
+		name       string
+		client     *model.OAuthClient
+		token      string
+		hint       string
+		setupMocks func(tokenMocks)
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name:   "user access token",
+			client: revokerClient(),
+			token:  accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(userClaims(), nil)
+				m.denylist.On("RevokeToken", mock.Anything, "jti", expiresAt).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "refresh token revokes family and its access tokens",
+			client: revokerClient(),
+			token:  refreshToken,
+			hint:   constants.TokenTypeRefresh,
+			setupMocks: func(m tokenMocks) {
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return(refreshTokenID, nil)
+				m.sessions.On("GetSession", mock.Anything, refreshTokenID).Return(refreshSession(), nil)
+				m.sessions.On("RevokeFamily", mock.Anything, int64(42), familyID).Return(nil)
+				m.denylist.On("RevokeSession", mock.Anything, familyID).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "refresh token without family",
+			client: revokerClient(),
+			token:  refreshToken,
+			hint:   constants.TokenTypeRefresh,
+			setupMocks: func(m tokenMocks) {
+				legacy := refreshSession()
+				legacy.FamilyID = ""
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return(refreshTokenID, nil)
+				m.sessions.On("GetSession", mock.Anything, refreshTokenID).Return(legacy, nil)
+				m.sessions.On("DeleteSession", mock.Anything, int64(42), refreshTokenID).Return(nil)
+				m.sessions.On("DeleteRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return(nil)
+				m.denylist.On("RevokeSession", mock.Anything, refreshTokenID).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "unknown token is not an error",
+			client: newClient(),
+			token:  "garbage",
+			setupMocks: func(m tokenMocks) {
+				notAccessToken(m, "garbage")
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex("garbage")).Return("", domcache.ErrNotFound)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "own client token without scope",
+			client: newClient(),
+			token:  accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(clientClaims(clientID), nil)
+				m.denylist.On("RevokeToken", mock.Anything, "client-jti", expiresAt).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "token of another client",
+			client: revokerClient(),
+			token:  accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(clientClaims("other-client"), nil)
+			},
+			wantStatus: http.StatusForbidden,
+			wantErr:    apperror.UnauthorizedClientErr,
+		},
+		{
+			name:   "user access token without revoke scope",
+			client: newClient(),
+			token:  accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(userClaims(), nil)
+			},
+			wantStatus: http.StatusForbidden,
+			wantErr:    apperror.UnauthorizedClientErr,
+		},
+		{
+			name:   "refresh token without revoke scope",
+			client: newClient(),
+			token:  refreshToken,
+			hint:   constants.TokenTypeRefresh,
+			setupMocks: func(m tokenMocks) {
+				m.sessions.On("GetRefreshTokenId", mock.Anything, hasher.Sha256Hex(refreshToken)).Return(refreshTokenID, nil)
+				m.sessions.On("GetSession", mock.Anything, refreshTokenID).Return(refreshSession(), nil)
+			},
+			wantStatus: http.StatusForbidden,
+			wantErr:    apperror.UnauthorizedClientErr,
+		},
+		{
+			name:   "denylist error",
+			client: revokerClient(),
+			token:  accessToken,
+			setupMocks: func(m tokenMocks) {
+				m.tokens.On("ParseToken", accessToken).Return(userClaims(), nil)
+				m.denylist.On("RevokeToken", mock.Anything, "jti", expiresAt).Return(errors.New("redis is down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTokenMocks()
+			m.repo.On("GetByClientID", mock.Anything, clientID).Return(tc.client, nil)
+			tc.setupMocks(m)
+
+			status, err := m.usecase().Revoke(context.Background(), clientID, clientSecret, tc.token, tc.hint, clientIP, clientUserAgent)
+
+			assert.Equal(t, tc.wantStatus, status)
+			switch {
+			case tc.wantErr != nil:
+				assert.ErrorIs(t, err, tc.wantErr)
+			case tc.wantStatus == http.StatusOK:
+				assert.NoError(t, err)
+			default:
+				assert.Error(t, err)
+			}
+			m.assertExpectations(t)
+		})
+	}
+
+	t.Run("audit", func(t *testing.T) {
+		m := newTokenMocks()
+		m.repo.On("GetByClientID", mock.Anything, clientID).Return(revokerClient(), nil)
+		m.tokens.On("ParseToken", accessToken).Return(userClaims(), nil)
+		m.denylist.On("RevokeToken", mock.Anything, "jti", expiresAt).Return(nil)
+		auditLogger := new(mocks.MockAuditLogger)
+		auditLogger.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+			return e.Action == constants.AuditTokenRevoke && e.UserID == 42 &&
+				e.Metadata["client_id"] == clientID && e.Metadata["token_type"] == constants.TokenTypeAccess
+		})).Return()
+
+		uc := m.usecase()
+		uc.Audit = auditLogger
+		_, err := uc.Revoke(context.Background(), clientID, clientSecret, accessToken, "", clientIP, clientUserAgent)
+
+		require.NoError(t, err)
+		auditLogger.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,36 @@
+package oauthserver
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOAuthClientRepository struct {
+	mock.Mock
+}
+
+func (m *MockOAuthClientRepository) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	args := m.Called(ctx, clientID)
+	client, _ := args.Get(0).(*model.OAuthClient)
+	return client, args.Error(1)
+}
+
+type MockOAuthServerUsecase struct {
+	mock.Mock
+}
+
+func (m *MockOAuthServerUsecase) ClientCredentials(ctx context.Context, clientID, clientSecret, scope, clientIP, ua string) (model.ClientToken, int, error) {
+	args := m.Called(ctx, clientID, clientSecret, scope, clientIP, ua)
+	return args.Get(0).(model.ClientToken), args.Int(1), args.Error(2)
+}
+
+func (m *MockOAuthServerUsecase) Introspect(ctx context.Context, clientID, clientSecret, token, tokenTypeHint, clientIP, ua string) (model.TokenIntrospection, int, error) {
+	args := m.Called(ctx, clientID, clientSecret, token, tokenTypeHint, clientIP, ua)
+	return args.Get(0).(model.TokenIntrospection), args.Int(1), args.Error(2)
+}
+
+func (m *MockOAuthServerUsecase) Revoke(ctx context.Context, clientID, clientSecret, token, tokenTypeHint, clientIP, ua string) (int, error) {
+	args := m.Called(ctx, clientID, clientSecret, token, tokenTypeHint, clientIP, ua)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,159 @@
+package oauthserver
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const (
+	clientID        = "billing"
+	clientSecret    = "s3cr3t-value"
+	clientIP        = "127.0.0.1"
+	clientUserAgent = "testClient"
+)
+
+func newClient() *model.OAuthClient {
+	return &model.OAuthClient{
+		ID:         1,
+		ClientID:   clientID,
+		SecretHash: hasher.Sha256Hex(clientSecret),
+		Name:       "Billing",
+		Scopes:     []string{"users:read", "audit:read"},
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	disabled := newClient()
+	disabled.Disabled = true
+
+	cases := []struct {
+		name       string
+		secret     string
+		scope      string
+		setupMocks func(*MockOAuthClientRepository, *mocks.MockTokenService)
+		want       model.ClientToken
+		wantStatus int
+		wantErr    error
+		wantAudit  string
+	}{
+		{
+			name:   "all allowed scopes by default",
+			secret: clientSecret,
+			setupMocks: func(repo *MockOAuthClientRepository, ts *mocks.MockTokenService) {
+				repo.On("GetByClientID", mock.Anything, clientID).Return(newClient(), nil)
+				ts.On("GenerateClientToken", clientID, []string{"users:read", "audit:read"}).Return("access", 15*time.Minute, nil)
+			},
+			want:       model.ClientToken{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, Scope: "users:read audit:read"},
+			wantStatus: http.StatusOK,
+			wantAudit:  constants.AuditClientToken,
+		},
+		{
+			name:   "requested subset",
+			secret: clientSecret,
+			scope:  "audit:read audit:read",
+			setupMocks: func(repo *MockOAuthClientRepository, ts *mocks.MockTokenService) {
+				repo.On("GetByClientID", mock.Anything, clientID).Return(newClient(), nil)
+				ts.On("GenerateClientToken", clientID, []string{"audit:read"}).Return("access", 15*time.Minute, nil)
+			},
+			want:       model.ClientToken{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, Scope: "audit:read"},
+			wantStatus: http.StatusOK,
+			wantAudit:  constants.AuditClientToken,
+		},
+		{
+			name:   "scope is not allowed",
+			secret: clientSecret,
+			scope:  "users:read users:manage",
+			setupMocks: func(repo *MockOAuthClientRepository, _ *mocks.MockTokenService) {
+				repo.On("GetByClientID", mock.Anything, clientID).Return(newClient(), nil)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantErr:    apperror.InvalidScopeErr,
+		},
+		{
+			name:   "wrong secret",
+			secret: "wrong",
+			setupMocks: func(repo *MockOAuthClientRepository, _ *mocks.MockTokenService) {
+				repo.On("GetByClientID", mock.Anything, clientID).Return(newClient(), nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidClientErr,
+			wantAudit:  constants.AuditClientAuthFailure,
+		},
+		{
+			name:   "unknown client",
+			secret: clientSecret,
+			setupMocks: func(repo *MockOAuthClientRepository, _ *mocks.MockTokenService) {
+				repo.On("GetByClientID", mock.Anything, clientID).Return(nil, apperror.OAuthClientNotFoundErr)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidClientErr,
+			wantAudit:  constants.AuditClientAuthFailure,
+		},
+		{
+			name:   "disabled client",
+			secret: clientSecret,
+			setupMocks: func(repo *MockOAuthClientRepository, _ *mocks.MockTokenService) {
+				repo.On("GetByClientID", mock.Anything, clientID).Return(disabled, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidClientErr,
+			wantAudit:  constants.AuditClientAuthFailure,
+		},
+		{
+			name:       "empty secret",
+			setupMocks: func(*MockOAuthClientRepository, *mocks.MockTokenService) {},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidClientErr,
+			wantAudit:  constants.AuditClientAuthFailure,
+		},
+		{
+			name:   "repository error",
+			secret: clientSecret,
+			setupMocks: func(repo *MockOAuthClientRepository, _ *mocks.MockTokenService) {
+				repo.On("GetByClientID", mock.Anything, clientID).Return(nil, errors.New("db is down"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(MockOAuthClientRepository)
+			tokenSvc := new(mocks.MockTokenService)
+			auditLogger := new(mocks.MockAuditLogger)
+			tc.setupMocks(repo, tokenSvc)
+			if tc.wantAudit != "" {
+				auditLogger.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+					return e.Action == tc.wantAudit && e.UserID == 0 && e.Metadata["client_id"] == clientID && e.IP == clientIP
+				})).Return()
+			}
+
+			uc := NewOAuthServerUsecase(repo, tokenSvc, nil, nil, auditLogger)
+			token, status, err := uc.ClientCredentials(context.Background(), clientID, tc.secret, tc.scope, clientIP, clientUserAgent)
+
+			assert.Equal(t, tc.want, token)
+			assert.Equal(t, tc.wantStatus, status)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else if tc.wantStatus != http.StatusOK {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			tokenSvc.AssertExpectations(t)
+			auditLogger.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"time"
+)
+
+type PermissionRepository struct {
+	DB *sql.DB
+}
+
+func NewPermissionRepository(db *sql.DB) repository.PermissionRepository {
+	return &PermissionRepository{DB: db}
+}
+
+func (p *PermissionRepository) GetRolePermissions(ctx context.Context, roleCode string) ([]string, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	rows, err := p.DB.QueryContext(ctxTimeout, `SELECT p.code FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		JOIN roles r ON r.id = rp.role_id
+		WHERE r.code = $1
+		ORDER BY p.code`, roleCode)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := make([]string, 0)
+	for rows.Next() {
+		var code string
+		if err = rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		permissions = append(permissions, code)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return permissions, nil
+}
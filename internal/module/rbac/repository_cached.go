@@ -0,0 +1,50 @@
+package rbac
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"sync"
+	"time"
+)
+
+// CachedPermissionRepository Права ролей меняются редко, а нужны на каждый запрос, поэтому держим их в памяти
+type CachedPermissionRepository struct {
+	repo    repository.PermissionRepository
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]cachedPermissions
+}
+
+type cachedPermissions struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+func NewCachedPermissionRepository(repo repository.PermissionRepository, ttl time.Duration) repository.PermissionRepository {
+	return &CachedPermissionRepository{
+		repo:    repo,
+		ttl:     ttl,
+		entries: make(map[string]cachedPermissions),
+	}
+}
+
+func (c *CachedPermissionRepository) GetRolePermissions(ctx context.Context, roleCode string) ([]string, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[roleCode]
+	c.mu.RUnlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	permissions, err := c.repo.GetRolePermissions(ctx, roleCode)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[roleCode] = cachedPermissions{permissions: permissions, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return permissions, nil
+}
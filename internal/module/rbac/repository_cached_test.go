@@ -0,0 +1,64 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCachedPermissionRepository(t *testing.T) {
+	t.Run("second call served from cache", func(t *testing.T) {
+		repo := new(mocks.MockPermissionRepository)
+		repo.On("GetRolePermissions", mock.Anything, "admin").
+			Return([]string{"users:read"}, nil).Once()
+
+		cached := NewCachedPermissionRepository(repo, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			perms, err := cached.GetRolePermissions(context.Background(), "admin")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"users:read"}, perms)
+		}
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("expired entry reloaded", func(t *testing.T) {
+		repo := new(mocks.MockPermissionRepository)
+		repo.On("GetRolePermissions", mock.Anything, "admin").
+			Return([]string{"users:read"}, nil).Twice()
+
+		cached := NewCachedPermissionRepository(repo, -time.Second)
+
+		for i := 0; i < 2; i++ {
+			_, err := cached.GetRolePermissions(context.Background(), "admin")
+			require.NoError(t, err)
+		}
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		repo := new(mocks.MockPermissionRepository)
+		repo.On("GetRolePermissions", mock.Anything, "admin").
+			Return(nil, errors.New("db is down")).Once()
+		repo.On("GetRolePermissions", mock.Anything, "admin").
+			Return([]string{"users:read"}, nil).Once()
+
+		cached := NewCachedPermissionRepository(repo, time.Minute)
+
+		_, err := cached.GetRolePermissions(context.Background(), "admin")
+		require.Error(t, err)
+
+		perms, err := cached.GetRolePermissions(context.Background(), "admin")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users:read"}, perms)
+
+		repo.AssertExpectations(t)
+	})
+}
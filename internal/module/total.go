@@ -2,19 +2,65 @@ package module
 
 import (
 	"database/sql"
+	rediscache "github.com/Elaman1/full-project-mock/internal/cache"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/audit"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/mailer"
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/module/account"
+	"github.com/Elaman1/full-project-mock/internal/module/admin"
+	"github.com/Elaman1/full-project-mock/internal/module/apikey"
+	auditmodule "github.com/Elaman1/full-project-mock/internal/module/audit"
+	"github.com/Elaman1/full-project-mock/internal/module/jwks"
+	"github.com/Elaman1/full-project-mock/internal/module/mfa"
+	"github.com/Elaman1/full-project-mock/internal/module/oauth"
+	"github.com/Elaman1/full-project-mock/internal/module/oauthserver"
 	"github.com/Elaman1/full-project-mock/internal/module/user"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"github.com/redis/go-redis/v9"
 )
 
 type Modules struct {
-	UserHandler *user.UserHandler
+	UserHandler        *user.UserHandler
+	JWKSHandler        *jwks.JWKSHandler
+	AdminHandler       *admin.AdminHandler
+	MFAHandler         *mfa.MFAHandler
+	AuditHandler       *auditmodule.AuditHandler
+	OAuthHandler       *oauth.OAuthHandler
+	OAuthServerHandler *oauthserver.OAuthServerHandler
+	APIKeyHandler      *apikey.APIKeyHandler
+	AccountHandler     *account.AccountHandler
 }
 
 // InitAllModule Инициализируем все модули здесь, Если новые добавиться то просто здесь же добавляем
-func InitAllModule(db *sql.DB, redisDB *redis.Client, tokenService usecase.TokenService) *Modules {
-	userHandler := user.InitUserModule(db, redisDB, tokenService)
+func InitAllModule(db *sql.DB, redisDB *redis.Client, tokenService usecase.TokenService, accessDenylist cache.AccessTokenDenylist, mail mailer.Mailer, mfaCrypter mfa.SecretCrypter, passwordPolicy validator.PasswordPolicy, auditLogger audit.AuditLogger, cfg *config.Config) *Modules {
+	// MFA первым: его usecase нужен логину
+	// Общий для логина и админки, чтобы администратор мог снять блокировку
+	loginThrottle := rediscache.NewLoginThrottleRedis(redisDB, cfg.LoginThrottle)
+	userRepo := user.NewUserRepository(db)
+	reauth := service.NewReauthenticator(loginThrottle, passwordPolicy)
+	mfaHandler := mfa.InitMFAModule(db, redisDB, userRepo, mfaCrypter, reauth, cfg.MFA.Issuer)
+	userHandler := user.InitUserModule(db, redisDB, tokenService, accessDenylist, mail, mfaHandler.Usecase, loginThrottle, passwordPolicy, auditLogger, cfg.Auth, cfg.MFA)
+	jwksHandler := jwks.InitJWKSModule(tokenService)
+	adminHandler := admin.InitAdminModule(db, redisDB, accessDenylist, loginThrottle, auditLogger)
+	auditHandler := auditmodule.InitAuditModule(db)
+	// Токены после входа через провайдера выдает usecase пользователей
+	oauthHandler := oauth.InitOAuthModule(db, redisDB, userHandler.Usecase, auditLogger, cfg.OAuth)
+	oauthServerHandler := oauthserver.InitOAuthServerModule(db, redisDB, tokenService, accessDenylist, auditLogger)
+	apiKeyHandler := apikey.InitAPIKeyModule(db, auditLogger)
+	// Выгрузка данных собирает профиль, сессии, привязки, ключи и журнал из других модулей
+	accountHandler := account.InitAccountModule(db, redisDB, accessDenylist, userRepo, userHandler.Usecase, oauth.NewIdentityRepository(db), apiKeyHandler.Usecase, auditHandler.Usecase, auditLogger, reauth, cfg.Account)
 	return &Modules{
-		UserHandler: userHandler,
+		UserHandler:        userHandler,
+		JWKSHandler:        jwksHandler,
+		AdminHandler:       adminHandler,
+		MFAHandler:         mfaHandler,
+		AuditHandler:       auditHandler,
+		OAuthHandler:       oauthHandler,
+		OAuthServerHandler: oauthServerHandler,
+		APIKeyHandler:      apiKeyHandler,
+		AccountHandler:     accountHandler,
 	}
 }
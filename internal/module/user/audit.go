@@ -0,0 +1,25 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+)
+
+// Причины неудачного входа в metadata.reason журнала аудита
+const (
+	loginFailureUserNotFound     = "user_not_found"
+	loginFailureWrongPassword    = "wrong_password"
+	loginFailureInvalidMFACode   = "invalid_mfa_code"
+	loginFailureLockedOut        = "locked_out"
+	loginFailureBlocked          = "blocked"
+	loginFailureEmailNotVerified = "email_not_verified"
+)
+
+// recordAudit Журнал необязателен: без него (например, в тестах) события только пишутся в лог
+func (u *Usecase) recordAudit(ctx context.Context, event model.AuditEvent) {
+	if u.Audit == nil {
+		return
+	}
+
+	u.Audit.Log(ctx, event)
+}
@@ -0,0 +1,129 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/useragent"
+	"net/http"
+	"net/netip"
+)
+
+// Политики привязки refresh-сессии к IP и User-Agent (auth.session_binding)
+const (
+	SessionBindingStrict   = "strict"    // IP и User-Agent должны совпадать полностью
+	SessionBindingSubnet   = "subnet"    // та же подсеть /24 (IPv6 - /64) и тот же браузер и ОС
+	SessionBindingUAFamily = "ua_family" // IP не проверяется, только браузер и ОС
+	SessionBindingOff      = "off"
+)
+
+// checkSessionBinding Любое расхождение отпечатка пишется в лог и журнал аудита, даже если политика его допускает.
+// stepUp - можно ли вместо отказа предложить подтвердить сессию паролем
+func (u *Usecase) checkSessionBinding(ctx context.Context, sess *domcache.RefreshSession, clientIP, ua string, stepUp bool) (int, error) {
+	if sess.IP == clientIP && sess.UserAgent == ua {
+		return http.StatusOK, nil
+	}
+
+	policy := u.Auth.SessionBinding
+	if policy == "" {
+		policy = SessionBindingStrict
+	}
+
+	allowed := bindingAllows(policy, sess, clientIP, ua)
+	stepUp = stepUp && !allowed && u.Auth.SessionStepUp
+
+	service.LoggerFromContext(ctx).Warn("security event: session fingerprint changed",
+		"event", "session_fingerprint_mismatch",
+		"user_id", sess.UserID,
+		"session_id", sessionID(sess),
+		"policy", policy,
+		"allowed", allowed,
+		"step_up", stepUp,
+		"session_ip", sess.IP,
+		"ip", clientIP,
+		"session_user_agent", sess.UserAgent,
+		"user_agent", ua,
+	)
+	u.recordAudit(ctx, model.AuditEvent{
+		UserID:    sess.UserID,
+		Action:    constants.AuditSessionFingerprintMismatch,
+		IP:        clientIP,
+		UserAgent: ua,
+		Metadata: map[string]any{
+			"session_id":         sessionID(sess),
+			"policy":             policy,
+			"allowed":            allowed,
+			"step_up":            stepUp,
+			"session_ip":         sess.IP,
+			"session_user_agent": sess.UserAgent,
+		},
+	})
+
+	if allowed {
+		return http.StatusOK, nil
+	}
+
+	if stepUp {
+		return http.StatusUnauthorized, apperror.StepUpRequiredErr
+	}
+
+	return http.StatusUnauthorized, fmt.Errorf("авторизуйтесь еще раз")
+}
+
+func bindingAllows(policy string, sess *domcache.RefreshSession, clientIP, ua string) bool {
+	switch policy {
+	case SessionBindingOff:
+		return true
+	case SessionBindingUAFamily:
+		return sameUAFamily(sess.UserAgent, ua)
+	case SessionBindingSubnet:
+		return sameSubnet(sess.IP, clientIP) && sameUAFamily(sess.UserAgent, ua)
+	default:
+		return sess.IP == clientIP && sess.UserAgent == ua
+	}
+}
+
+// sameSubnet /24 для IPv4 и /64 для IPv6. Нераспознанные адреса сравниваются как строки
+func sameSubnet(a, b string) bool {
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	addrA, addrB = addrA.Unmap(), addrB.Unmap()
+	if addrA.Is4() != addrB.Is4() {
+		return false
+	}
+
+	bits := 64
+	if addrA.Is4() {
+		bits = 24
+	}
+
+	prefix, err := addrA.Prefix(bits)
+	if err != nil {
+		return false
+	}
+
+	return prefix.Contains(addrB)
+}
+
+// sameUAFamily Обновление браузера меняет версию в User-Agent, но не браузер и ОС
+func sameUAFamily(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	deviceA, deviceB := useragent.Parse(a), useragent.Parse(b)
+	if deviceA.Browser == "unknown" || deviceA.OS == "unknown" {
+		// По нераспознанному User-Agent семейство не определить
+		return false
+	}
+
+	return deviceA.Browser == deviceB.Browser && deviceA.OS == deviceB.OS && deviceA.Type == deviceB.Type
+}
@@ -2,12 +2,17 @@ package user
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
 	"github.com/Elaman1/full-project-mock/internal/middleware"
 	"github.com/Elaman1/full-project-mock/internal/service"
 	"github.com/Elaman1/full-project-mock/pkg/req"
 	"github.com/Elaman1/full-project-mock/pkg/respond"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
 	"net/http"
 )
 
@@ -32,6 +37,10 @@ func (u *UserHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newUserId, err := u.Usecase.Register(r.Context(), registerUser.Email, registerUser.Username, registerUser.Password)
+	if respondPasswordPolicy(w, err, "User registration error", lgr) {
+		return
+	}
+
 	if err != nil {
 
 		msg := fmt.Sprintf("User registration error: %v", err)
@@ -61,13 +70,51 @@ func (u *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ip, userAgent := req.GetClientMeta(r)
-	accessToken, refreshToken, httpStatus, err := u.Usecase.Login(r.Context(), loginRequest.Email, loginRequest.Password, ip, userAgent)
+	result, httpStatus, err := u.Usecase.Login(r.Context(), loginRequest.Email, loginRequest.Password, ip, userAgent)
 	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
 		msg := fmt.Sprintf("User login error: %v", err)
 		respond.WithError(w, httpStatus, msg, lgr)
 		return
 	}
 
+	if result.MFARequired {
+		respond.WithSuccessJSON(w, httpStatus, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		return
+	}
+
+	respond.WithSuccessJSON(w, httpStatus, map[string]string{
+		"access_token":  result.AccessToken,
+		"refresh_token": result.RefreshToken,
+	})
+}
+
+func (u *UserHandler) LoginMFAHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var loginRequest LoginMFARequest
+	err := json.NewDecoder(r.Body).Decode(&loginRequest)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err = loginRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("MFA login validation error: %v", err), lgr)
+		return
+	}
+
+	ip, userAgent := req.GetClientMeta(r)
+	accessToken, refreshToken, httpStatus, err := u.Usecase.LoginMFA(r.Context(), loginRequest.MFAToken, loginRequest.Code, ip, userAgent)
+	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, httpStatus, fmt.Sprintf("MFA login error: %v", err), lgr)
+		return
+	}
+
 	respond.WithSuccessJSON(w, httpStatus, map[string]string{
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
@@ -75,18 +122,70 @@ func (u *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (u *UserHandler) MeHandler(w http.ResponseWriter, r *http.Request) {
-	id, ok := middleware.GetUserIDFromContext(r.Context())
-	if ok {
-		json.NewEncoder(w).Encode(map[string]string{
-			"id": id,
-		})
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
 		return
 	}
 
-	w.WriteHeader(http.StatusNotFound)
-	json.NewEncoder(w).Encode(map[string]string{
-		"error": "Not found",
-	})
+	user, err := u.Usecase.GetProfile(r.Context(), principal.UserID)
+	if err != nil {
+		respond.WithError(w, profileErrorStatus(err), fmt.Sprintf("Get profile error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, newProfileResponse(user))
+}
+
+func (u *UserHandler) UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	var updateRequest UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err := updateRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Update profile validation error: %v", err), lgr)
+		return
+	}
+
+	ip, _ := req.GetClientMeta(r)
+	user, err := u.Usecase.UpdateProfile(r.Context(), principal.UserID, updateRequest.Version, updateRequest.Username, updateRequest.Email, updateRequest.CurrentPassword, ip)
+	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, profileErrorStatus(err), fmt.Sprintf("Update profile error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, newProfileResponse(user))
+}
+
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperror.UserNotFoundErr):
+		return http.StatusNotFound
+	case errors.Is(err, apperror.PasswordRequiredErr),
+		errors.Is(err, apperror.WrongPasswordErr):
+		return http.StatusBadRequest
+	case errors.Is(err, apperror.VersionConflictErr),
+		errors.Is(err, apperror.ExistsEmailErr),
+		errors.Is(err, apperror.ExistsUsernameErr):
+		return http.StatusConflict
+	case errors.Is(err, apperror.TooManyLoginAttemptsErr):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func (u *UserHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
@@ -101,6 +200,14 @@ func (u *UserHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
 
 	ip, userAgent := req.GetClientMeta(r)
 	accessToken, refreshToken, httpStatus, err := u.Usecase.Refresh(r.Context(), refreshRequest.AccessToken, refreshRequest.RefreshToken, ip, userAgent)
+	if errors.Is(err, apperror.StepUpRequiredErr) {
+		respond.WithErrorJSON(w, httpStatus, StepUpRequiredResponse{
+			Error:          fmt.Sprintf("Refresh error: %v", err),
+			StepUpRequired: true,
+		}, lgr)
+		return
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("Refresh error: %v", err)
 		respond.WithError(w, httpStatus, msg, lgr)
@@ -113,6 +220,34 @@ func (u *UserHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (u *UserHandler) RefreshStepUpHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var stepUpRequest StepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&stepUpRequest); err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err := stepUpRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Step-up validation error: %v", err), lgr)
+		return
+	}
+
+	ip, userAgent := req.GetClientMeta(r)
+	accessToken, refreshToken, httpStatus, err := u.Usecase.RefreshStepUp(r.Context(), stepUpRequest.RefreshToken, stepUpRequest.Password, stepUpRequest.Code, ip, userAgent)
+	if err != nil {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, httpStatus, fmt.Sprintf("Step-up error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, httpStatus, map[string]string{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
+
 func (u *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	lgr := service.LoggerFromContext(r.Context())
 
@@ -123,8 +258,9 @@ func (u *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	accessToken, _ := req.GetBearerToken(r)
 	ip, userAgent := req.GetClientMeta(r)
-	err = u.Usecase.Logout(r.Context(), refreshRequest.RefreshToken, ip, userAgent)
+	err = u.Usecase.Logout(r.Context(), accessToken, refreshRequest.RefreshToken, ip, userAgent)
 	if err != nil {
 		respond.WithError(w, http.StatusBadRequest, "error logout", lgr)
 		return
@@ -152,3 +288,216 @@ func (u *UserHandler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func (u *UserHandler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var verifyRequest VerifyEmailRequest
+	err := json.NewDecoder(r.Body).Decode(&verifyRequest)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err = verifyRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Verify email validation error: %v", err), lgr)
+		return
+	}
+
+	err = u.Usecase.VerifyEmail(r.Context(), verifyRequest.Token)
+	if errors.Is(err, apperror.InvalidTokenErr) {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Verify email error: %v", err), lgr)
+		return
+	}
+
+	// Новый адрес успели занять, пока смена ждала подтверждения
+	if errors.Is(err, apperror.ExistsEmailErr) {
+		respond.WithError(w, http.StatusConflict, fmt.Sprintf("Verify email error: %v", err), lgr)
+		return
+	}
+
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("Verify email error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "email verified")
+}
+
+func (u *UserHandler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var resendRequest ResendVerificationRequest
+	err := json.NewDecoder(r.Body).Decode(&resendRequest)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err = resendRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Resend verification validation error: %v", err), lgr)
+		return
+	}
+
+	u.Usecase.ResendVerification(r.Context(), resendRequest.Email)
+
+	// Ответ одинаковый независимо от того, зарегистрирован ли email
+	respond.WithSuccess(w, http.StatusAccepted, "if the email is registered and not verified, a new letter has been sent")
+}
+
+func (u *UserHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var forgotRequest ForgotPasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&forgotRequest)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err = forgotRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Forgot password validation error: %v", err), lgr)
+		return
+	}
+
+	u.Usecase.ForgotPassword(r.Context(), forgotRequest.Email)
+
+	// Ответ одинаковый независимо от того, зарегистрирован ли email
+	respond.WithSuccess(w, http.StatusAccepted, "if the email is registered, a password reset link has been sent")
+}
+
+func (u *UserHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	var resetRequest ResetPasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&resetRequest)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err = resetRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Reset password validation error: %v", err), lgr)
+		return
+	}
+
+	err = u.Usecase.ResetPassword(r.Context(), resetRequest.Token, resetRequest.Password)
+	if respondPasswordPolicy(w, err, "Reset password error", lgr) {
+		return
+	}
+
+	if errors.Is(err, apperror.InvalidTokenErr) {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Reset password error: %v", err), lgr)
+		return
+	}
+
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("Reset password error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccess(w, http.StatusOK, "password changed")
+}
+
+func (u *UserHandler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	var changeRequest ChangePasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&changeRequest)
+	if err != nil {
+		respond.WithError(w, http.StatusBadRequest, "Invalid request payload", lgr)
+		return
+	}
+
+	if err = changeRequest.Validate(); err != nil {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Change password validation error: %v", err), lgr)
+		return
+	}
+
+	ip, _ := req.GetClientMeta(r)
+	accessToken, err := u.Usecase.ChangePassword(r.Context(), principal.UserID, principal.SessionID, changeRequest.CurrentPassword, changeRequest.NewPassword, ip)
+	if errors.Is(err, apperror.WrongPasswordErr) {
+		respond.WithError(w, http.StatusBadRequest, fmt.Sprintf("Change password error: %v", err), lgr)
+		return
+	}
+
+	if errors.Is(err, apperror.TooManyLoginAttemptsErr) {
+		respond.SetRetryAfter(w, apperror.RetryAfter(err))
+		respond.WithError(w, http.StatusTooManyRequests, fmt.Sprintf("Change password error: %v", err), lgr)
+		return
+	}
+
+	if respondPasswordPolicy(w, err, "Change password error", lgr) {
+		return
+	}
+
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("Change password error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, map[string]string{
+		"access_token": accessToken,
+	})
+}
+
+// respondPasswordPolicy Нарушения политики пароля отдаются списком, чтобы клиент показал их все сразу
+func respondPasswordPolicy(w http.ResponseWriter, err error, msg string, lgr *slog.Logger) bool {
+	var policyErr *validator.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	respond.WithErrorJSON(w, http.StatusBadRequest, PasswordPolicyErrorResponse{
+		Error:      fmt.Sprintf("%s: %v", msg, err),
+		Violations: policyErr.Violations,
+	}, lgr)
+	return true
+}
+
+func (u *UserHandler) SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	sessions, err := u.Usecase.ListSessions(r.Context(), principal.UserID, principal.SessionID)
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("List sessions error: %v", err), lgr)
+		return
+	}
+
+	respond.WithSuccessJSON(w, http.StatusOK, SessionsResponse{Items: sessions})
+}
+
+func (u *UserHandler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	lgr := service.LoggerFromContext(r.Context())
+
+	principal, ok := middleware.GetPrincipalFromContext(r.Context())
+	if !ok {
+		respond.WithError(w, http.StatusUnauthorized, "Unauthorized", lgr)
+		return
+	}
+
+	err := u.Usecase.RevokeSession(r.Context(), principal.UserID, chi.URLParam(r, "id"))
+	if errors.Is(err, apperror.SessionNotFoundErr) {
+		respond.WithError(w, http.StatusNotFound, fmt.Sprintf("Revoke session error: %v", err), lgr)
+		return
+	}
+
+	if err != nil {
+		respond.WithError(w, http.StatusInternalServerError, fmt.Sprintf("Revoke session error: %v", err), lgr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
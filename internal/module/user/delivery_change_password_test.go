@@ -0,0 +1,116 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangePasswordHandler(t *testing.T) {
+	principal := &model.Principal{UserID: int64(defaultUserId), SessionID: refreshTokenId}
+
+	tests := []struct {
+		name         string
+		body         string
+		principal    *model.Principal
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Unauthorized",
+			body:         `{"current_password":"old-password","new_password":"new-password"}`,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Same password",
+			body:         `{"current_password":"old-password","new_password":"old-password"}`,
+			principal:    principal,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Change password validation error: new password must differ from current"}`,
+		},
+		{
+			name:      "Password policy violation",
+			body:      `{"current_password":"old-password","new_password":"short"}`,
+			principal: principal,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ChangePassword", mock.Anything, int64(defaultUserId), refreshTokenId, "old-password", "short", mock.Anything).
+					Return("", validator.PasswordPolicy{}.Validate("short")).Once()
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Change password error: password must be at least 8 characters","violations":[{"rule":"min_length","message":"password must be at least 8 characters"}]}`,
+		},
+		{
+			name:      "Wrong current password",
+			body:      `{"current_password":"old-password","new_password":"new-password"}`,
+			principal: principal,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ChangePassword", mock.Anything, int64(defaultUserId), refreshTokenId, "old-password", "new-password", mock.Anything).
+					Return("", apperror.WrongPasswordErr).Once()
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Change password error: current password is incorrect"}`,
+		},
+		{
+			name:      "Locked out",
+			body:      `{"current_password":"old-password","new_password":"new-password"}`,
+			principal: principal,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ChangePassword", mock.Anything, int64(defaultUserId), refreshTokenId, "old-password", "new-password", mock.Anything).
+					Return("", &apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: time.Minute}).Once()
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedBody: `{"error":"Change password error: too many login attempts"}`,
+		},
+		{
+			name:      "Success",
+			body:      `{"current_password":"old-password","new_password":"new-password"}`,
+			principal: principal,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ChangePassword", mock.Anything, int64(defaultUserId), refreshTokenId, "old-password", "new-password", mock.Anything).
+					Return(accessToken, nil).Once()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"access_token":"testAccessToken"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			ctx := service.WithLogger(context.Background(), slog.Default())
+			if tt.principal != nil {
+				ctx = middleware.SetPrincipalToContext(ctx, tt.principal)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/password", strings.NewReader(tt.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			handler.ChangePasswordHandler(rec, req)
+
+			body, _ := io.ReadAll(rec.Body)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Contains(t, string(body), tt.expectedBody)
+
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoginMFAHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Invalid JSON",
+			body:         `{"mfa_token":`,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Invalid request payload"}`,
+		},
+		{
+			name:         "Empty code",
+			body:         `{"mfa_token":"mfa-token"}`,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"MFA login validation error: mfa_token or code is empty"}`,
+		},
+		{
+			name: "Wrong code",
+			body: `{"mfa_token":"mfa-token","code":"123456"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("LoginMFA", mock.Anything, "mfa-token", "123456", ipAddress, testAgent).
+					Return("", "", http.StatusUnauthorized, apperror.InvalidMFACodeErr).Once()
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"MFA login error: invalid mfa code"}`,
+		},
+		{
+			name: "Success",
+			body: `{"mfa_token":"mfa-token","code":"123456"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("LoginMFA", mock.Anything, "mfa-token", "123456", ipAddress, testAgent).
+					Return("access-token", "refresh-token", http.StatusOK, nil).Once()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"access_token":"access-token","refresh_token":"refresh-token"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			req := httptest.NewRequest(http.MethodPost, "/login/mfa", strings.NewReader(tt.body))
+			req.Header.Set("User-Agent", testAgent)
+			req.RemoteAddr = ipAddress
+			req = req.WithContext(service.WithLogger(req.Context(), slog.Default()))
+
+			rec := httptest.NewRecorder()
+			handler.LoginMFAHandler(rec, req)
+
+			body, _ := io.ReadAll(rec.Result().Body)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Contains(t, string(body), tt.expectedBody)
+
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
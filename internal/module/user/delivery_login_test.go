@@ -3,6 +3,8 @@ package user
 import (
 	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -12,6 +14,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -28,6 +31,8 @@ func TestLoginHandler(t *testing.T) {
 		mockSetup    func(m *MockUserUsecase)
 		expectedCode int
 		expectedBody string
+		// expectedRetryAfter Заголовок Retry-After, пусто - заголовка нет
+		expectedRetryAfter string
 	}
 	tests := []struct {
 		name string
@@ -48,7 +53,7 @@ func TestLoginHandler(t *testing.T) {
 				body: fmt.Sprintf(`{"email":"%s","password":"%s"}`, email, wrongPass),
 				mockSetup: func(m *MockUserUsecase) {
 					m.On("Login", mock.Anything, email, wrongPass, ipAddress, testAgent).
-						Return("", "", http.StatusBadRequest, errors.New("invalid credentials")).Once()
+						Return(model.LoginResult{}, http.StatusBadRequest, errors.New("invalid credentials")).Once()
 				},
 				expectedCode: http.StatusBadRequest,
 				expectedBody: `{"error":"User login error: invalid credentials"}`,
@@ -60,12 +65,37 @@ func TestLoginHandler(t *testing.T) {
 				body: fmt.Sprintf(`{"email":"%s","password":"%s"}`, email, correctPass),
 				mockSetup: func(m *MockUserUsecase) {
 					m.On("Login", mock.Anything, email, correctPass, ipAddress, testAgent).
-						Return("access-token", "refresh-token", http.StatusOK, nil).Once()
+						Return(model.LoginResult{AccessToken: "access-token", RefreshToken: "refresh-token"}, http.StatusOK, nil).Once()
 				},
 				expectedCode: http.StatusOK,
 				expectedBody: `"access_token":"access-token"`,
 			},
 		},
+		{
+			name: "Locked out",
+			args: args{
+				body: fmt.Sprintf(`{"email":"%s","password":"%s"}`, email, correctPass),
+				mockSetup: func(m *MockUserUsecase) {
+					m.On("Login", mock.Anything, email, correctPass, ipAddress, testAgent).
+						Return(model.LoginResult{}, http.StatusTooManyRequests, &apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: 1500 * time.Millisecond}).Once()
+				},
+				expectedCode:       http.StatusTooManyRequests,
+				expectedBody:       `{"error":"User login error: too many login attempts"}`,
+				expectedRetryAfter: "2",
+			},
+		},
+		{
+			name: "MFA required",
+			args: args{
+				body: fmt.Sprintf(`{"email":"%s","password":"%s"}`, email, correctPass),
+				mockSetup: func(m *MockUserUsecase) {
+					m.On("Login", mock.Anything, email, correctPass, ipAddress, testAgent).
+						Return(model.LoginResult{MFARequired: true, MFAToken: "mfa-token"}, http.StatusOK, nil).Once()
+				},
+				expectedCode: http.StatusOK,
+				expectedBody: `{"mfa_required":true,"mfa_token":"mfa-token"}`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -91,6 +121,7 @@ func TestLoginHandler(t *testing.T) {
 
 			assert.Equal(t, tt.args.expectedCode, res.StatusCode)
 			assert.Contains(t, string(body), tt.args.expectedBody)
+			assert.Equal(t, tt.args.expectedRetryAfter, res.Header.Get("Retry-After"))
 
 			mockUsecase.AssertExpectations(t)
 		})
@@ -39,7 +39,7 @@ func TestLogoutHandler(t *testing.T) {
 			args: args{
 				body: fmt.Sprintf(`{"refresh_token":"%s"}`, refreshStr),
 				mockSetup: func(m *MockUserUsecase) {
-					m.On("Logout", mock.Anything, refreshStr, ipAddress, testAgent).
+					m.On("Logout", mock.Anything, accessStr, refreshStr, ipAddress, testAgent).
 						Return(errors.New("logout failed")).Once()
 				},
 				expectedCode: http.StatusBadRequest,
@@ -51,7 +51,7 @@ func TestLogoutHandler(t *testing.T) {
 			args: args{
 				body: fmt.Sprintf(`{"refresh_token":"%s"}`, refreshStr),
 				mockSetup: func(m *MockUserUsecase) {
-					m.On("Logout", mock.Anything, refreshStr, ipAddress, testAgent).
+					m.On("Logout", mock.Anything, accessStr, refreshStr, ipAddress, testAgent).
 						Return(nil).Once()
 				},
 				expectedCode: http.StatusCreated,
@@ -69,6 +69,7 @@ func TestLogoutHandler(t *testing.T) {
 
 			req := httptest.NewRequest(http.MethodPost, "/logout", strings.NewReader(tt.args.body))
 			req.Header.Set("User-Agent", testAgent)
+			req.Header.Set("Authorization", "Bearer "+accessStr)
 			req.RemoteAddr = ipAddress
 			req = req.WithContext(service.WithLogger(req.Context(), slog.Default()))
 
@@ -2,42 +2,71 @@ package user
 
 import (
 	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/internal/middleware"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
-func TestMeHandler(t *testing.T) {
-	handler := &UserHandler{}
+func profileUser() *model.User {
+	return &model.User{
+		ID:        123,
+		Email:     "user@test.com",
+		Username:  "user",
+		Password:  "bcrypt-hash",
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Role:      model.UserRole{Code: "user", Name: "Пользователь"},
+		Version:   2,
+	}
+}
 
+func TestMeHandler(t *testing.T) {
 	tests := []struct {
 		name         string
 		ctx          context.Context
+		setupMock    func(*MockUserUsecase)
 		expectedCode int
 		expectedBody string
 	}{
 		{
-			name: "User ID found in context",
-			ctx: func() context.Context {
-				ctx := context.Background()
-				return middleware.SetUserIDToContext(ctx, "123")
-			}(),
+			name: "profile without password",
+			ctx:  middleware.SetPrincipalToContext(context.Background(), &model.Principal{UserID: 123}),
+			setupMock: func(uc *MockUserUsecase) {
+				uc.On("GetProfile", mock.Anything, int64(123)).Return(profileUser(), nil)
+			},
 			expectedCode: http.StatusOK,
-			expectedBody: `{"id":"123"}`,
+			expectedBody: `{"id":123,"email":"user@test.com","username":"user","role":{"code":"user","name":"Пользователь"},"created_at":"2026-01-01T00:00:00Z","email_verified":false,"mfa_enabled":false,"version":2}`,
 		},
 		{
-			name:         "User ID not found in context",
-			ctx:          context.Background(),
+			name: "user deleted",
+			ctx:  middleware.SetPrincipalToContext(context.Background(), &model.Principal{UserID: 123}),
+			setupMock: func(uc *MockUserUsecase) {
+				uc.On("GetProfile", mock.Anything, int64(123)).Return(nil, apperror.UserNotFoundErr)
+			},
 			expectedCode: http.StatusNotFound,
-			expectedBody: `{"error":"Not found"}`,
+		},
+		{
+			name:         "no principal",
+			ctx:          context.Background(),
+			expectedCode: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			if tt.setupMock != nil {
+				tt.setupMock(mockUsecase)
+			}
+			handler := &UserHandler{Usecase: mockUsecase}
+
 			req := httptest.NewRequest(http.MethodGet, "/me", nil)
 			req = req.WithContext(tt.ctx)
 
@@ -50,7 +79,109 @@ func TestMeHandler(t *testing.T) {
 			body, _ := io.ReadAll(res.Body)
 
 			assert.Equal(t, tt.expectedCode, res.StatusCode)
-			assert.JSONEq(t, tt.expectedBody, string(body))
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, string(body))
+			}
+			assert.NotContains(t, string(body), "bcrypt-hash")
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateMeHandler(t *testing.T) {
+	username := "renamed"
+	email := "new@example.com"
+
+	tests := []struct {
+		name         string
+		body         string
+		setupMock    func(*MockUserUsecase)
+		expectedCode int
+	}{
+		{
+			name: "updated",
+			body: `{"version":2,"username":"renamed"}`,
+			setupMock: func(uc *MockUserUsecase) {
+				updated := profileUser()
+				updated.Username = username
+				updated.Version = 3
+				uc.On("UpdateProfile", mock.Anything, int64(123), int64(2), &username, (*string)(nil), "", mock.Anything).Return(updated, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "missing version",
+			body:         `{"username":"renamed"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid email",
+			body:         `{"version":2,"email":"not-an-email"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "version conflict",
+			body: `{"version":2,"username":"renamed"}`,
+			setupMock: func(uc *MockUserUsecase) {
+				uc.On("UpdateProfile", mock.Anything, int64(123), int64(2), &username, (*string)(nil), "", mock.Anything).Return(nil, apperror.VersionConflictErr)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "email change without password",
+			body: `{"version":2,"email":"new@example.com"}`,
+			setupMock: func(uc *MockUserUsecase) {
+				uc.On("UpdateProfile", mock.Anything, int64(123), int64(2), (*string)(nil), &email, "", mock.Anything).Return(nil, apperror.PasswordRequiredErr)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "email change with wrong password",
+			body: `{"version":2,"email":"new@example.com","current_password":"wrong"}`,
+			setupMock: func(uc *MockUserUsecase) {
+				uc.On("UpdateProfile", mock.Anything, int64(123), int64(2), (*string)(nil), &email, "wrong", mock.Anything).Return(nil, apperror.WrongPasswordErr)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "email change locked out",
+			body: `{"version":2,"email":"new@example.com","current_password":"wrong"}`,
+			setupMock: func(uc *MockUserUsecase) {
+				uc.On("UpdateProfile", mock.Anything, int64(123), int64(2), (*string)(nil), &email, "wrong", mock.Anything).
+					Return(nil, &apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: time.Minute})
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "username taken",
+			body: `{"version":2,"username":"renamed"}`,
+			setupMock: func(uc *MockUserUsecase) {
+				uc.On("UpdateProfile", mock.Anything, int64(123), int64(2), &username, (*string)(nil), "", mock.Anything).Return(nil, apperror.ExistsUsernameErr)
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			if tt.setupMock != nil {
+				tt.setupMock(mockUsecase)
+			}
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			req := httptest.NewRequest(http.MethodPatch, "/auth/me", strings.NewReader(tt.body))
+			req = req.WithContext(middleware.SetPrincipalToContext(req.Context(), &model.Principal{UserID: 123, SessionID: "session-id"}))
+
+			rec := httptest.NewRecorder()
+			handler.UpdateMeHandler(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"username":"renamed"`)
+				assert.Contains(t, rec.Body.String(), `"version":3`)
+			}
+			mockUsecase.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,120 @@
+package user
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestForgotPasswordHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Invalid email",
+			body:         `{"email":"bad"}`,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Forgot password validation error: invalid email"}`,
+		},
+		{
+			// Поиск и отправка идут в фоне, ответ для любого email один и тот же
+			name: "Accepted",
+			body: `{"email":"test@example.com"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ForgotPassword", mock.Anything, "test@example.com").Return().Once()
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: `{"success":"if the email is registered, a password reset link has been sent"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			req := httptest.NewRequest(http.MethodPost, "/password/forgot", strings.NewReader(tt.body))
+			req = req.WithContext(service.WithLogger(req.Context(), slog.Default()))
+			rec := httptest.NewRecorder()
+
+			handler.ForgotPasswordHandler(rec, req)
+
+			body, _ := io.ReadAll(rec.Body)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Contains(t, string(body), tt.expectedBody)
+
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResetPasswordHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Empty password",
+			body:         `{"token":"tok","password":""}`,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Reset password validation error: token or password is empty"}`,
+		},
+		{
+			name: "Invalid token",
+			body: `{"token":"tok","password":"new-pass"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ResetPassword", mock.Anything, "tok", "new-pass").Return(apperror.InvalidTokenErr).Once()
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Reset password error: invalid or expired token"}`,
+		},
+		{
+			name: "Success",
+			body: `{"token":"tok","password":"new-pass"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ResetPassword", mock.Anything, "tok", "new-pass").Return(nil).Once()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"success":"password changed"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			req := httptest.NewRequest(http.MethodPost, "/password/reset", strings.NewReader(tt.body))
+			req = req.WithContext(service.WithLogger(req.Context(), slog.Default()))
+			rec := httptest.NewRecorder()
+
+			handler.ResetPasswordHandler(rec, req)
+
+			body, _ := io.ReadAll(rec.Body)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Contains(t, string(body), tt.expectedBody)
+
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
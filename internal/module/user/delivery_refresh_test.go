@@ -3,6 +3,7 @@ package user
 import (
 	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
 	"github.com/Elaman1/full-project-mock/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -46,6 +47,18 @@ func TestRefreshHandler(t *testing.T) {
 				expectedBody: `{"error":"Refresh error: invalid token"}`,
 			},
 		},
+		{
+			name: "Step-up required",
+			args: args{
+				body: fmt.Sprintf(`{"access_token":"%s","refresh_token":"%s"}`, accessStr, refreshStr),
+				mockSetup: func(m *MockUserUsecase) {
+					m.On("Refresh", mock.Anything, accessStr, refreshStr, ipAddress, testAgent).
+						Return("", "", http.StatusUnauthorized, apperror.StepUpRequiredErr).Once()
+				},
+				expectedCode: http.StatusUnauthorized,
+				expectedBody: `"step_up_required":true`,
+			},
+		},
 		{
 			name: "Success",
 			args: args{
@@ -87,3 +100,64 @@ func TestRefreshHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshStepUpHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Empty password",
+			body:         fmt.Sprintf(`{"refresh_token":"%s"}`, refreshStr),
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Step-up validation error: refresh_token or password is empty"}`,
+		},
+		{
+			name: "Wrong password",
+			body: fmt.Sprintf(`{"refresh_token":"%s","password":"wrong"}`, refreshStr),
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("RefreshStepUp", mock.Anything, refreshStr, "wrong", "", ipAddress, testAgent).
+					Return("", "", http.StatusUnauthorized, apperror.WrongPasswordErr).Once()
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"Step-up error: current password is incorrect"}`,
+		},
+		{
+			name: "Success",
+			body: fmt.Sprintf(`{"refresh_token":"%s","password":"secret","code":"123456"}`, refreshStr),
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("RefreshStepUp", mock.Anything, refreshStr, "secret", "123456", ipAddress, testAgent).
+					Return("new-access", "new-refresh", http.StatusOK, nil).Once()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"refresh_token":"new-refresh"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			req := httptest.NewRequest(http.MethodPost, "/refresh/step-up", strings.NewReader(tt.body))
+			req.Header.Set("User-Agent", testAgent)
+			req.RemoteAddr = ipAddress
+			req = req.WithContext(service.WithLogger(req.Context(), slog.Default()))
+
+			rec := httptest.NewRecorder()
+			handler.RefreshStepUpHandler(rec, req)
+
+			body, _ := io.ReadAll(rec.Body)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Contains(t, string(body), tt.expectedBody)
+
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
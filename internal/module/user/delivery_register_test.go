@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -111,13 +112,23 @@ func (mock *MockUserUsecase) Register(ctx context.Context, email, username, pass
 	return id, args.Error(1)
 }
 
-func (mock *MockUserUsecase) Login(ctx context.Context, email, password, clientIP, ua string) (string, string, int, error) {
+func (mock *MockUserUsecase) Login(ctx context.Context, email, password, clientIP, ua string) (model.LoginResult, int, error) {
 	args := mock.Called(ctx, email, password, clientIP, ua)
+	return args.Get(0).(model.LoginResult), args.Int(1), args.Error(2)
+}
+
+func (mock *MockUserUsecase) LoginMFA(ctx context.Context, mfaToken, code, clientIP, ua string) (string, string, int, error) {
+	args := mock.Called(ctx, mfaToken, code, clientIP, ua)
 	return args.String(0), args.String(1), args.Int(2), args.Error(3)
 }
 
-func (mock *MockUserUsecase) Logout(ctx context.Context, refreshToken, clientIP, ua string) error {
-	args := mock.Called(ctx, refreshToken, clientIP, ua)
+func (mock *MockUserUsecase) LoginWithIdentity(ctx context.Context, userID int64, provider, clientIP, ua string) (model.LoginResult, int, error) {
+	args := mock.Called(ctx, userID, provider, clientIP, ua)
+	return args.Get(0).(model.LoginResult), args.Int(1), args.Error(2)
+}
+
+func (mock *MockUserUsecase) Logout(ctx context.Context, accessToken, refreshToken, clientIP, ua string) error {
+	args := mock.Called(ctx, accessToken, refreshToken, clientIP, ua)
 	return args.Error(0)
 }
 
@@ -130,3 +141,63 @@ func (mock *MockUserUsecase) Refresh(ctx context.Context, accessToken, refreshTo
 	args := mock.Called(ctx, accessToken, refreshToken, clientIP, ua)
 	return args.String(0), args.String(1), args.Int(2), args.Error(3)
 }
+
+func (mock *MockUserUsecase) VerifyEmail(ctx context.Context, token string) error {
+	args := mock.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (mock *MockUserUsecase) ResendVerification(ctx context.Context, email string) {
+	mock.Called(ctx, email)
+}
+
+func (mock *MockUserUsecase) ForgotPassword(ctx context.Context, email string) {
+	mock.Called(ctx, email)
+}
+
+func (mock *MockUserUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	args := mock.Called(ctx, token, newPassword)
+	return args.Error(0)
+}
+
+func (mock *MockUserUsecase) ChangePassword(ctx context.Context, userID int64, sessionID, currentPassword, newPassword, clientIP string) (string, error) {
+	args := mock.Called(ctx, userID, sessionID, currentPassword, newPassword, clientIP)
+	return args.String(0), args.Error(1)
+}
+
+func (mock *MockUserUsecase) Close(ctx context.Context) error {
+	args := mock.Called(ctx)
+	return args.Error(0)
+}
+
+func (mock *MockUserUsecase) ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]model.Session, error) {
+	args := mock.Called(ctx, userID, currentSessionID)
+	sessions, ok := args.Get(0).([]model.Session)
+	if !ok {
+		return nil, args.Error(1)
+	}
+
+	return sessions, args.Error(1)
+}
+
+func (mock *MockUserUsecase) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	args := mock.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (mock *MockUserUsecase) RefreshStepUp(ctx context.Context, refreshToken, password, code, clientIP, ua string) (string, string, int, error) {
+	args := mock.Called(ctx, refreshToken, password, code, clientIP, ua)
+	return args.String(0), args.String(1), args.Int(2), args.Error(3)
+}
+
+func (mock *MockUserUsecase) GetProfile(ctx context.Context, userID int64) (*model.User, error) {
+	args := mock.Called(ctx, userID)
+	user, _ := args.Get(0).(*model.User)
+	return user, args.Error(1)
+}
+
+func (mock *MockUserUsecase) UpdateProfile(ctx context.Context, userID, version int64, username, email *string, currentPassword, clientIP string) (*model.User, error) {
+	args := mock.Called(ctx, userID, version, username, email, currentPassword, clientIP)
+	user, _ := args.Get(0).(*model.User)
+	return user, args.Error(1)
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/middleware"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/useragent"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newSessionsRouter(uc *MockUserUsecase) http.Handler {
+	handler := &UserHandler{Usecase: uc}
+
+	r := chi.NewRouter()
+	r.Get("/auth/sessions", handler.SessionsHandler)
+	r.Delete("/auth/sessions/{id}", handler.RevokeSessionHandler)
+	return r
+}
+
+func sessionsRequest(method, target string, principal *model.Principal) *http.Request {
+	ctx := service.WithLogger(context.Background(), slog.Default())
+	if principal != nil {
+		ctx = middleware.SetPrincipalToContext(ctx, principal)
+	}
+
+	return httptest.NewRequest(method, target, nil).WithContext(ctx)
+}
+
+func TestSessionsHandler(t *testing.T) {
+	principal := &model.Principal{UserID: int64(defaultUserId), SessionID: refreshTokenId}
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		uc := new(MockUserUsecase)
+		uc.On("ListSessions", mock.Anything, int64(defaultUserId), refreshTokenId).Return([]model.Session{{
+			ID:         familyID,
+			Current:    true,
+			IP:         clientIP,
+			Device:     useragent.Device{Browser: "Chrome", OS: "Windows", Type: "desktop"},
+			CreatedAt:  createdAt,
+			LastUsedAt: createdAt,
+			ExpiresAt:  createdAt.Add(time.Hour),
+		}}, nil)
+
+		rec := httptest.NewRecorder()
+		newSessionsRouter(uc).ServeHTTP(rec, sessionsRequest(http.MethodGet, "/auth/sessions", principal))
+
+		body, _ := io.ReadAll(rec.Body)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"items":[{"id":"testFamilyId","current":true,"ip":"127.0.0.1","device":{"browser":"Chrome","os":"Windows","type":"desktop"},"created_at":"2025-01-02T03:04:05Z","last_used_at":"2025-01-02T03:04:05Z","expires_at":"2025-01-02T04:04:05Z"}]}`, string(body))
+		uc.AssertExpectations(t)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		uc := new(MockUserUsecase)
+
+		rec := httptest.NewRecorder()
+		newSessionsRouter(uc).ServeHTTP(rec, sessionsRequest(http.MethodGet, "/auth/sessions", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestRevokeSessionHandler(t *testing.T) {
+	principal := &model.Principal{UserID: int64(defaultUserId), SessionID: refreshTokenId}
+
+	tests := []struct {
+		name         string
+		principal    *model.Principal
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+	}{
+		{
+			name:      "success",
+			principal: principal,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("RevokeSession", mock.Anything, int64(defaultUserId), familyID).Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:      "not found",
+			principal: principal,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("RevokeSession", mock.Anything, int64(defaultUserId), familyID).Return(apperror.SessionNotFoundErr)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:      "internal error",
+			principal: principal,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("RevokeSession", mock.Anything, int64(defaultUserId), familyID).Return(customErr)
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:         "unauthorized",
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := new(MockUserUsecase)
+			tt.mockSetup(uc)
+
+			rec := httptest.NewRecorder()
+			newSessionsRouter(uc).ServeHTTP(rec, sessionsRequest(http.MethodDelete, "/auth/sessions/"+familyID, tt.principal))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			uc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package user
+
+import (
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerifyEmailHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Empty token",
+			body:         `{"token":""}`,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Verify email validation error: token is empty"}`,
+		},
+		{
+			name: "Invalid token",
+			body: `{"token":"bad"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("VerifyEmail", mock.Anything, "bad").Return(apperror.InvalidTokenErr).Once()
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"Verify email error: invalid or expired token"}`,
+		},
+		{
+			name: "New email taken",
+			body: `{"token":"good"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("VerifyEmail", mock.Anything, "good").Return(apperror.ExistsEmailErr).Once()
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"error":"Verify email error: email already exists"}`,
+		},
+		{
+			name: "Usecase returns error",
+			body: `{"token":"good"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("VerifyEmail", mock.Anything, "good").Return(customErr).Once()
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Verify email error: custom error"}`,
+		},
+		{
+			name: "Success",
+			body: `{"token":"good"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("VerifyEmail", mock.Anything, "good").Return(nil).Once()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"success":"email verified"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			req := httptest.NewRequest(http.MethodPost, "/verify-email", strings.NewReader(tt.body))
+			req = req.WithContext(service.WithLogger(req.Context(), slog.Default()))
+			rec := httptest.NewRecorder()
+
+			handler.VerifyEmailHandler(rec, req)
+
+			body, _ := io.ReadAll(rec.Body)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Contains(t, string(body), tt.expectedBody)
+
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResendVerificationHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(m *MockUserUsecase)
+		expectedCode int
+	}{
+		{
+			name:         "Invalid email",
+			body:         `{"email":"bad"}`,
+			mockSetup:    func(m *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			// Поиск и отправка идут в фоне, ответ для любого email один и тот же
+			name: "Accepted",
+			body: `{"email":"test@example.com"}`,
+			mockSetup: func(m *MockUserUsecase) {
+				m.On("ResendVerification", mock.Anything, "test@example.com").Return().Once()
+			},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := new(MockUserUsecase)
+			tt.mockSetup(mockUsecase)
+
+			handler := &UserHandler{Usecase: mockUsecase}
+
+			req := httptest.NewRequest(http.MethodPost, "/verify-email/resend", strings.NewReader(tt.body))
+			req = req.WithContext(service.WithLogger(req.Context(), slog.Default()))
+			rec := httptest.NewRecorder()
+
+			handler.ResendVerificationHandler(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
@@ -2,7 +2,10 @@ package user
 
 import (
 	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"time"
+	"unicode/utf8"
 )
 
 type RegisterRequest struct {
@@ -23,6 +26,12 @@ func (r RegisterRequest) Validate() error {
 	return nil
 }
 
+// PasswordPolicyErrorResponse Ответ 400, если новый пароль не прошел политику паролей
+type PasswordPolicyErrorResponse struct {
+	Error      string                        `json:"error"`
+	Violations []validator.PasswordViolation `json:"violations"`
+}
+
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -40,7 +49,181 @@ func (r LoginRequest) Validate() error {
 	return nil
 }
 
+type SessionsResponse struct {
+	Items []model.Session `json:"items"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+func (r LoginMFARequest) Validate() error {
+	if r.MFAToken == "" || r.Code == "" {
+		return errors.New("mfa_token or code is empty")
+	}
+
+	return nil
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 	AccessToken  string `json:"access_token"`
 }
+
+// StepUpRequiredResponse Ответ /refresh, когда сессию нужно подтвердить через /refresh/step-up
+type StepUpRequiredResponse struct {
+	Error          string `json:"error"`
+	StepUpRequired bool   `json:"step_up_required"`
+}
+
+type StepUpRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	Password     string `json:"password"`
+	Code         string `json:"code,omitempty"` // TOTP или код восстановления, если включен MFA
+}
+
+func (r StepUpRequest) Validate() error {
+	if r.RefreshToken == "" || r.Password == "" {
+		return errors.New("refresh_token or password is empty")
+	}
+
+	return nil
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func (r VerifyEmailRequest) Validate() error {
+	if r.Token == "" {
+		return errors.New("token is empty")
+	}
+
+	return nil
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+func (r ResendVerificationRequest) Validate() error {
+	if !validator.IsValidEmail(r.Email) {
+		return errors.New("invalid email")
+	}
+
+	return nil
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func (r ForgotPasswordRequest) Validate() error {
+	if !validator.IsValidEmail(r.Email) {
+		return errors.New("invalid email")
+	}
+
+	return nil
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (r ResetPasswordRequest) Validate() error {
+	if r.Token == "" || r.Password == "" {
+		return errors.New("token or password is empty")
+	}
+
+	return nil
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (r ChangePasswordRequest) Validate() error {
+	if r.CurrentPassword == "" || r.NewPassword == "" {
+		return errors.New("current or new password is empty")
+	}
+
+	if r.CurrentPassword == r.NewPassword {
+		return errors.New("new password must differ from current")
+	}
+
+	return nil
+}
+
+// maxUsernameLen Как у users.name
+const maxUsernameLen = 500
+
+type UpdateProfileRequest struct {
+	Version  int64   `json:"version"`
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+	// CurrentPassword Обязателен при смене email
+	CurrentPassword string `json:"current_password"`
+}
+
+func (r UpdateProfileRequest) Validate() error {
+	if r.Version <= 0 {
+		return errors.New("version is required")
+	}
+
+	if r.Username == nil && r.Email == nil {
+		return errors.New("username or email is required")
+	}
+
+	if r.Username != nil && (*r.Username == "" || utf8.RuneCountInString(*r.Username) > maxUsernameLen) {
+		return errors.New("invalid username")
+	}
+
+	if r.Email != nil && !validator.IsValidEmail(*r.Email) {
+		return errors.New("invalid email")
+	}
+
+	return nil
+}
+
+// ProfileResponse Профиль для /auth/me, без пароля и служебных полей
+type ProfileResponse struct {
+	ID              int64       `json:"id"`
+	Email           string      `json:"email"`
+	Username        string      `json:"username"`
+	Role            ProfileRole `json:"role"`
+	CreatedAt       time.Time   `json:"created_at"`
+	EmailVerified   bool        `json:"email_verified"`
+	EmailVerifiedAt *time.Time  `json:"email_verified_at,omitempty"`
+	// PendingEmail Новый email, ожидающий подтверждения по ссылке
+	PendingEmail *string `json:"pending_email,omitempty"`
+	MFAEnabled   bool    `json:"mfa_enabled"`
+	Version      int64   `json:"version"`
+}
+
+type ProfileRole struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func newProfileResponse(user *model.User) ProfileResponse {
+	return ProfileResponse{
+		ID:              user.ID,
+		Email:           user.Email,
+		Username:        user.Username,
+		Role:            ProfileRole{Code: user.Role.Code, Name: user.Role.Name},
+		CreatedAt:       user.CreatedAt,
+		EmailVerified:   user.EmailVerifiedAt != nil,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PendingEmail:    user.PendingEmail,
+		MFAEnabled:      user.MFAEnabledAt != nil,
+		Version:         user.Version,
+	}
+}
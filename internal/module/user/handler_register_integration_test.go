@@ -7,9 +7,12 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/Elaman1/full-project-mock/internal/cache"
+	"github.com/Elaman1/full-project-mock/internal/config"
 	cache2 "github.com/Elaman1/full-project-mock/internal/domain/cache"
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/mailer"
 	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"log/slog"
@@ -51,7 +54,13 @@ func buildUserHandlerIntegration(t *testing.T, tx *sql.Tx) (*UserHandler, usecas
 	sessionCache := cache.NewSessionRedisRepository(testRedis)
 	privateKey, publicKey := generateTestKeys(t)
 	tokenService := service.NewTokenService(publicKey, privateKey, accessTTL)
-	usecase := NewUserUsecase(userRepo, tokenService, sessionCache)
+	accessDenylist := cache.NewAccessDenylistRedis(testRedis, accessTTL)
+	emailTokens := cache.NewOneTimeTokenRedis(testRedis, "auth:email_verify")
+	emailChangeTokens := cache.NewOneTimeTokenRedis(testRedis, "auth:email_change")
+	resetTokens := cache.NewOneTimeTokenRedis(testRedis, "auth:password_reset")
+	mfaChallenges := cache.NewOneTimeTokenRedis(testRedis, "auth:mfa_challenge")
+	// У тестовых пользователей MFA не включен, поэтому MFA usecase не нужен
+	usecase := NewUserUsecase(userRepo, tokenService, sessionCache, accessDenylist, emailTokens, emailChangeTokens, resetTokens, mailer.NewFileMailer("", slog.Default()), nil, mfaChallenges, cache.NewLoginThrottleRedis(testRedis, config.LoginThrottle{}), validator.PasswordPolicy{}, nil, config.Auth{}, config.MFA{})
 	return &UserHandler{Usecase: usecase}, tokenService, sessionCache
 }
 func TestRegisterHandler_Integration(t *testing.T) {
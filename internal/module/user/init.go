@@ -3,14 +3,23 @@ package user
 import (
 	"database/sql"
 	"github.com/Elaman1/full-project-mock/internal/cache"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
+	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/mailer"
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"github.com/redis/go-redis/v9"
 )
 
-func InitUserModule(db *sql.DB, redisDB *redis.Client, tokenService usecase.TokenService) *UserHandler {
+func InitUserModule(db *sql.DB, redisDB *redis.Client, tokenService usecase.TokenService, accessDenylist domcache.AccessTokenDenylist, mail mailer.Mailer, mfa usecase.MFAUsecase, loginThrottle domcache.LoginThrottle, passwordPolicy validator.PasswordPolicy, auditLogger domaudit.AuditLogger, authCfg config.Auth, mfaCfg config.MFA) *UserHandler {
 	sessionCache := cache.NewSessionRedisRepository(redisDB)
+	emailTokens := cache.NewOneTimeTokenRedis(redisDB, "auth:email_verify")
+	emailChangeTokens := cache.NewOneTimeTokenRedis(redisDB, "auth:email_change")
+	resetTokens := cache.NewOneTimeTokenRedis(redisDB, "auth:password_reset")
+	mfaChallenges := cache.NewOneTimeTokenRedis(redisDB, "auth:mfa_challenge")
 	userRepo := NewUserRepository(db)
-	userUsecase := NewUserUsecase(userRepo, tokenService, sessionCache)
+	userUsecase := NewUserUsecase(userRepo, tokenService, sessionCache, accessDenylist, emailTokens, emailChangeTokens, resetTokens, mail, mfa, mfaChallenges, loginThrottle, passwordPolicy, auditLogger, authCfg, mfaCfg)
 	return NewUserHandler(userUsecase)
 }
 
@@ -5,11 +5,26 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
 	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/internal/domain/repository"
+	"github.com/lib/pq"
 	"time"
 )
 
+// selectUserQuery Роль подтягиваем сразу, она нужна для claims access-токена.
+// Удаленные пользователем аккаунты не выбираются: войти и обновить токены по ним нельзя
+const selectUserQuery = `SELECT u.id, u.email, u.name, u.password, u.created_at, u.role_id, COALESCE(r.code, ''), COALESCE(r.name, ''), u.blocked, u.email_verified_at, u.mfa_enabled_at, u.version, u.pending_email
+	FROM users u LEFT JOIN roles r ON r.id = u.role_id`
+
+const uniqueViolationCode = "23505"
+
+// Уникальные ограничения users: name и lower(email)
+const (
+	usernameConstraint = "users_pk"
+	emailConstraint    = "users_email_lower_key"
+)
+
 type Repository struct {
 	DB DBExecutor
 }
@@ -31,7 +46,8 @@ func (u *Repository) Create(ctx context.Context, user *model.User) error {
 	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	_, err := u.DB.ExecContext(ctxTimeout, "INSERT INTO users (email, password, name, role_id) VALUES ($1, $2, $3, $4)", user.Email, user.Password, user.Username, user.RoleID)
+	err := u.DB.QueryRowContext(ctxTimeout, "INSERT INTO users (email, password, name, role_id) VALUES ($1, $2, $3, $4) RETURNING id", user.Email, user.Password, user.Username, user.RoleID).
+		Scan(&user.ID)
 	if err != nil {
 		return fmt.Errorf("create user error: %w", err)
 	}
@@ -44,17 +60,18 @@ func (u *Repository) Get(ctx context.Context, email string) (*model.User, error)
 	defer cancel()
 
 	user := &model.User{}
-	err := u.DB.QueryRowContext(ctxTimeout, "SELECT id, email, name, password, created_at, role_id FROM users WHERE email = $1", email).
-		Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.RoleID)
+	err := u.DB.QueryRowContext(ctxTimeout, selectUserQuery+" WHERE lower(u.email) = lower($1) AND u.deleted_at IS NULL", email).
+		Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.RoleID, &user.Role.Code, &user.Role.Name, &user.Blocked, &user.EmailVerifiedAt, &user.MFAEnabledAt, &user.Version, &user.PendingEmail)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperror.UserNotFoundErr
 		}
 
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 
+	user.Role.ID = user.RoleID
 	return user, nil
 }
 
@@ -63,7 +80,7 @@ func (u *Repository) Exists(ctx context.Context, email string) (bool, error) {
 	defer cancel()
 
 	var dummy int
-	err := u.DB.QueryRowContext(ctxTimeout, "select 1 from users where email = $1 limit 1", email).Scan(&dummy)
+	err := u.DB.QueryRowContext(ctxTimeout, "select 1 from users where lower(email) = lower($1) limit 1", email).Scan(&dummy)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
 	}
@@ -79,16 +96,120 @@ func (u *Repository) GetById(ctx context.Context, id int64) (*model.User, error)
 	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 	user := &model.User{}
-	err := u.DB.QueryRowContext(ctxTimeout, "SELECT id, email, name, password, created_at, role_id FROM users WHERE id = $1", id).
-		Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.RoleID)
+	err := u.DB.QueryRowContext(ctxTimeout, selectUserQuery+" WHERE u.id = $1 AND u.deleted_at IS NULL", id).
+		Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.RoleID, &user.Role.Code, &user.Role.Name, &user.Blocked, &user.EmailVerifiedAt, &user.MFAEnabledAt, &user.Version, &user.PendingEmail)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperror.UserNotFoundErr
 		}
 
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 
+	user.Role.ID = user.RoleID
 	return user, nil
 }
+
+func (u *Repository) MarkEmailVerified(ctx context.Context, id int64) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	// Повторное подтверждение не сдвигает дату
+	_, err := u.DB.ExecContext(ctxTimeout, "UPDATE users SET email_verified_at = now() WHERE id = $1 AND email_verified_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("update user error: %w", err)
+	}
+
+	return nil
+}
+
+func (u *Repository) UpdatePassword(ctx context.Context, id int64, hashedPassword string) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := u.DB.ExecContext(ctxTimeout, "UPDATE users SET password = $1 WHERE id = $2 AND deleted_at IS NULL", hashedPassword, id)
+	if err != nil {
+		return fmt.Errorf("update user error: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+
+	if affected == 0 {
+		return apperror.UserNotFoundErr
+	}
+
+	return nil
+}
+
+func (u *Repository) UpdateProfile(ctx context.Context, user *model.User, version int64) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	err := u.DB.QueryRowContext(ctxTimeout, `
+		UPDATE users SET name = $1, pending_email = $2, version = version + 1
+		WHERE id = $3 AND version = $4
+		RETURNING version`,
+		user.Username, user.PendingEmail, user.ID, version).
+		Scan(&user.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperror.VersionConflictErr
+	}
+
+	if uniqueErr := uniqueViolationErr(err); uniqueErr != nil {
+		return uniqueErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("update user error: %w", err)
+	}
+
+	return nil
+}
+
+func (u *Repository) ConfirmEmailChange(ctx context.Context, id int64) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := u.DB.ExecContext(ctxTimeout, `
+		UPDATE users SET email = pending_email, pending_email = NULL, email_verified_at = now(), version = version + 1
+		WHERE id = $1 AND pending_email IS NOT NULL AND deleted_at IS NULL`, id)
+	if uniqueErr := uniqueViolationErr(err); uniqueErr != nil {
+		return uniqueErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("update user error: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+
+	if affected == 0 {
+		return apperror.UserNotFoundErr
+	}
+
+	return nil
+}
+
+// uniqueViolationErr Ошибка приложения по нарушенному ограничению уникальности, nil - если это другая ошибка
+func uniqueViolationErr(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolationCode {
+		return nil
+	}
+
+	switch pqErr.Constraint {
+	case usernameConstraint:
+		return apperror.ExistsUsernameErr
+	case emailConstraint:
+		return apperror.ExistsEmailErr
+	default:
+		return nil
+	}
+}
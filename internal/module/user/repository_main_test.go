@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -66,6 +67,17 @@ func TestExists(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func TestGetIgnoresEmailCase(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	user, repo, err := createTestUser(t, ctx, 4)
+	require.NoError(t, err)
+
+	u, err := repo.Get(ctx, strings.ToUpper(user.Email))
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, u.Email)
+}
+
 func TestGetById(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
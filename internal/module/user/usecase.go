@@ -2,35 +2,100 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	domaudit "github.com/Elaman1/full-project-mock/internal/domain/audit"
 	domcache "github.com/Elaman1/full-project-mock/internal/domain/cache"
 	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/mailer"
 	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/internal/domain/repository"
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
+	"github.com/Elaman1/full-project-mock/internal/service"
 	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/useragent"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 )
 
+const (
+	defaultEmailVerificationTTL = 24 * time.Hour
+	defaultPasswordResetTTL     = 30 * time.Minute
+	defaultMFAChallengeTTL      = 5 * time.Minute
+	// backgroundTimeout Сколько фоновая задача может ждать БД, Redis и почту после ответа клиенту
+	backgroundTimeout = 30 * time.Second
+)
+
 type Usecase struct {
-	Rep          repository.UserRepository
-	TokenService usecase.TokenService
-	SessionCache domcache.SessionCache
-	RefreshTtl   time.Duration
+	Rep            repository.UserRepository
+	TokenService   usecase.TokenService
+	SessionCache   domcache.SessionCache
+	AccessDenylist domcache.AccessTokenDenylist
+	EmailTokens    domcache.OneTimeTokenCache
+	// EmailChangeTokens Ссылки на новый адрес. Отдельно от EmailTokens, чтобы ссылка на прежний адрес не подтверждала новый
+	EmailChangeTokens domcache.OneTimeTokenCache
+	ResetTokens       domcache.OneTimeTokenCache
+	Mailer            mailer.Mailer
+	MFA               usecase.MFAUsecase
+	MFAChallenges     domcache.OneTimeTokenCache
+	LoginThrottle     domcache.LoginThrottle
+	PasswordPolicy    validator.PasswordPolicy
+	Reauth            *service.Reauthenticator // текущий пароль при смене пароля и email, счетчик тот же, что у входа
+	Audit             domaudit.AuditLogger
+	Auth              config.Auth
+	RefreshTtl        time.Duration
+	// MFAChallengeTTL Сколько живет mfa_token между паролем и вводом кода
+	MFAChallengeTTL time.Duration
+	// background Задачи, запущенные после ответа клиенту
+	background sync.WaitGroup
 }
 
-func NewUserUsecase(userRepository repository.UserRepository, tokenService usecase.TokenService, sessionCache domcache.SessionCache) usecase.UserUsecase {
+func NewUserUsecase(userRepository repository.UserRepository, tokenService usecase.TokenService, sessionCache domcache.SessionCache, accessDenylist domcache.AccessTokenDenylist, emailTokens, emailChangeTokens, resetTokens domcache.OneTimeTokenCache, mail mailer.Mailer, mfa usecase.MFAUsecase, mfaChallenges domcache.OneTimeTokenCache, loginThrottle domcache.LoginThrottle, passwordPolicy validator.PasswordPolicy, auditLogger domaudit.AuditLogger, authCfg config.Auth, mfaCfg config.MFA) usecase.UserUsecase {
+	if authCfg.EmailVerificationTTL == 0 {
+		authCfg.EmailVerificationTTL = defaultEmailVerificationTTL
+	}
+
+	if authCfg.PasswordResetTTL == 0 {
+		authCfg.PasswordResetTTL = defaultPasswordResetTTL
+	}
+
+	if mfaCfg.ChallengeTTL == 0 {
+		mfaCfg.ChallengeTTL = defaultMFAChallengeTTL
+	}
+
 	return &Usecase{
-		Rep:          userRepository,
-		TokenService: tokenService,
-		SessionCache: sessionCache,
-		RefreshTtl:   7 * 24 * time.Hour, // 7 дней
+		Rep:               userRepository,
+		TokenService:      tokenService,
+		SessionCache:      sessionCache,
+		AccessDenylist:    accessDenylist,
+		EmailTokens:       emailTokens,
+		EmailChangeTokens: emailChangeTokens,
+		ResetTokens:       resetTokens,
+		Mailer:            mail,
+		MFA:               mfa,
+		MFAChallenges:     mfaChallenges,
+		LoginThrottle:     loginThrottle,
+		PasswordPolicy:    passwordPolicy,
+		Reauth:            service.NewReauthenticator(loginThrottle, passwordPolicy),
+		Audit:             auditLogger,
+		Auth:              authCfg,
+		RefreshTtl:        7 * 24 * time.Hour, // 7 дней
+		MFAChallengeTTL:   mfaCfg.ChallengeTTL,
 	}
 }
 
 func (u *Usecase) Register(ctx context.Context, email, username, password string) (int64, error) {
+	if err := u.PasswordPolicy.Validate(password, username, email); err != nil {
+		return 0, err
+	}
+
 	exists, err := u.Rep.Exists(ctx, email)
 	if err != nil {
 		return 0, err
@@ -57,46 +122,507 @@ func (u *Usecase) Register(ctx context.Context, email, username, password string
 		return 0, fmt.Errorf("произошла ошибка при регистрации")
 	}
 
-	// пока что поставим 0, если потом надо, будем возвращать нормально
-	return 0, nil
+	// Пользователь уже создан, письмо можно запросить повторно через resend
+	if err = u.sendVerificationEmail(ctx, user); err != nil {
+		service.LoggerFromContext(ctx).Error("failed to send verification email", "error", err, "user_id", user.ID)
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: user.ID, Action: constants.AuditRegister})
+	return user.ID, nil
+}
+
+func (u *Usecase) VerifyEmail(ctx context.Context, token string) error {
+	hashedToken := hasher.Sha256Hex(token)
+	userID, err := u.EmailTokens.Consume(ctx, hashedToken)
+	if errors.Is(err, domcache.ErrNotFound) {
+		return u.confirmEmailChange(ctx, hashedToken)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return u.Rep.MarkEmailVerified(ctx, userID)
+}
+
+// confirmEmailChange Ссылка из письма на новый адрес: только теперь он становится email для входа
+func (u *Usecase) confirmEmailChange(ctx context.Context, hashedToken string) error {
+	userID, err := u.EmailChangeTokens.Consume(ctx, hashedToken)
+	if errors.Is(err, domcache.ErrNotFound) {
+		return apperror.InvalidTokenErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = u.Rep.ConfirmEmailChange(ctx, userID)
+	if errors.Is(err, apperror.UserNotFoundErr) {
+		// Смену уже отменили или аккаунт удален
+		return apperror.InvalidTokenErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditEmailChange})
+	return nil
+}
+
+// ResendVerification Как и ForgotPassword, выполняется в фоне
+func (u *Usecase) ResendVerification(ctx context.Context, email string) {
+	u.runInBackground(ctx, "verification mail", func(ctx context.Context) error {
+		user, err := u.Rep.Get(ctx, email)
+		if errors.Is(err, apperror.UserNotFoundErr) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+
+		return u.sendVerificationEmail(ctx, user)
+	})
+}
+
+// sendVerificationEmail Новый токен заменяет ранее отправленный
+func (u *Usecase) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, err := hasher.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	err = u.EmailTokens.Save(ctx, user.ID, hasher.Sha256Hex(token), u.Auth.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return u.Mailer.Send(ctx, model.MailMessage{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body:    fmt.Sprintf("Для подтверждения email перейдите по ссылке:\n%s\n\nСсылка действует %s.", tokenLink(u.Auth.VerifyEmailURL, token), u.Auth.EmailVerificationTTL),
+	})
 }
 
-func (u *Usecase) Login(ctx context.Context, email, password, clientIP, ua string) (string, string, int, error) {
+// ForgotPassword Поиск пользователя, токен и письмо выполняются в фоне: ни код ответа, ни его время
+// не зависят от того, есть ли аккаунт
+func (u *Usecase) ForgotPassword(ctx context.Context, email string) {
+	u.runInBackground(ctx, "password reset mail", func(ctx context.Context) error {
+		return u.sendPasswordResetEmail(ctx, email)
+	})
+}
+
+func (u *Usecase) sendPasswordResetEmail(ctx context.Context, email string) error {
 	user, err := u.Rep.Get(ctx, email)
+	if errors.Is(err, apperror.UserNotFoundErr) {
+		return nil
+	}
+
 	if err != nil {
-		return "", "", http.StatusUnauthorized, err
+		return err
 	}
 
-	err = hasher.Verify(user.Password, password)
+	token, err := hasher.GenerateToken()
 	if err != nil {
-		return "", "", http.StatusUnauthorized, err
+		return err
+	}
+
+	// Новый запрос гасит ранее отправленную ссылку
+	err = u.ResetTokens.Save(ctx, user.ID, hasher.Sha256Hex(token), u.Auth.PasswordResetTTL)
+	if err != nil {
+		return err
 	}
 
-	return u.generateAccessAndRefreshToken(ctx, clientIP, ua, user)
+	return u.Mailer.Send(ctx, model.MailMessage{
+		To:      user.Email,
+		Subject: "Восстановление пароля",
+		Body: fmt.Sprintf("Для смены пароля перейдите по ссылке:\n%s\n\nСсылка действует %s. Если вы не запрашивали смену пароля, просто проигнорируйте письмо.",
+			tokenLink(u.Auth.PasswordResetURL, token), u.Auth.PasswordResetTTL),
+	})
 }
 
-func (u *Usecase) generateAccessAndRefreshToken(ctx context.Context, clientIP, ua string, user *model.User) (string, string, int, error) {
-	accessToken, err := u.TokenService.GenerateAccessToken(user)
+func (u *Usecase) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		u.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runInBackground Задача переживает отмену контекста запроса, но не дольше backgroundTimeout. Ошибка только логируется
+func (u *Usecase) runInBackground(ctx context.Context, name string, job func(ctx context.Context) error) {
+	u.background.Add(1)
+	go func() {
+		defer u.background.Done()
+
+		jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backgroundTimeout)
+		defer cancel()
+
+		if err := job(jobCtx); err != nil {
+			service.LoggerFromContext(jobCtx).Error("background task failed", "task", name, "error", err)
+		}
+	}()
+}
+
+func (u *Usecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	// Токен гасится только после проверки пароля, чтобы отклоненный пароль можно было исправить по той же ссылке
+	hashedToken := hasher.Sha256Hex(token)
+	userID, err := u.ResetTokens.Peek(ctx, hashedToken)
+	if errors.Is(err, domcache.ErrNotFound) {
+		return apperror.InvalidTokenErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	user, err := u.Rep.GetById(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = u.PasswordPolicy.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	pwd, err := hasher.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("произошла ошибка при хешировании пароля")
+	}
+
+	// Параллельный запрос мог успеть воспользоваться тем же токеном
+	consumedID, err := u.ResetTokens.Consume(ctx, hashedToken)
+	if errors.Is(err, domcache.ErrNotFound) || (err == nil && consumedID != userID) {
+		return apperror.InvalidTokenErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = u.Rep.UpdatePassword(ctx, userID, pwd); err != nil {
+		return err
+	}
+
+	// Пароль мог быть сброшен из-за утечки, поэтому выходим со всех устройств
+	if err = u.SessionCache.DeleteAllUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	if err = u.AccessDenylist.RevokeAllUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	service.LoggerFromContext(ctx).Info("password reset", "event", "password_reset", "user_id", userID)
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditPasswordReset})
+	return nil
+}
+
+func (u *Usecase) ChangePassword(ctx context.Context, userID int64, sessionID, currentPassword, newPassword, clientIP string) (string, error) {
+	user, err := u.Rep.GetById(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = u.Reauth.Verify(ctx, user, currentPassword, clientIP, nil); err != nil {
+		return "", err
+	}
+
+	if err = u.PasswordPolicy.Validate(newPassword, user.Username, user.Email); err != nil {
+		return "", err
+	}
+
+	pwd, err := hasher.HashPassword(newPassword)
+	if err != nil {
+		return "", fmt.Errorf("произошла ошибка при хешировании пароля")
+	}
+
+	if err = u.Rep.UpdatePassword(ctx, userID, pwd); err != nil {
+		return "", err
+	}
+
+	keepTokenID, err := u.currentTokenID(ctx, userID, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = u.SessionCache.DeleteOtherUserSessions(ctx, userID, keepTokenID); err != nil {
+		return "", err
+	}
+
+	// Отзываем все access-токены, включая текущий, и сразу выдаем текущей сессии новый.
+	// Refresh токен текущей сессии остается прежним
+	if err = u.AccessDenylist.RevokeAllUserTokens(ctx, userID); err != nil {
+		return "", err
+	}
+
+	accessToken, err := u.TokenService.GenerateAccessToken(user, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	service.LoggerFromContext(ctx).Info("password changed", "event", "password_changed", "user_id", userID)
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditPasswordChange})
+	return accessToken, nil
+}
+
+// tokenLink Ссылка для письма. Если она не настроена, в письме остается только токен
+func tokenLink(baseURL, token string) string {
+	if baseURL == "" {
+		return token
+	}
+
+	link, err := url.Parse(baseURL)
+	if err != nil {
+		return token
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+func (u *Usecase) Login(ctx context.Context, email, password, clientIP, ua string) (model.LoginResult, int, error) {
+	// Проверяем до хеширования пароля, иначе перебор нагружает CPU
+	if httpStatus, err := u.checkLoginThrottle(ctx, email, clientIP); err != nil {
+		u.recordLoginFailure(ctx, 0, email, clientIP, ua, loginFailureLockedOut)
+		return model.LoginResult{}, httpStatus, err
+	}
+
+	user, err := u.Rep.Get(ctx, email)
+	if errors.Is(err, apperror.UserNotFoundErr) {
+		// Несуществующий email считаем так же, чтобы по блокировке нельзя было понять, есть ли пользователь
+		u.registerLoginFailure(ctx, 0, email, clientIP, ua, loginFailureUserNotFound)
+		return model.LoginResult{}, http.StatusUnauthorized, err
+	}
+
+	if err != nil {
+		return model.LoginResult{}, http.StatusUnauthorized, err
+	}
+
+	err = u.verifyPassword(user.Password, password)
+	if err != nil {
+		u.registerLoginFailure(ctx, user.ID, email, clientIP, ua, loginFailureWrongPassword)
+		return model.LoginResult{}, http.StatusUnauthorized, err
+	}
+
+	if err = u.LoginThrottle.Reset(ctx, email); err != nil {
+		service.LoggerFromContext(ctx).Error("failed to reset login throttle", "error", err, "user_id", user.ID)
+	}
+
+	// Проверяем после пароля, чтобы по ответу нельзя было узнать о блокировке без пароля
+	if user.Blocked {
+		u.recordLoginFailure(ctx, user.ID, email, clientIP, ua, loginFailureBlocked)
+		return model.LoginResult{}, http.StatusForbidden, apperror.UserBlockedErr
+	}
+
+	if u.Auth.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		u.recordLoginFailure(ctx, user.ID, email, clientIP, ua, loginFailureEmailNotVerified)
+		return model.LoginResult{}, http.StatusForbidden, apperror.EmailNotVerifiedErr
+	}
+
+	// Пароль верный, но токены выдаем только после второго шага
+	if user.MFAEnabledAt != nil {
+		mfaToken, challengeErr := u.newMFAChallenge(ctx, user.ID)
+		if challengeErr != nil {
+			return model.LoginResult{}, http.StatusInternalServerError, challengeErr
+		}
+
+		return model.LoginResult{MFARequired: true, MFAToken: mfaToken}, http.StatusOK, nil
+	}
+
+	accessToken, refreshToken, httpStatus, err := u.generateAccessAndRefreshToken(ctx, clientIP, ua, user)
+	if err != nil {
+		return model.LoginResult{}, httpStatus, err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: user.ID, Action: constants.AuditLoginSuccess, IP: clientIP, UserAgent: ua})
+	return model.LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, httpStatus, nil
+}
+
+// LoginMFA Второй шаг логина. mfa_token одноразовый: после неверного кода нужно снова войти по паролю
+func (u *Usecase) LoginMFA(ctx context.Context, mfaToken, code, clientIP, ua string) (string, string, int, error) {
+	userID, err := u.MFAChallenges.Consume(ctx, hasher.Sha256Hex(mfaToken))
+	if errors.Is(err, domcache.ErrNotFound) {
+		return "", "", http.StatusUnauthorized, apperror.InvalidTokenErr
+	}
+
 	if err != nil {
 		return "", "", http.StatusInternalServerError, err
 	}
 
-	refreshTokenId, plainToken, err := u.TokenService.GenerateRefreshToken()
+	user, err := u.Rep.GetById(ctx, userID)
+	if err != nil {
+		return "", "", http.StatusUnauthorized, err
+	}
+
+	// Могли заблокировать, пока пользователь вводил код
+	if user.Blocked {
+		u.recordLoginFailure(ctx, user.ID, user.Email, clientIP, ua, loginFailureBlocked)
+		return "", "", http.StatusForbidden, apperror.UserBlockedErr
+	}
+
+	// Между вводом пароля и кода email могли заблокировать за перебор с другого устройства
+	if httpStatus, throttleErr := u.checkLoginThrottle(ctx, user.Email, clientIP); throttleErr != nil {
+		u.recordLoginFailure(ctx, user.ID, user.Email, clientIP, ua, loginFailureLockedOut)
+		return "", "", httpStatus, throttleErr
+	}
+
+	err = u.MFA.VerifyCode(ctx, userID, code)
+	if errors.Is(err, apperror.InvalidMFACodeErr) || errors.Is(err, apperror.MFANotEnabledErr) {
+		service.LoggerFromContext(ctx).Warn("mfa login failed", "event", "mfa_login_failed", "user_id", userID, "ip", clientIP, "user_agent", ua)
+		// Неверный код считается как неверный пароль
+		u.registerLoginFailure(ctx, user.ID, user.Email, clientIP, ua, loginFailureInvalidMFACode)
+		return "", "", http.StatusUnauthorized, apperror.InvalidMFACodeErr
+	}
+
 	if err != nil {
 		return "", "", http.StatusInternalServerError, err
 	}
 
-	hashedPlainToken := hashRefreshToken(plainToken)
-	newSess := &domcache.RefreshSession{
+	accessToken, refreshToken, httpStatus, err := u.generateAccessAndRefreshToken(ctx, clientIP, ua, user)
+	if err != nil {
+		return "", "", httpStatus, err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{
 		UserID:    user.ID,
-		TokenID:   refreshTokenId,
-		TokenHash: hashedPlainToken,
-		ExpiresAt: time.Now().Add(u.RefreshTtl),
+		Action:    constants.AuditLoginSuccess,
 		IP:        clientIP,
 		UserAgent: ua,
+		Metadata:  map[string]any{"mfa": true},
+	})
+	return accessToken, refreshToken, httpStatus, nil
+}
+
+// LoginWithIdentity Пароль проверил провайдер, поэтому счетчик перебора не участвует.
+// MFA по-прежнему требуется: аккаунт у провайдера - только первый фактор
+func (u *Usecase) LoginWithIdentity(ctx context.Context, userID int64, provider, clientIP, ua string) (model.LoginResult, int, error) {
+	user, err := u.Rep.GetById(ctx, userID)
+	if err != nil {
+		return model.LoginResult{}, http.StatusUnauthorized, err
+	}
+
+	if user.Blocked {
+		u.recordLoginFailure(ctx, user.ID, user.Email, clientIP, ua, loginFailureBlocked)
+		return model.LoginResult{}, http.StatusForbidden, apperror.UserBlockedErr
+	}
+
+	if u.Auth.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		u.recordLoginFailure(ctx, user.ID, user.Email, clientIP, ua, loginFailureEmailNotVerified)
+		return model.LoginResult{}, http.StatusForbidden, apperror.EmailNotVerifiedErr
 	}
 
-	err = u.SessionCache.SetRefreshTokenId(ctx, hashedPlainToken, refreshTokenId, u.RefreshTtl)
+	if user.MFAEnabledAt != nil {
+		mfaToken, challengeErr := u.newMFAChallenge(ctx, user.ID)
+		if challengeErr != nil {
+			return model.LoginResult{}, http.StatusInternalServerError, challengeErr
+		}
+
+		return model.LoginResult{MFARequired: true, MFAToken: mfaToken}, http.StatusOK, nil
+	}
+
+	accessToken, refreshToken, httpStatus, err := u.generateAccessAndRefreshToken(ctx, clientIP, ua, user)
+	if err != nil {
+		return model.LoginResult{}, httpStatus, err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{
+		UserID:    user.ID,
+		Action:    constants.AuditLoginSuccess,
+		IP:        clientIP,
+		UserAgent: ua,
+		Metadata:  map[string]any{"provider": provider},
+	})
+	return model.LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, httpStatus, nil
+}
+
+// checkLoginThrottle Ошибка содержит RetryAfter, если email или IP заблокированы
+func (u *Usecase) checkLoginThrottle(ctx context.Context, email, clientIP string) (int, error) {
+	retryAfter, err := u.LoginThrottle.Check(ctx, email, clientIP)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if retryAfter > 0 {
+		return http.StatusTooManyRequests, &apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: retryAfter}
+	}
+
+	return http.StatusOK, nil
+}
+
+// registerLoginFailure Ошибка счетчика не должна менять ответ на неверный пароль, поэтому только логируем.
+// userID 0, если пользователь с таким email не найден
+func (u *Usecase) registerLoginFailure(ctx context.Context, userID int64, email, clientIP, ua, reason string) {
+	lgr := service.LoggerFromContext(ctx)
+	u.recordLoginFailure(ctx, userID, email, clientIP, ua, reason)
+
+	lockout, err := u.LoginThrottle.RegisterFailure(ctx, email, clientIP)
+	if err != nil {
+		lgr.Error("failed to register login failure", "error", err)
+		return
+	}
+
+	if lockout > 0 {
+		lgr.Warn("security event: login locked out",
+			"event", "login_lockout",
+			"email", email,
+			"ip", clientIP,
+			"user_agent", ua,
+			"lockout", lockout,
+		)
+	}
+}
+
+// recordLoginFailure Email пишем только для неизвестного пользователя, иначе его видно по user_id
+func (u *Usecase) recordLoginFailure(ctx context.Context, userID int64, email, clientIP, ua, reason string) {
+	metadata := map[string]any{"reason": reason}
+	if userID == 0 {
+		metadata["email"] = email
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{
+		UserID:    userID,
+		Action:    constants.AuditLoginFailure,
+		IP:        clientIP,
+		UserAgent: ua,
+		Metadata:  metadata,
+	})
+}
+
+func (u *Usecase) newMFAChallenge(ctx context.Context, userID int64) (string, error) {
+	token, err := hasher.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err = u.MFAChallenges.Save(ctx, userID, hasher.Sha256Hex(token), u.MFAChallengeTTL); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (u *Usecase) generateAccessAndRefreshToken(ctx context.Context, clientIP, ua string, user *model.User) (string, string, int, error) {
+	accessToken, plainToken, newSess, err := u.newTokenPair(clientIP, ua, user, "")
+	if err != nil {
+		return "", "", http.StatusInternalServerError, err
+	}
+
+	err = u.SessionCache.SetRefreshTokenId(ctx, newSess.TokenHash, newSess.TokenID, u.RefreshTtl)
 	if err != nil {
 		return "", "", http.StatusInternalServerError, err
 	}
@@ -109,6 +635,101 @@ func (u *Usecase) generateAccessAndRefreshToken(ctx context.Context, clientIP, u
 	return accessToken, plainToken, http.StatusOK, nil
 }
 
+// rotateRefreshToken Выдает новую пару токенов и в той же операции гасит предъявленный refresh токен
+func (u *Usecase) rotateRefreshToken(ctx context.Context, clientIP, ua string, user *model.User, oldSess *domcache.RefreshSession) (string, string, int, error) {
+	familyID := oldSess.FamilyID
+	if familyID == "" {
+		// Сессии, созданные до появления семейств
+		familyID = oldSess.TokenID
+	}
+
+	accessToken, plainToken, newSess, err := u.newTokenPair(clientIP, ua, user, familyID)
+	if err != nil {
+		return "", "", http.StatusInternalServerError, err
+	}
+
+	// Для пользователя это та же сессия, поэтому время логина сохраняем
+	if !oldSess.CreatedAt.IsZero() {
+		newSess.CreatedAt = oldSess.CreatedAt
+	}
+
+	err = u.SessionCache.RotateSession(ctx, oldSess, newSess, u.RefreshTtl)
+	if errors.Is(err, domcache.ErrRefreshTokenConsumed) {
+		// Токен уже успели обменять, значит его предъявили повторно
+		u.revokeRefreshFamily(ctx, oldSess.UserID, familyID, clientIP, ua)
+		return "", "", http.StatusUnauthorized, apperror.RefreshTokenReusedErr
+	}
+
+	if err != nil {
+		return "", "", http.StatusInternalServerError, err
+	}
+
+	return accessToken, plainToken, http.StatusOK, nil
+}
+
+// newTokenPair Пустой familyID означает новый логин, тогда семейство начинается с этого токена
+func (u *Usecase) newTokenPair(clientIP, ua string, user *model.User, familyID string) (string, string, *domcache.RefreshSession, error) {
+	refreshTokenId, plainToken, err := u.TokenService.GenerateRefreshToken()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if familyID == "" {
+		familyID = refreshTokenId
+	}
+
+	// sid - семейство, чтобы завершение сессии отзывало access-токены всех ротаций
+	accessToken, err := u.TokenService.GenerateAccessToken(user, familyID)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	now := time.Now()
+	newSess := &domcache.RefreshSession{
+		UserID:     user.ID,
+		TokenID:    refreshTokenId,
+		TokenHash:  hashRefreshToken(plainToken),
+		FamilyID:   familyID,
+		ExpiresAt:  now.Add(u.RefreshTtl),
+		IP:         clientIP,
+		UserAgent:  ua,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+
+	return accessToken, plainToken, newSess, nil
+}
+
+// detectRefreshReuse Проверяет, не предъявлен ли уже ротированный токен. Если да - отзываем все семейство
+func (u *Usecase) detectRefreshReuse(ctx context.Context, hashedRefreshToken, clientIP, ua string) error {
+	rotated, err := u.SessionCache.GetRotatedRefreshToken(ctx, hashedRefreshToken)
+	if errors.Is(err, domcache.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	u.revokeRefreshFamily(ctx, rotated.UserID, rotated.FamilyID, clientIP, ua)
+	return apperror.RefreshTokenReusedErr
+}
+
+func (u *Usecase) revokeRefreshFamily(ctx context.Context, userID int64, familyID, clientIP, ua string) {
+	lgr := service.LoggerFromContext(ctx)
+	lgr.Warn("security event: refresh token reuse detected",
+		"event", "refresh_token_reuse",
+		"user_id", userID,
+		"family_id", familyID,
+		"ip", clientIP,
+		"user_agent", ua,
+	)
+
+	if err := u.SessionCache.RevokeFamily(ctx, userID, familyID); err != nil {
+		lgr.Error("failed to revoke refresh token family", "error", err, "user_id", userID, "family_id", familyID)
+	}
+}
+
 func (u *Usecase) Refresh(ctx context.Context, accessToken, refreshToken, clientIP, ua string) (string, string, int, error) {
 	mapClaims, err := u.TokenService.ParseToken(accessToken)
 	if err != nil {
@@ -125,7 +746,18 @@ func (u *Usecase) Refresh(ctx context.Context, accessToken, refreshToken, client
 		return "", "", http.StatusBadRequest, err
 	}
 
-	refreshTokenId, err := u.SessionCache.GetRefreshTokenId(ctx, hashRefreshToken(refreshToken))
+	if user.Blocked {
+		return "", "", http.StatusForbidden, apperror.UserBlockedErr
+	}
+
+	hashedRefreshToken := hashRefreshToken(refreshToken)
+	refreshTokenId, err := u.SessionCache.GetRefreshTokenId(ctx, hashedRefreshToken)
+	if errors.Is(err, domcache.ErrNotFound) {
+		if reuseErr := u.detectRefreshReuse(ctx, hashedRefreshToken, clientIP, ua); reuseErr != nil {
+			return "", "", http.StatusUnauthorized, reuseErr
+		}
+	}
+
 	if err != nil {
 		return "", "", http.StatusBadRequest, err
 	}
@@ -139,22 +771,112 @@ func (u *Usecase) Refresh(ctx context.Context, accessToken, refreshToken, client
 		return "", "", http.StatusUnauthorized, fmt.Errorf("время истек заново авторизуйтесь")
 	}
 
-	if refreshSession.IP != clientIP {
+	if hashedRefreshToken != refreshSession.TokenHash {
 		return "", "", http.StatusUnauthorized, fmt.Errorf("авторизуйтесь еще раз")
 	}
 
-	if refreshSession.UserAgent != ua {
-		return "", "", http.StatusUnauthorized, fmt.Errorf("авторизуйтесь еще раз")
+	// Access и refresh токены должны принадлежать одному пользователю, иначе чужая сессия уйдет в семейство user
+	if mapClaims.Subject != strconv.FormatInt(refreshSession.UserID, 10) {
+		return "", "", http.StatusUnauthorized, apperror.InvalidTokenErr
 	}
 
-	if hashRefreshToken(refreshToken) != refreshSession.TokenHash {
-		return "", "", http.StatusUnauthorized, fmt.Errorf("авторизуйтесь еще раз")
+	if httpStatus, bindingErr := u.checkSessionBinding(ctx, refreshSession, clientIP, ua, true); bindingErr != nil {
+		return "", "", httpStatus, bindingErr
 	}
 
-	return u.generateAccessAndRefreshToken(ctx, clientIP, ua, user)
+	newAccessToken, newRefreshToken, httpStatus, err := u.rotateRefreshToken(ctx, clientIP, ua, user, refreshSession)
+	if err != nil {
+		return "", "", httpStatus, err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{
+		UserID:    user.ID,
+		Action:    constants.AuditRefresh,
+		IP:        clientIP,
+		UserAgent: ua,
+		Metadata:  map[string]any{"session_id": sessionID(refreshSession)},
+	})
+	return newAccessToken, newRefreshToken, httpStatus, nil
 }
 
-func (u *Usecase) Logout(ctx context.Context, refreshToken, clientIP, ua string) error {
+// RefreshStepUp Подтверждение сессии паролем (и кодом MFA, если он включен) после смены отпечатка.
+// Неверный пароль или код считается как неудачный вход
+func (u *Usecase) RefreshStepUp(ctx context.Context, refreshToken, password, code, clientIP, ua string) (string, string, int, error) {
+	if !u.Auth.SessionStepUp {
+		return "", "", http.StatusNotFound, apperror.StepUpNotEnabledErr
+	}
+
+	hashedRefreshToken := hashRefreshToken(refreshToken)
+	refreshTokenId, err := u.SessionCache.GetRefreshTokenId(ctx, hashedRefreshToken)
+	if errors.Is(err, domcache.ErrNotFound) {
+		return "", "", http.StatusUnauthorized, apperror.InvalidTokenErr
+	}
+
+	if err != nil {
+		return "", "", http.StatusInternalServerError, err
+	}
+
+	refreshSession, err := u.SessionCache.GetSession(ctx, refreshTokenId)
+	if err != nil {
+		return "", "", http.StatusUnauthorized, apperror.InvalidTokenErr
+	}
+
+	if refreshSession.ExpiresAt.Before(time.Now()) || refreshSession.TokenHash != hashedRefreshToken {
+		return "", "", http.StatusUnauthorized, apperror.InvalidTokenErr
+	}
+
+	user, err := u.Rep.GetById(ctx, refreshSession.UserID)
+	if err != nil {
+		return "", "", http.StatusUnauthorized, err
+	}
+
+	if user.Blocked {
+		return "", "", http.StatusForbidden, apperror.UserBlockedErr
+	}
+
+	if httpStatus, throttleErr := u.checkLoginThrottle(ctx, user.Email, clientIP); throttleErr != nil {
+		return "", "", httpStatus, throttleErr
+	}
+
+	if err = u.verifyPassword(user.Password, password); err != nil {
+		u.registerLoginFailure(ctx, user.ID, user.Email, clientIP, ua, loginFailureWrongPassword)
+		return "", "", http.StatusUnauthorized, apperror.WrongPasswordErr
+	}
+
+	if user.MFAEnabledAt != nil {
+		err = u.MFA.VerifyCode(ctx, user.ID, code)
+		if errors.Is(err, apperror.InvalidMFACodeErr) || errors.Is(err, apperror.MFANotEnabledErr) {
+			u.registerLoginFailure(ctx, user.ID, user.Email, clientIP, ua, loginFailureInvalidMFACode)
+			return "", "", http.StatusUnauthorized, apperror.InvalidMFACodeErr
+		}
+
+		if err != nil {
+			return "", "", http.StatusInternalServerError, err
+		}
+	}
+
+	if err = u.LoginThrottle.Reset(ctx, user.Email); err != nil {
+		service.LoggerFromContext(ctx).Error("failed to reset login throttle", "error", err, "user_id", user.ID)
+	}
+
+	service.LoggerFromContext(ctx).Info("session step-up completed",
+		"event", "session_step_up",
+		"user_id", user.ID,
+		"session_id", sessionID(refreshSession),
+		"ip", clientIP,
+		"user_agent", ua,
+	)
+
+	// Новая сессия того же семейства уже с новыми IP и User-Agent
+	return u.rotateRefreshToken(ctx, clientIP, ua, user, refreshSession)
+}
+
+func (u *Usecase) Logout(ctx context.Context, accessToken, refreshToken, clientIP, ua string) error {
+	accessClaims, err := u.TokenService.ParseToken(accessToken)
+	if err != nil {
+		return err
+	}
+
 	refreshTokenId, err := u.SessionCache.GetRefreshTokenId(ctx, hashRefreshToken(refreshToken))
 	if err != nil {
 		return err
@@ -169,11 +891,12 @@ func (u *Usecase) Logout(ctx context.Context, refreshToken, clientIP, ua string)
 		return fmt.Errorf("невозможно выполнить операцию")
 	}
 
-	if refreshSession.IP != clientIP {
+	if _, err = u.checkSessionBinding(ctx, refreshSession, clientIP, ua, false); err != nil {
 		return fmt.Errorf("невозможно выполнить операцию")
 	}
 
-	if refreshSession.UserAgent != ua {
+	// Access и refresh токены должны принадлежать одному пользователю
+	if accessClaims.Subject != strconv.FormatInt(refreshSession.UserID, 10) {
 		return fmt.Errorf("невозможно выполнить операцию")
 	}
 
@@ -187,6 +910,17 @@ func (u *Usecase) Logout(ctx context.Context, refreshToken, clientIP, ua string)
 		return err
 	}
 
+	if err = u.AccessDenylist.RevokeToken(ctx, accessClaims.ID, accessClaims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{
+		UserID:    refreshSession.UserID,
+		Action:    constants.AuditLogout,
+		IP:        clientIP,
+		UserAgent: ua,
+		Metadata:  map[string]any{"session_id": sessionID(refreshSession)},
+	})
 	return nil
 }
 
@@ -205,22 +939,224 @@ func (u *Usecase) LogoutAllDevices(ctx context.Context, refreshToken, clientIP,
 		return fmt.Errorf("невозможно выполнить операцию")
 	}
 
-	if refreshSession.IP != clientIP {
+	if _, err = u.checkSessionBinding(ctx, refreshSession, clientIP, ua, false); err != nil {
 		return fmt.Errorf("невозможно выполнить операцию")
 	}
 
-	if refreshSession.UserAgent != ua {
-		return fmt.Errorf("невозможно выполнить операцию")
+	err = u.SessionCache.DeleteAllUserSessions(ctx, refreshSession.UserID)
+	if err != nil {
+		return err
 	}
 
-	err = u.SessionCache.DeleteAllUserSessions(ctx, refreshSession.UserID)
+	// Все выданные access-токены перестают работать сразу, а не по истечении accessTTL
+	if err = u.AccessDenylist.RevokeAllUserTokens(ctx, refreshSession.UserID); err != nil {
+		return err
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: refreshSession.UserID, Action: constants.AuditLogoutAll, IP: clientIP, UserAgent: ua})
+	return nil
+}
+
+func (u *Usecase) ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]model.Session, error) {
+	refreshSessions, err := u.SessionCache.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]model.Session, 0, len(refreshSessions))
+	for _, s := range refreshSessions {
+		sessions = append(sessions, model.Session{
+			ID:         sessionID(s),
+			Current:    sessionID(s) == currentSessionID,
+			IP:         s.IP,
+			Device:     useragent.Parse(s.UserAgent),
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+
+	// Сначала недавно использованные
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (u *Usecase) RevokeSession(ctx context.Context, userID int64, id string) error {
+	refreshSessions, err := u.SessionCache.ListUserSessions(ctx, userID)
 	if err != nil {
 		return err
 	}
 
+	var target *domcache.RefreshSession
+	for _, s := range refreshSessions {
+		if sessionID(s) == id {
+			target = s
+			break
+		}
+	}
+
+	// Ищем только среди своих сессий, поэтому чужую завершить нельзя
+	if target == nil {
+		return apperror.SessionNotFoundErr
+	}
+
+	if target.FamilyID != "" {
+		err = u.SessionCache.RevokeFamily(ctx, userID, target.FamilyID)
+	} else {
+		err = u.SessionCache.DeleteSession(ctx, userID, target.TokenID)
+		if err == nil {
+			err = u.SessionCache.DeleteRefreshTokenId(ctx, target.TokenHash)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Access-токены этой сессии, выпущенные при любой ротации, перестают работать сразу
+	if err = u.AccessDenylist.RevokeSession(ctx, id); err != nil {
+		return err
+	}
+
+	service.LoggerFromContext(ctx).Info("session revoked", "event", "session_revoked", "user_id", userID, "session_id", id)
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditSessionRevoke, Metadata: map[string]any{"session_id": id}})
 	return nil
 }
 
+func (u *Usecase) GetProfile(ctx context.Context, userID int64) (*model.User, error) {
+	return u.Rep.GetById(ctx, userID)
+}
+
+func (u *Usecase) UpdateProfile(ctx context.Context, userID, version int64, username, email *string, currentPassword, clientIP string) (*model.User, error) {
+	user, err := u.Rep.GetById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Клиент видел устаревший профиль, не даем затереть чужие изменения
+	if user.Version != version {
+		return nil, apperror.VersionConflictErr
+	}
+
+	changed := make([]string, 0, 2)
+	if username != nil && *username != user.Username {
+		user.Username = *username
+		changed = append(changed, "username")
+	}
+
+	emailChanged := email != nil && *email != user.Email
+	if emailChanged {
+		// Email - это вход и восстановление пароля, одного access-токена для его смены мало
+		if currentPassword == "" {
+			return nil, apperror.PasswordRequiredErr
+		}
+
+		if err = u.Reauth.Verify(ctx, user, currentPassword, clientIP, nil); err != nil {
+			return nil, err
+		}
+
+		exists, err := u.Rep.Exists(ctx, *email)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			return nil, apperror.ExistsEmailErr
+		}
+
+		user.PendingEmail = email
+		changed = append(changed, "pending_email")
+	} else if email != nil && user.PendingEmail != nil {
+		// Прежний адрес вместо нового - отмена незавершенной смены
+		user.PendingEmail = nil
+		changed = append(changed, "pending_email")
+	}
+
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	if err = u.Rep.UpdateProfile(ctx, user, version); err != nil {
+		return nil, err
+	}
+
+	if emailChanged {
+		u.sendEmailChangeMails(ctx, user)
+	}
+
+	u.recordAudit(ctx, model.AuditEvent{UserID: userID, Action: constants.AuditProfileUpdate, Metadata: map[string]any{"fields": changed}})
+	return user, nil
+}
+
+// verifyPassword Пароль длиннее политики не хешируется: он заведомо неверный, а argon2 от длинной строки нагружает CPU
+func (u *Usecase) verifyPassword(hashedPassword, password string) error {
+	if u.PasswordPolicy.TooLong(password) {
+		return hasher.ErrMismatchedPassword
+	}
+
+	return hasher.Verify(hashedPassword, password)
+}
+
+// sendEmailChangeMails Ссылка для подтверждения уходит на новый адрес, уведомление - на прежний.
+// Смена уже сохранена, поэтому ошибки отправки только логируются: ссылку можно запросить повторным PATCH
+func (u *Usecase) sendEmailChangeMails(ctx context.Context, user *model.User) {
+	lgr := service.LoggerFromContext(ctx)
+	token, err := hasher.GenerateToken()
+	if err == nil {
+		// Новый токен гасит ссылку, отправленную на предыдущий новый адрес
+		err = u.EmailChangeTokens.Save(ctx, user.ID, hasher.Sha256Hex(token), u.Auth.EmailVerificationTTL)
+	}
+
+	if err == nil {
+		err = u.Mailer.Send(ctx, model.MailMessage{
+			To:      *user.PendingEmail,
+			Subject: "Подтверждение нового email",
+			Body:    fmt.Sprintf("Для смены email перейдите по ссылке:\n%s\n\nСсылка действует %s. До подтверждения вход выполняется по прежнему адресу.", tokenLink(u.Auth.VerifyEmailURL, token), u.Auth.EmailVerificationTTL),
+		})
+	}
+
+	if err != nil {
+		lgr.Error("failed to send email change confirmation", "error", err, "user_id", user.ID)
+	}
+
+	err = u.Mailer.Send(ctx, model.MailMessage{
+		To:      user.Email,
+		Subject: "Запрошена смена email",
+		Body:    fmt.Sprintf("Для вашего аккаунта запрошена смена email на %s. Адрес изменится только после перехода по ссылке из письма на новый адрес.\n\nЕсли это были не вы, смените пароль и завершите все сессии.", *user.PendingEmail),
+	})
+	if err != nil {
+		lgr.Error("failed to send email change notice", "error", err, "user_id", user.ID)
+	}
+}
+
+// currentTokenID TokenID последней ротации сессии id. Если сессии уже нет, пустая строка
+func (u *Usecase) currentTokenID(ctx context.Context, userID int64, id string) (string, error) {
+	refreshSessions, err := u.SessionCache.ListUserSessions(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, s := range refreshSessions {
+		if sessionID(s) == id {
+			return s.TokenID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// sessionID Семейство не меняется при ротации. У сессий, созданных до появления семейств, его нет
+func sessionID(s *domcache.RefreshSession) string {
+	if s.FamilyID != "" {
+		return s.FamilyID
+	}
+
+	return s.TokenID
+}
+
 func hashRefreshToken(token string) string {
 	return hasher.Sha256Hex(token)
 }
@@ -0,0 +1,113 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLogin_Audit(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		password   string
+		setupMocks func(*MockUserRepository, *mocks.MockTokenService, *MockSessionCache, *mocks.MockLoginThrottle)
+		wantEvent  model.AuditEvent
+	}{
+		{
+			name:     "success",
+			password: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				th.On("Reset", mock.Anything, defaultEmail).Return(nil)
+				repo.On("Get", mock.Anything, defaultEmail).Return(user, nil)
+				ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
+				ts.On("GenerateAccessToken", user, refreshTokenId).Return(accessToken, nil)
+				cs.On("SetRefreshTokenId", mock.Anything, mock.Anything, refreshTokenId, mock.Anything).Return(nil)
+				cs.On("SaveSession", mock.Anything, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).Return(nil)
+			},
+			wantEvent: model.AuditEvent{UserID: int64(defaultUserId), Action: constants.AuditLoginSuccess, IP: clientIP, UserAgent: clientUserAgent},
+		},
+		{
+			name:     "wrong password",
+			password: "wrongPassword",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, defaultEmail).Return(user, nil)
+			},
+			wantEvent: model.AuditEvent{
+				UserID:    int64(defaultUserId),
+				Action:    constants.AuditLoginFailure,
+				IP:        clientIP,
+				UserAgent: clientUserAgent,
+				Metadata:  map[string]any{"reason": loginFailureWrongPassword},
+			},
+		},
+		{
+			name:     "unknown email",
+			password: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, defaultEmail).Return(nil, apperror.UserNotFoundErr)
+			},
+			wantEvent: model.AuditEvent{
+				Action:    constants.AuditLoginFailure,
+				IP:        clientIP,
+				UserAgent: clientUserAgent,
+				Metadata:  map[string]any{"reason": loginFailureUserNotFound, "email": defaultEmail},
+			},
+		},
+		{
+			name:     "locked out",
+			password: defaultPassword,
+			setupMocks: func(_ *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Minute, nil)
+			},
+			wantEvent: model.AuditEvent{
+				Action:    constants.AuditLoginFailure,
+				IP:        clientIP,
+				UserAgent: clientUserAgent,
+				Metadata:  map[string]any{"reason": loginFailureLockedOut, "email": defaultEmail},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			ts := new(mocks.MockTokenService)
+			cs := new(MockSessionCache)
+			throttle := new(mocks.MockLoginThrottle)
+			audit := new(mocks.MockAuditLogger)
+
+			tc.setupMocks(repo, ts, cs, throttle)
+			audit.On("Log", mock.Anything, tc.wantEvent).Return().Once()
+
+			uc := Usecase{
+				Rep:           repo,
+				TokenService:  ts,
+				SessionCache:  cs,
+				LoginThrottle: throttle,
+				Audit:         audit,
+			}
+
+			_, _, _ = uc.Login(context.Background(), defaultEmail, tc.password, clientIP, clientUserAgent)
+
+			audit.AssertExpectations(t)
+			repo.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+}
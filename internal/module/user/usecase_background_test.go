@@ -0,0 +1,40 @@
+package user
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	t.Run("waits for background tasks", func(t *testing.T) {
+		uc := &Usecase{}
+		release := make(chan struct{})
+		finished := false
+		uc.runInBackground(context.Background(), "test", func(context.Context) error {
+			<-release
+			finished = true
+			return nil
+		})
+
+		close(release)
+		require.NoError(t, uc.Close(context.Background()))
+		assert.True(t, finished)
+	})
+
+	t.Run("gives up when context expires", func(t *testing.T) {
+		uc := &Usecase{}
+		release := make(chan struct{})
+		defer close(release)
+		uc.runInBackground(context.Background(), "test", func(context.Context) error {
+			<-release
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, uc.Close(ctx), context.DeadlineExceeded)
+	})
+}
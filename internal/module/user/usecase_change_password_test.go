@@ -0,0 +1,167 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestChangePassword(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+
+	newPasswordHash := mock.MatchedBy(func(hash string) bool {
+		return hasher.Verify(hash, newPassword) == nil
+	})
+
+	// Текущая сессия уже ротировалась: sid в access-токене - семейство, TokenID другой
+	rotatedTokenID := "rotatedTokenId"
+	currentSessions := []*cache.RefreshSession{
+		{UserID: int64(defaultUserId), TokenID: rotatedTokenID, FamilyID: refreshTokenId},
+		{UserID: int64(defaultUserId), TokenID: "otherTokenId", FamilyID: "otherFamilyId"},
+	}
+
+	cases := []struct {
+		name            string
+		currentPassword string
+		setupMocks      func(*MockUserRepository, *mocks.MockTokenService, *MockSessionCache, *mocks.MockAccessTokenDenylist)
+		wantToken       string
+		wantErr         error
+		// wantFailure Неверный пароль учитывается счетчиком входа
+		wantFailure bool
+	}{
+		{
+			name:            "success keeps current session",
+			currentPassword: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+				repo.On("UpdatePassword", mock.Anything, int64(defaultUserId), newPasswordHash).Return(nil)
+				cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return(currentSessions, nil)
+				cs.On("DeleteOtherUserSessions", mock.Anything, int64(defaultUserId), rotatedTokenID).Return(nil)
+				dl.On("RevokeAllUserTokens", mock.Anything, int64(defaultUserId)).Return(nil)
+				ts.On("GenerateAccessToken", user, refreshTokenId).Return(accessToken, nil)
+			},
+			wantToken: accessToken,
+		},
+		{
+			name:            "wrong current password",
+			currentPassword: "wrongPassword",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+			},
+			wantErr:     apperror.WrongPasswordErr,
+			wantFailure: true,
+		},
+		{
+			name:            "update password error",
+			currentPassword: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+				repo.On("UpdatePassword", mock.Anything, int64(defaultUserId), mock.Anything).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name:            "delete other sessions error",
+			currentPassword: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, cs *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+				repo.On("UpdatePassword", mock.Anything, int64(defaultUserId), mock.Anything).Return(nil)
+				cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return(currentSessions, nil)
+				cs.On("DeleteOtherUserSessions", mock.Anything, int64(defaultUserId), rotatedTokenID).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			ts := new(mocks.MockTokenService)
+			cs := new(MockSessionCache)
+			dl := new(mocks.MockAccessTokenDenylist)
+			throttle := new(mocks.MockLoginThrottle)
+			tc.setupMocks(repo, ts, cs, dl)
+			throttle.On("Check", mock.Anything, user.Email, clientIP).Return(time.Duration(0), nil)
+			if tc.wantFailure {
+				throttle.On("RegisterFailure", mock.Anything, user.Email, clientIP).Return(time.Duration(0), nil)
+			} else {
+				throttle.On("Reset", mock.Anything, user.Email).Return(nil)
+			}
+
+			uc := &Usecase{
+				Rep:            repo,
+				TokenService:   ts,
+				SessionCache:   cs,
+				AccessDenylist: dl,
+				Reauth:         service.NewReauthenticator(throttle, validator.PasswordPolicy{}),
+			}
+			token, err := uc.ChangePassword(context.Background(), int64(defaultUserId), refreshTokenId, tc.currentPassword, newPassword, clientIP)
+
+			assert.Equal(t, tc.wantToken, token)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			ts.AssertExpectations(t)
+			cs.AssertExpectations(t)
+			dl.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+
+	t.Run("locked out before password check", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, user.Email, clientIP).Return(90*time.Second, nil)
+
+		uc := &Usecase{Rep: repo, Reauth: service.NewReauthenticator(throttle, validator.PasswordPolicy{})}
+		token, err := uc.ChangePassword(context.Background(), int64(defaultUserId), refreshTokenId, defaultPassword, newPassword, clientIP)
+
+		assert.ErrorIs(t, err, apperror.TooManyLoginAttemptsErr)
+		assert.Equal(t, 90*time.Second, apperror.RetryAfter(err))
+		assert.Empty(t, token)
+		throttle.AssertExpectations(t)
+	})
+}
+
+func TestChangePasswordPolicy(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+
+	repo := new(MockUserRepository)
+	repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+	throttle := new(mocks.MockLoginThrottle)
+	throttle.On("Check", mock.Anything, user.Email, clientIP).Return(time.Duration(0), nil)
+	throttle.On("Reset", mock.Anything, user.Email).Return(nil)
+
+	policy := validator.PasswordPolicy{RequireDigit: true}
+	uc := &Usecase{
+		Rep:            repo,
+		PasswordPolicy: policy,
+		Reauth:         service.NewReauthenticator(throttle, policy),
+	}
+	token, err := uc.ChangePassword(context.Background(), int64(defaultUserId), refreshTokenId, defaultPassword, newPassword, clientIP)
+
+	var policyErr *validator.PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, []validator.PasswordViolation{{Rule: validator.RuleDigit, Message: "password must contain a digit"}}, policyErr.Violations)
+	assert.Empty(t, token)
+	// Пароль не меняется, сессии остаются
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLoginWithIdentity(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		tokenSvc := new(mocks.MockTokenService)
+		cs := new(MockSessionCache)
+		auditLogger := new(mocks.MockAuditLogger)
+
+		repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+		tokenSvc.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
+		tokenSvc.On("GenerateAccessToken", user, refreshTokenId).Return(accessToken, nil)
+		cs.On("SetRefreshTokenId", mock.Anything, mock.Anything, refreshTokenId, mock.Anything).Return(nil)
+		cs.On("SaveSession", mock.Anything, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).Return(nil)
+		auditLogger.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+			return e.Action == constants.AuditLoginSuccess && e.Metadata["provider"] == "google"
+		})).Return()
+
+		// Счетчик перебора не участвует: LoginThrottle не задан
+		uc := Usecase{Rep: repo, TokenService: tokenSvc, SessionCache: cs, Audit: auditLogger}
+		result, status, err := uc.LoginWithIdentity(context.Background(), int64(defaultUserId), "google", clientIP, clientUserAgent)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, accessToken, result.AccessToken)
+		assert.Equal(t, plainToken, result.RefreshToken)
+		auditLogger.AssertExpectations(t)
+	})
+
+	t.Run("mfa still required", func(t *testing.T) {
+		withMFA := *user
+		enabledAt := time.Now()
+		withMFA.MFAEnabledAt = &enabledAt
+
+		repo := new(MockUserRepository)
+		tokenSvc := new(mocks.MockTokenService)
+		challenges := new(mocks.MockOneTimeTokenCache)
+		repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&withMFA, nil)
+		challenges.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, 5*time.Minute).Return(nil)
+
+		uc := Usecase{Rep: repo, TokenService: tokenSvc, MFAChallenges: challenges, MFAChallengeTTL: 5 * time.Minute}
+		result, status, err := uc.LoginWithIdentity(context.Background(), int64(defaultUserId), "google", clientIP, clientUserAgent)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.True(t, result.MFARequired)
+		assert.Empty(t, result.AccessToken)
+		tokenSvc.AssertNotCalled(t, "GenerateRefreshToken")
+	})
+
+	t.Run("blocked user", func(t *testing.T) {
+		blocked := *user
+		blocked.Blocked = true
+
+		repo := new(MockUserRepository)
+		repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&blocked, nil)
+
+		uc := Usecase{Rep: repo}
+		_, status, err := uc.LoginWithIdentity(context.Background(), int64(defaultUserId), "google", clientIP, clientUserAgent)
+
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.ErrorIs(t, err, apperror.UserBlockedErr)
+	})
+}
@@ -0,0 +1,187 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const (
+	mfaToken = "testMFAToken"
+	mfaCode  = "123456"
+)
+
+func TestLogin_MFARequired(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+	enabledAt := time.Now()
+	user.MFAEnabledAt = &enabledAt
+
+	repo := new(MockUserRepository)
+	tokenSvc := new(mocks.MockTokenService)
+	challenges := new(mocks.MockOneTimeTokenCache)
+
+	throttle := new(mocks.MockLoginThrottle)
+	throttle.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+	throttle.On("Reset", mock.Anything, defaultEmail).Return(nil)
+
+	repo.On("Get", mock.Anything, defaultEmail).Return(user, nil)
+	challenges.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, 5*time.Minute).Return(nil)
+
+	uc := Usecase{
+		Rep:             repo,
+		TokenService:    tokenSvc,
+		MFAChallenges:   challenges,
+		LoginThrottle:   throttle,
+		MFAChallengeTTL: 5 * time.Minute,
+	}
+
+	result, status, err := uc.Login(context.Background(), defaultEmail, defaultPassword, clientIP, clientUserAgent)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, result.MFARequired)
+	assert.NotEmpty(t, result.MFAToken)
+	assert.Empty(t, result.AccessToken)
+	assert.Empty(t, result.RefreshToken)
+
+	// В кеше только хеш выданного токена
+	challenges.AssertCalled(t, "Save", mock.Anything, int64(defaultUserId), hasher.Sha256Hex(result.MFAToken), 5*time.Minute)
+	tokenSvc.AssertNotCalled(t, "GenerateRefreshToken")
+}
+
+func TestLoginMFA(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+	user.Email = defaultEmail
+
+	hashedToken := hasher.Sha256Hex(mfaToken)
+
+	// challengeFor Общий для большинства сценариев шаг: токен валиден, пользователь найден и не заблокирован
+	challengeFor := func(repo *MockUserRepository, ch *mocks.MockOneTimeTokenCache, th *mocks.MockLoginThrottle) {
+		ch.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+		repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+		th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+	}
+
+	cases := []struct {
+		name       string
+		setupMocks func(*MockUserRepository, *mocks.MockTokenService, *MockSessionCache, *mocks.MockOneTimeTokenCache, *mocks.MockMFAUsecase, *mocks.MockLoginThrottle)
+		wantToken  string
+		wantPlain  string
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name: "success",
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache, ch *mocks.MockOneTimeTokenCache, m *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				challengeFor(repo, ch, th)
+				m.On("VerifyCode", mock.Anything, int64(defaultUserId), mfaCode).Return(nil)
+				ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
+				ts.On("GenerateAccessToken", user, refreshTokenId).Return(accessToken, nil)
+				cs.On("SetRefreshTokenId", mock.Anything, mock.Anything, refreshTokenId, mock.Anything).Return(nil)
+				cs.On("SaveSession", mock.Anything, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).Return(nil)
+			},
+			wantToken:  accessToken,
+			wantPlain:  plainToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "unknown or used challenge",
+			setupMocks: func(_ *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, ch *mocks.MockOneTimeTokenCache, _ *mocks.MockMFAUsecase, _ *mocks.MockLoginThrottle) {
+				ch.On("Consume", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidTokenErr,
+		},
+		{
+			name: "wrong code is counted as failed login",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, ch *mocks.MockOneTimeTokenCache, m *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				challengeFor(repo, ch, th)
+				m.On("VerifyCode", mock.Anything, int64(defaultUserId), mfaCode).Return(apperror.InvalidMFACodeErr)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidMFACodeErr,
+		},
+		{
+			name: "locked out",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, ch *mocks.MockOneTimeTokenCache, _ *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				ch.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Minute, nil)
+			},
+			wantStatus: http.StatusTooManyRequests,
+			wantErr:    apperror.TooManyLoginAttemptsErr,
+		},
+		{
+			name: "verify error",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, ch *mocks.MockOneTimeTokenCache, m *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				challengeFor(repo, ch, th)
+				m.On("VerifyCode", mock.Anything, int64(defaultUserId), mfaCode).Return(customErr)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantErr:    customErr,
+		},
+		{
+			name: "blocked while entering code",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache, ch *mocks.MockOneTimeTokenCache, _ *mocks.MockMFAUsecase, _ *mocks.MockLoginThrottle) {
+				blocked := *user
+				blocked.Blocked = true
+				ch.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&blocked, nil)
+			},
+			wantStatus: http.StatusForbidden,
+			wantErr:    apperror.UserBlockedErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			ts := new(mocks.MockTokenService)
+			cs := new(MockSessionCache)
+			challenges := new(mocks.MockOneTimeTokenCache)
+			mfa := new(mocks.MockMFAUsecase)
+			throttle := new(mocks.MockLoginThrottle)
+
+			tc.setupMocks(repo, ts, cs, challenges, mfa, throttle)
+
+			uc := Usecase{
+				Rep:           repo,
+				TokenService:  ts,
+				SessionCache:  cs,
+				MFA:           mfa,
+				MFAChallenges: challenges,
+				LoginThrottle: throttle,
+			}
+
+			token, plain, status, err := uc.LoginMFA(context.Background(), mfaToken, mfaCode, clientIP, clientUserAgent)
+
+			assert.Equal(t, tc.wantToken, token)
+			assert.Equal(t, tc.wantPlain, plain)
+			assert.Equal(t, tc.wantStatus, status)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			ts.AssertExpectations(t)
+			cs.AssertExpectations(t)
+			challenges.AssertExpectations(t)
+			mfa.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+}
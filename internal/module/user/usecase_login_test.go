@@ -2,11 +2,19 @@ package user
 
 import (
 	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
 	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogin(t *testing.T) {
@@ -19,6 +27,8 @@ func TestLogin(t *testing.T) {
 		wantToken  string
 		wantPlain  string
 		wantErr    error
+		// requireVerified включает config.Auth.RequireVerifiedEmail
+		requireVerified bool
 	}
 
 	cases := []testCase{
@@ -27,7 +37,7 @@ func TestLogin(t *testing.T) {
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
 				repo.On("Get", mock.Anything, defaultEmail).
 					Return(user, nil)
-				ts.On("GenerateAccessToken", mock.Anything).
+				ts.On("GenerateAccessToken", mock.Anything, mock.Anything).
 					Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").
 					Return(refreshTokenId, plainToken, nil)
@@ -40,6 +50,29 @@ func TestLogin(t *testing.T) {
 			wantPlain: plainToken,
 			wantErr:   nil,
 		},
+		{
+			name: "blocked user",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache) {
+				blocked := *user
+				blocked.Blocked = true
+				repo.On("Get", mock.Anything, defaultEmail).
+					Return(&blocked, nil)
+			},
+			wantToken: "",
+			wantPlain: "",
+			wantErr:   apperror.UserBlockedErr,
+		},
+		{
+			name: "email not verified",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache) {
+				repo.On("Get", mock.Anything, defaultEmail).
+					Return(user, nil)
+			},
+			requireVerified: true,
+			wantToken:       "",
+			wantPlain:       "",
+			wantErr:         apperror.EmailNotVerifiedErr,
+		},
 		{
 			name: "repo returns error",
 			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, _ *MockSessionCache) {
@@ -55,7 +88,9 @@ func TestLogin(t *testing.T) {
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, _ *MockSessionCache) {
 				repo.On("Get", mock.Anything, defaultEmail).
 					Return(user, nil)
-				ts.On("GenerateAccessToken", mock.Anything).
+				ts.On("GenerateRefreshToken").
+					Return(refreshTokenId, plainToken, nil)
+				ts.On("GenerateAccessToken", mock.Anything, refreshTokenId).
 					Return("", customErr)
 			},
 			wantToken: "",
@@ -67,8 +102,6 @@ func TestLogin(t *testing.T) {
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, _ *MockSessionCache) {
 				repo.On("Get", mock.Anything, defaultEmail).
 					Return(user, nil)
-				ts.On("GenerateAccessToken", mock.Anything).
-					Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").
 					Return("", "", customErr)
 			},
@@ -81,7 +114,7 @@ func TestLogin(t *testing.T) {
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
 				repo.On("Get", mock.Anything, defaultEmail).
 					Return(user, nil)
-				ts.On("GenerateAccessToken", mock.Anything).
+				ts.On("GenerateAccessToken", mock.Anything, mock.Anything).
 					Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").
 					Return(refreshTokenId, plainToken, nil)
@@ -97,7 +130,7 @@ func TestLogin(t *testing.T) {
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
 				repo.On("Get", mock.Anything, defaultEmail).
 					Return(user, nil)
-				ts.On("GenerateAccessToken", mock.Anything).
+				ts.On("GenerateAccessToken", mock.Anything, mock.Anything).
 					Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").
 					Return(refreshTokenId, plainToken, nil)
@@ -122,16 +155,24 @@ func TestLogin(t *testing.T) {
 
 			tc.setupMocks(repo, tokenSvc, cache)
 
+			// Сценарии троттлинга проверяются в TestLogin_Throttle
+			throttle := new(mocks.MockLoginThrottle)
+			throttle.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+			throttle.On("Reset", mock.Anything, defaultEmail).Return(nil).Maybe()
+
 			uc := Usecase{
-				Rep:          repo,
-				TokenService: tokenSvc,
-				SessionCache: cache,
+				Rep:           repo,
+				TokenService:  tokenSvc,
+				SessionCache:  cache,
+				LoginThrottle: throttle,
+				Auth:          config.Auth{RequireVerifiedEmail: tc.requireVerified},
 			}
 
-			token, plain, _, logErr := uc.Login(context.Background(), defaultEmail, defaultPassword, clientIP, clientUserAgent)
+			result, _, logErr := uc.Login(context.Background(), defaultEmail, defaultPassword, clientIP, clientUserAgent)
 
-			assert.Equal(t, tc.wantToken, token)
-			assert.Equal(t, tc.wantPlain, plain)
+			assert.Equal(t, tc.wantToken, result.AccessToken)
+			assert.Equal(t, tc.wantPlain, result.RefreshToken)
+			assert.False(t, result.MFARequired)
 
 			if tc.wantErr != nil {
 				assert.EqualError(t, logErr, tc.wantErr.Error())
@@ -145,3 +186,140 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestLogin_Throttle(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+
+	longPassword := strings.Repeat("a", validator.DefaultMaxPasswordLength+1)
+	longHashed, err := hasher.HashPassword(longPassword)
+	require.NoError(t, err)
+	longUser := &model.User{ID: int64(defaultUserId), Password: longHashed}
+
+	cases := []struct {
+		name       string
+		password   string
+		setupMocks func(*MockUserRepository, *mocks.MockLoginThrottle)
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name:     "locked out before password check",
+			password: defaultPassword,
+			setupMocks: func(_ *MockUserRepository, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(90*time.Second, nil)
+			},
+			wantStatus: http.StatusTooManyRequests,
+			wantErr:    apperror.TooManyLoginAttemptsErr,
+		},
+		{
+			name:     "wrong password is counted",
+			password: "wrongPassword",
+			setupMocks: func(repo *MockUserRepository, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, defaultEmail).Return(user, nil)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Minute, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			// Хеш совпадает, но такой пароль нельзя установить: до argon2 не доходим
+			name:     "password longer than policy is counted",
+			password: longPassword,
+			setupMocks: func(repo *MockUserRepository, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, defaultEmail).Return(longUser, nil)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "unknown email is counted",
+			password: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, defaultEmail).Return(nil, apperror.UserNotFoundErr)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.UserNotFoundErr,
+		},
+		{
+			name:     "counter failure does not change response",
+			password: "wrongPassword",
+			setupMocks: func(repo *MockUserRepository, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				repo.On("Get", mock.Anything, defaultEmail).Return(user, nil)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), customErr)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "check error",
+			password: defaultPassword,
+			setupMocks: func(_ *MockUserRepository, th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), customErr)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantErr:    customErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			throttle := new(mocks.MockLoginThrottle)
+			tc.setupMocks(repo, throttle)
+
+			uc := Usecase{Rep: repo, LoginThrottle: throttle}
+
+			result, status, err := uc.Login(context.Background(), defaultEmail, tc.password, clientIP, clientUserAgent)
+
+			assert.Error(t, err)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+			assert.Equal(t, tc.wantStatus, status)
+			assert.Empty(t, result.AccessToken)
+
+			repo.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+
+	t.Run("lockout carries retry after", func(t *testing.T) {
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, defaultEmail, clientIP).Return(90*time.Second, nil)
+
+		uc := Usecase{Rep: new(MockUserRepository), LoginThrottle: throttle}
+		_, _, err := uc.Login(context.Background(), defaultEmail, defaultPassword, clientIP, clientUserAgent)
+
+		var retryErr *apperror.RetryAfterError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, 90*time.Second, retryErr.RetryAfter)
+	})
+
+	t.Run("success resets counter", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		tokenSvc := new(mocks.MockTokenService)
+		cs := new(MockSessionCache)
+		throttle := new(mocks.MockLoginThrottle)
+
+		throttle.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+		repo.On("Get", mock.Anything, defaultEmail).Return(user, nil)
+		throttle.On("Reset", mock.Anything, defaultEmail).Return(nil)
+		tokenSvc.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
+		tokenSvc.On("GenerateAccessToken", user, refreshTokenId).Return(accessToken, nil)
+		cs.On("SetRefreshTokenId", mock.Anything, mock.Anything, refreshTokenId, mock.Anything).Return(nil)
+		cs.On("SaveSession", mock.Anything, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).Return(nil)
+
+		uc := Usecase{Rep: repo, TokenService: tokenSvc, SessionCache: cs, LoginThrottle: throttle}
+		_, status, err := uc.Login(context.Background(), defaultEmail, defaultPassword, clientIP, clientUserAgent)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		throttle.AssertExpectations(t)
+	})
+}
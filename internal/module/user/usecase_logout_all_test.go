@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
@@ -13,26 +14,28 @@ func TestLogoutAllDevices(t *testing.T) {
 
 	type testCase struct {
 		name       string
-		setupMocks func(cs *MockSessionCache)
+		setupMocks func(cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist)
 		wantErr    error
 	}
 
 	cases := []testCase{
 		{
 			name: "success",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).
 					Return(refreshSession, nil)
 				cs.On("DeleteAllUserSessions", mock.Anything, refreshSession.UserID).
 					Return(nil)
+				dl.On("RevokeAllUserTokens", mock.Anything, refreshSession.UserID).
+					Return(nil)
 			},
 			wantErr: nil,
 		},
 		{
 			name: "get refresh token id returns error",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return("", customErr)
 			},
@@ -40,7 +43,7 @@ func TestLogoutAllDevices(t *testing.T) {
 		},
 		{
 			name: "get session returns error",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).
@@ -50,12 +53,26 @@ func TestLogoutAllDevices(t *testing.T) {
 		},
 		{
 			name: "delete all user sessions returns error",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				cs.On("GetRefreshTokenId", mock.Anything, hashed).
+					Return(refreshTokenId, nil)
+				cs.On("GetSession", mock.Anything, refreshTokenId).
+					Return(refreshSession, nil)
+				cs.On("DeleteAllUserSessions", mock.Anything, refreshSession.UserID).
+					Return(customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name: "revoke access tokens returns error",
+			setupMocks: func(cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).
 					Return(refreshSession, nil)
 				cs.On("DeleteAllUserSessions", mock.Anything, refreshSession.UserID).
+					Return(nil)
+				dl.On("RevokeAllUserTokens", mock.Anything, refreshSession.UserID).
 					Return(customErr)
 			},
 			wantErr: customErr,
@@ -67,10 +84,12 @@ func TestLogoutAllDevices(t *testing.T) {
 			t.Parallel()
 
 			cacheSession := new(MockSessionCache)
-			tc.setupMocks(cacheSession)
+			denylist := new(mocks.MockAccessTokenDenylist)
+			tc.setupMocks(cacheSession, denylist)
 
 			uc := Usecase{
-				SessionCache: cacheSession,
+				SessionCache:   cacheSession,
+				AccessDenylist: denylist,
 			}
 
 			err := uc.LogoutAllDevices(context.Background(), plainToken, clientIP, clientUserAgent)
@@ -82,6 +101,7 @@ func TestLogoutAllDevices(t *testing.T) {
 			}
 
 			cacheSession.AssertExpectations(t)
+			denylist.AssertExpectations(t)
 		})
 	}
 }
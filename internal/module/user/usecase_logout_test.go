@@ -2,6 +2,8 @@ package user
 
 import (
 	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
@@ -10,17 +12,20 @@ import (
 func TestLogout(t *testing.T) {
 	hashed := hashRefreshToken(plainToken)
 	refreshSession := initRefreshSession()
+	accessClaims := initAccessClaims()
 
 	type testCase struct {
 		name       string
-		setupMocks func(cs *MockSessionCache)
+		setupMocks func(cs *MockSessionCache, ts *mocks.MockTokenService, dl *mocks.MockAccessTokenDenylist)
 		wantErr    error
 	}
 
 	cases := []testCase{
 		{
 			name: "success",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, ts *mocks.MockTokenService, dl *mocks.MockAccessTokenDenylist) {
+				ts.On("ParseToken", accessToken).
+					Return(accessClaims, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).
@@ -29,12 +34,16 @@ func TestLogout(t *testing.T) {
 					Return(nil)
 				cs.On("DeleteRefreshTokenId", mock.Anything, refreshSession.TokenHash).
 					Return(nil)
+				dl.On("RevokeToken", mock.Anything, accessClaims.ID, accessClaims.ExpiresAt.Time).
+					Return(nil)
 			},
 			wantErr: nil,
 		},
 		{
 			name: "get refresh token id returns error",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, ts *mocks.MockTokenService, _ *mocks.MockAccessTokenDenylist) {
+				ts.On("ParseToken", accessToken).
+					Return(accessClaims, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return("", customErr)
 			},
@@ -42,7 +51,9 @@ func TestLogout(t *testing.T) {
 		},
 		{
 			name: "get session returns error",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, ts *mocks.MockTokenService, _ *mocks.MockAccessTokenDenylist) {
+				ts.On("ParseToken", accessToken).
+					Return(accessClaims, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).
@@ -52,7 +63,9 @@ func TestLogout(t *testing.T) {
 		},
 		{
 			name: "delete session returns error",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, ts *mocks.MockTokenService, _ *mocks.MockAccessTokenDenylist) {
+				ts.On("ParseToken", accessToken).
+					Return(accessClaims, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).
@@ -64,7 +77,9 @@ func TestLogout(t *testing.T) {
 		},
 		{
 			name: "delete refresh token id returns error",
-			setupMocks: func(cs *MockSessionCache) {
+			setupMocks: func(cs *MockSessionCache, ts *mocks.MockTokenService, _ *mocks.MockAccessTokenDenylist) {
+				ts.On("ParseToken", accessToken).
+					Return(accessClaims, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).
 					Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).
@@ -76,6 +91,46 @@ func TestLogout(t *testing.T) {
 			},
 			wantErr: customErr,
 		},
+		{
+			name: "parse access token returns error",
+			setupMocks: func(_ *MockSessionCache, ts *mocks.MockTokenService, _ *mocks.MockAccessTokenDenylist) {
+				ts.On("ParseToken", accessToken).
+					Return(accessClaims, customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name: "access token belongs to another user",
+			setupMocks: func(cs *MockSessionCache, ts *mocks.MockTokenService, _ *mocks.MockAccessTokenDenylist) {
+				otherClaims := accessClaims
+				otherClaims.Subject = "999"
+				ts.On("ParseToken", accessToken).
+					Return(otherClaims, nil)
+				cs.On("GetRefreshTokenId", mock.Anything, hashed).
+					Return(refreshTokenId, nil)
+				cs.On("GetSession", mock.Anything, refreshTokenId).
+					Return(refreshSession, nil)
+			},
+			wantErr: errors.New("невозможно выполнить операцию"),
+		},
+		{
+			name: "revoke access token returns error",
+			setupMocks: func(cs *MockSessionCache, ts *mocks.MockTokenService, dl *mocks.MockAccessTokenDenylist) {
+				ts.On("ParseToken", accessToken).
+					Return(accessClaims, nil)
+				cs.On("GetRefreshTokenId", mock.Anything, hashed).
+					Return(refreshTokenId, nil)
+				cs.On("GetSession", mock.Anything, refreshTokenId).
+					Return(refreshSession, nil)
+				cs.On("DeleteSession", mock.Anything, refreshSession.UserID, refreshTokenId).
+					Return(nil)
+				cs.On("DeleteRefreshTokenId", mock.Anything, refreshSession.TokenHash).
+					Return(nil)
+				dl.On("RevokeToken", mock.Anything, accessClaims.ID, accessClaims.ExpiresAt.Time).
+					Return(customErr)
+			},
+			wantErr: customErr,
+		},
 	}
 
 	for _, tc := range cases {
@@ -83,13 +138,17 @@ func TestLogout(t *testing.T) {
 			t.Parallel()
 
 			cacheSession := new(MockSessionCache)
-			tc.setupMocks(cacheSession)
+			tokenSvc := new(mocks.MockTokenService)
+			denylist := new(mocks.MockAccessTokenDenylist)
+			tc.setupMocks(cacheSession, tokenSvc, denylist)
 
 			uc := Usecase{
-				SessionCache: cacheSession,
+				SessionCache:   cacheSession,
+				TokenService:   tokenSvc,
+				AccessDenylist: denylist,
 			}
 
-			err := uc.Logout(context.Background(), plainToken, clientIP, clientUserAgent)
+			err := uc.Logout(context.Background(), accessToken, plainToken, clientIP, clientUserAgent)
 
 			if tc.wantErr != nil {
 				assert.EqualError(t, err, tc.wantErr.Error())
@@ -98,6 +157,8 @@ func TestLogout(t *testing.T) {
 			}
 
 			cacheSession.AssertExpectations(t)
+			tokenSvc.AssertExpectations(t)
+			denylist.AssertExpectations(t)
 		})
 	}
 }
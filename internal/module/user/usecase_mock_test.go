@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/Elaman1/full-project-mock/internal/domain/cache"
 	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
 	"github.com/Elaman1/full-project-mock/pkg/hasher"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
@@ -23,6 +24,7 @@ var (
 	accessToken    = "testAccessToken"
 	refreshTokenId = "testTokenId"
 	plainToken     = "testPlainToken"
+	familyID       = "testFamilyId"
 
 	defaultUserId = 11
 
@@ -30,54 +32,7 @@ var (
 )
 
 // Session раздел
-type MockSessionCache struct {
-	mock.Mock
-}
-
-func (m *MockSessionCache) SaveSession(ctx context.Context, s *cache.RefreshSession, ttl time.Duration) error {
-	args := m.Called(ctx, s, ttl)
-	return args.Error(0)
-}
-
-func (m *MockSessionCache) GetSession(ctx context.Context, tokenID string) (*cache.RefreshSession, error) {
-	args := m.Called(ctx, tokenID)
-	c := args.Get(0)
-	if c == nil {
-		return nil, args.Error(1)
-	}
-
-	refreshSession, ok := c.(*cache.RefreshSession)
-	if !ok {
-		return nil, errors.New("invalid token")
-	}
-
-	return refreshSession, args.Error(1)
-}
-
-func (m *MockSessionCache) DeleteSession(ctx context.Context, userID int64, tokenID string) error {
-	args := m.Called(ctx, userID, tokenID)
-	return args.Error(0)
-}
-
-func (m *MockSessionCache) DeleteAllUserSessions(ctx context.Context, userID int64) error {
-	args := m.Called(ctx, userID)
-	return args.Error(0)
-}
-
-func (m *MockSessionCache) GetRefreshTokenId(ctx context.Context, hashedRefreshToken string) (string, error) {
-	args := m.Called(ctx, hashedRefreshToken)
-	return args.String(0), args.Error(1)
-}
-
-func (m *MockSessionCache) SetRefreshTokenId(ctx context.Context, hashedRefreshToken string, refreshTokenID string, ttl time.Duration) error {
-	args := m.Called(ctx, hashedRefreshToken, refreshTokenID, ttl)
-	return args.Error(0)
-}
-
-func (m *MockSessionCache) DeleteRefreshTokenId(ctx context.Context, hashedRefreshToken string) error {
-	args := m.Called(ctx, hashedRefreshToken)
-	return args.Error(0)
-}
+type MockSessionCache = mocks.MockSessionCache
 
 func initUserWithPassword() (*model.User, error) {
 	password, err := hasher.HashPassword(defaultPassword)
@@ -94,9 +49,22 @@ func initUserWithPassword() (*model.User, error) {
 }
 
 // Refresh раздел
-func initRegisteredClaims() jwt.RegisteredClaims {
-	return jwt.RegisteredClaims{
-		Subject: strconv.Itoa(defaultUserId),
+func initRegisteredClaims() model.AccessClaims {
+	return model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: strconv.Itoa(defaultUserId),
+		},
+	}
+}
+
+func initAccessClaims() model.AccessClaims {
+	return model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "testJti",
+			Subject:   strconv.Itoa(defaultUserId),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 10)),
+		},
+		SessionID: refreshTokenId,
 	}
 }
 
@@ -106,8 +74,10 @@ func initRefreshSession() *cache.RefreshSession {
 		ExpiresAt: time.Now().Add(time.Minute * 10),
 		TokenID:   refreshTokenId,
 		TokenHash: hashRefreshToken(plainToken),
+		FamilyID:  familyID,
 		UserAgent: clientUserAgent,
 		IP:        clientIP,
+		CreatedAt: time.Now().Add(-time.Hour),
 	}
 }
 
@@ -150,3 +120,23 @@ func (m *MockUserRepository) GetById(ctx context.Context, id int64) (*model.User
 
 	return user, args.Error(1)
 }
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id int64, hashedPassword string) error {
+	args := m.Called(ctx, id, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConfirmEmailChange(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateProfile(ctx context.Context, user *model.User, version int64) error {
+	args := m.Called(ctx, user, version)
+	return args.Error(0)
+}
@@ -0,0 +1,218 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	resetToken  = "testResetToken"
+	newPassword = "newSecurePassword"
+)
+
+func TestForgotPassword(t *testing.T) {
+	cases := []struct {
+		name       string
+		setupMocks func(*MockUserRepository, *mocks.MockOneTimeTokenCache, *mocks.MockMailer)
+	}{
+		{
+			name: "sends reset link",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(&model.User{ID: int64(defaultUserId), Email: defaultEmail}, nil)
+				// Запрос уже завершен, а задача продолжает работать
+				tokens.On("Save", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), int64(defaultUserId), mock.Anything, 15*time.Minute).Return(nil)
+				mailer.On("Send", mock.Anything, mock.MatchedBy(func(msg model.MailMessage) bool {
+					return msg.To == defaultEmail && strings.Contains(msg.Body, "https://app.test/reset?token=")
+				})).Return(nil)
+			},
+		},
+		{
+			name: "unknown email is not reported",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(nil, apperror.UserNotFoundErr)
+			},
+		},
+		{
+			name: "repo error is only logged",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(nil, customErr)
+			},
+		},
+		{
+			name: "save token error is only logged",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(&model.User{ID: int64(defaultUserId), Email: defaultEmail}, nil)
+				tokens.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, 15*time.Minute).Return(customErr)
+			},
+		},
+		{
+			name: "mail error is only logged",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(&model.User{ID: int64(defaultUserId), Email: defaultEmail}, nil)
+				tokens.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, 15*time.Minute).Return(nil)
+				mailer.On("Send", mock.Anything, mock.Anything).Return(customErr)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			tokens := new(mocks.MockOneTimeTokenCache)
+			mailer := new(mocks.MockMailer)
+			tc.setupMocks(repo, tokens, mailer)
+
+			uc := &Usecase{
+				Rep:         repo,
+				ResetTokens: tokens,
+				Mailer:      mailer,
+				Auth:        config.Auth{PasswordResetTTL: 15 * time.Minute, PasswordResetURL: "https://app.test/reset"},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			uc.ForgotPassword(ctx, defaultEmail)
+			cancel()
+			uc.background.Wait()
+
+			repo.AssertExpectations(t)
+			tokens.AssertExpectations(t)
+			mailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	hashedToken := hasher.Sha256Hex(resetToken)
+	newPasswordHash := mock.MatchedBy(func(hash string) bool {
+		return hasher.Verify(hash, newPassword) == nil
+	})
+
+	cases := []struct {
+		name       string
+		password   string
+		setupMocks func(*MockUserRepository, *mocks.MockOneTimeTokenCache, *MockSessionCache, *mocks.MockAccessTokenDenylist)
+		wantErr    error
+		wantRule   string
+	}{
+		{
+			name: "success revokes all sessions",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
+				tokens.On("Peek", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&model.User{ID: int64(defaultUserId), Username: defaultUserName, Email: defaultEmail}, nil)
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("UpdatePassword", mock.Anything, int64(defaultUserId), newPasswordHash).Return(nil)
+				cs.On("DeleteAllUserSessions", mock.Anything, int64(defaultUserId)).Return(nil)
+				dl.On("RevokeAllUserTokens", mock.Anything, int64(defaultUserId)).Return(nil)
+			},
+		},
+		{
+			name: "invalid token",
+			setupMocks: func(_ *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, _ *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				tokens.On("Peek", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+			},
+			wantErr: apperror.InvalidTokenErr,
+		},
+		{
+			name:     "weak password keeps token",
+			password: "short",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, _ *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				tokens.On("Peek", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&model.User{ID: int64(defaultUserId), Username: defaultUserName, Email: defaultEmail}, nil)
+			},
+			wantRule: validator.RuleMinLength,
+		},
+		{
+			name:     "password contains username",
+			password: "my-" + defaultUserName + "-password",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, _ *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				tokens.On("Peek", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&model.User{ID: int64(defaultUserId), Username: defaultUserName, Email: defaultEmail}, nil)
+			},
+			wantRule: validator.RulePersonalInfo,
+		},
+		{
+			name: "token used by concurrent request",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, _ *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				tokens.On("Peek", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&model.User{ID: int64(defaultUserId), Username: defaultUserName, Email: defaultEmail}, nil)
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+			},
+			wantErr: apperror.InvalidTokenErr,
+		},
+		{
+			name: "update password error",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, _ *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				tokens.On("Peek", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&model.User{ID: int64(defaultUserId), Username: defaultUserName, Email: defaultEmail}, nil)
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("UpdatePassword", mock.Anything, int64(defaultUserId), mock.Anything).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name: "delete sessions error",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, cs *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				tokens.On("Peek", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&model.User{ID: int64(defaultUserId), Username: defaultUserName, Email: defaultEmail}, nil)
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("UpdatePassword", mock.Anything, int64(defaultUserId), mock.Anything).Return(nil)
+				cs.On("DeleteAllUserSessions", mock.Anything, int64(defaultUserId)).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			tokens := new(mocks.MockOneTimeTokenCache)
+			cs := new(MockSessionCache)
+			dl := new(mocks.MockAccessTokenDenylist)
+			if tc.setupMocks != nil {
+				tc.setupMocks(repo, tokens, cs, dl)
+			}
+
+			uc := &Usecase{
+				Rep:            repo,
+				ResetTokens:    tokens,
+				SessionCache:   cs,
+				AccessDenylist: dl,
+			}
+			password := tc.password
+			if password == "" {
+				password = newPassword
+			}
+			err := uc.ResetPassword(context.Background(), resetToken, password)
+
+			if tc.wantRule != "" {
+				var policyErr *validator.PasswordPolicyError
+				require.ErrorAs(t, err, &policyErr)
+				assert.Equal(t, tc.wantRule, policyErr.Violations[0].Rule)
+			} else if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			tokens.AssertExpectations(t)
+			cs.AssertExpectations(t)
+			dl.AssertExpectations(t)
+		})
+	}
+}
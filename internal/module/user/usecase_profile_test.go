@@ -0,0 +1,243 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/internal/service"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	const newEmail = "new@example.com"
+	verifiedAt := time.Now()
+	newUsername := "renamed"
+	sameUsername := defaultUserName
+	email := newEmail
+	currentEmail := defaultEmail
+
+	withPassword, err := initUserWithPassword()
+	require.NoError(t, err)
+
+	profile := func() *model.User {
+		return &model.User{ID: int64(defaultUserId), Email: defaultEmail, Username: defaultUserName, Password: withPassword.Password, EmailVerifiedAt: &verifiedAt, Version: 3}
+	}
+
+	pendingProfile := func() *model.User {
+		user := profile()
+		pending := newEmail
+		user.PendingEmail = &pending
+		return user
+	}
+
+	cases := []struct {
+		name       string
+		version    int64
+		username   *string
+		email      *string
+		password   string
+		setupMocks func(*MockUserRepository, *mocks.MockOneTimeTokenCache, *mocks.MockMailer, *mocks.MockAuditLogger)
+		wantErr    error
+		wantUser   func(*testing.T, *model.User)
+	}{
+		{
+			name:     "username",
+			version:  3,
+			username: &newUsername,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, audit *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+				repo.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.Username == newUsername && u.Email == defaultEmail
+				}), int64(3)).Run(func(args mock.Arguments) {
+					args.Get(1).(*model.User).Version = 4
+				}).Return(nil)
+				audit.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+					return e.Action == constants.AuditProfileUpdate && assert.ObjectsAreEqual([]string{"username"}, e.Metadata["fields"])
+				})).Return()
+			},
+			wantUser: func(t *testing.T, u *model.User) {
+				assert.Equal(t, newUsername, u.Username)
+				assert.Equal(t, int64(4), u.Version)
+				assert.NotNil(t, u.EmailVerifiedAt)
+			},
+		},
+		{
+			name:     "email waits for confirmation",
+			version:  3,
+			email:    &email,
+			password: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer, audit *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+				repo.On("Exists", mock.Anything, newEmail).Return(false, nil)
+				repo.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.Email == defaultEmail && u.PendingEmail != nil && *u.PendingEmail == newEmail
+				}), int64(3)).Run(func(args mock.Arguments) {
+					args.Get(1).(*model.User).Version = 4
+				}).Return(nil)
+				tokens.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, time.Hour).Return(nil)
+				mailer.On("Send", mock.Anything, mock.MatchedBy(func(msg model.MailMessage) bool {
+					return msg.To == newEmail
+				})).Return(nil)
+				// Уведомление на прежний адрес
+				mailer.On("Send", mock.Anything, mock.MatchedBy(func(msg model.MailMessage) bool {
+					return msg.To == defaultEmail
+				})).Return(nil)
+				audit.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+					return assert.ObjectsAreEqual([]string{"pending_email"}, e.Metadata["fields"])
+				})).Return()
+			},
+			wantUser: func(t *testing.T, u *model.User) {
+				assert.Equal(t, defaultEmail, u.Email)
+				assert.Equal(t, newEmail, *u.PendingEmail)
+				assert.NotNil(t, u.EmailVerifiedAt)
+			},
+		},
+		{
+			name:     "mail error does not fail update",
+			version:  3,
+			email:    &email,
+			password: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer, audit *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+				repo.On("Exists", mock.Anything, newEmail).Return(false, nil)
+				repo.On("UpdateProfile", mock.Anything, mock.Anything, int64(3)).Return(nil)
+				tokens.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, time.Hour).Return(nil)
+				mailer.On("Send", mock.Anything, mock.Anything).Return(customErr)
+				audit.On("Log", mock.Anything, mock.Anything).Return()
+			},
+			wantUser: func(t *testing.T, u *model.User) {
+				assert.Equal(t, newEmail, *u.PendingEmail)
+			},
+		},
+		{
+			name:    "email change without password",
+			version: 3,
+			email:   &email,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, _ *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+			},
+			wantErr: apperror.PasswordRequiredErr,
+		},
+		{
+			name:     "email change with wrong password",
+			version:  3,
+			email:    &email,
+			password: "wrongPassword",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, _ *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:    "current email cancels pending change",
+			version: 3,
+			email:   &currentEmail,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, audit *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(pendingProfile(), nil)
+				repo.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.PendingEmail == nil
+				}), int64(3)).Return(nil)
+				audit.On("Log", mock.Anything, mock.Anything).Return()
+			},
+			wantUser: func(t *testing.T, u *model.User) {
+				assert.Equal(t, defaultEmail, u.Email)
+				assert.Nil(t, u.PendingEmail)
+			},
+		},
+		{
+			name:     "nothing changed",
+			version:  3,
+			username: &sameUsername,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, _ *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+			},
+			wantUser: func(t *testing.T, u *model.User) {
+				assert.Equal(t, int64(3), u.Version)
+			},
+		},
+		{
+			name:     "stale version",
+			version:  2,
+			username: &newUsername,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, _ *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+			},
+			wantErr: apperror.VersionConflictErr,
+		},
+		{
+			name:     "concurrent update",
+			version:  3,
+			username: &newUsername,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, _ *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+				repo.On("UpdateProfile", mock.Anything, mock.Anything, int64(3)).Return(apperror.VersionConflictErr)
+			},
+			wantErr: apperror.VersionConflictErr,
+		},
+		{
+			name:     "email taken",
+			version:  3,
+			email:    &email,
+			password: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer, _ *mocks.MockAuditLogger) {
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(profile(), nil)
+				repo.On("Exists", mock.Anything, newEmail).Return(true, nil)
+			},
+			wantErr: apperror.ExistsEmailErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			tokens := new(mocks.MockOneTimeTokenCache)
+			mailer := new(mocks.MockMailer)
+			audit := new(mocks.MockAuditLogger)
+			throttle := new(mocks.MockLoginThrottle)
+			tc.setupMocks(repo, tokens, mailer, audit)
+			// Пароль проверяется только при смене email, неверный учитывается счетчиком входа
+			if tc.password != "" {
+				throttle.On("Check", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				if tc.password == defaultPassword {
+					throttle.On("Reset", mock.Anything, defaultEmail).Return(nil)
+				} else {
+					throttle.On("RegisterFailure", mock.Anything, defaultEmail, clientIP).Return(time.Duration(0), nil)
+				}
+			}
+
+			uc := &Usecase{
+				Rep:               repo,
+				EmailChangeTokens: tokens,
+				Mailer:            mailer,
+				Audit:             audit,
+				Auth:              config.Auth{EmailVerificationTTL: time.Hour},
+				Reauth:            service.NewReauthenticator(throttle, validator.PasswordPolicy{}),
+			}
+			user, err := uc.UpdateProfile(context.Background(), int64(defaultUserId), tc.version, tc.username, tc.email, tc.password, clientIP)
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				tc.wantUser(t, user)
+			}
+
+			repo.AssertExpectations(t)
+			tokens.AssertExpectations(t)
+			mailer.AssertExpectations(t)
+			audit.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package user
 
 import (
 	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
 	"github.com/Elaman1/full-project-mock/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -32,10 +34,12 @@ func TestRefresh(t *testing.T) {
 				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).Return(refreshSession, nil)
-				ts.On("GenerateAccessToken", user).Return(accessToken, nil)
+				ts.On("GenerateAccessToken", user, familyID).Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
-				cs.On("SetRefreshTokenId", mock.Anything, hashed, refreshTokenId, mock.Anything).Return(nil)
-				cs.On("SaveSession", mock.Anything, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).Return(nil)
+				cs.On("RotateSession", mock.Anything, refreshSession, mock.MatchedBy(func(s *cache.RefreshSession) bool {
+					// Новая сессия остается в том же семействе и сохраняет время логина
+					return s.FamilyID == familyID && s.TokenHash == hashed && s.CreatedAt.Equal(refreshSession.CreatedAt)
+				}), mock.Anything).Return(nil)
 			},
 			wantToken: accessToken,
 			wantPlain: plainToken,
@@ -60,6 +64,18 @@ func TestRefresh(t *testing.T) {
 			wantPlain: "",
 			wantErr:   customErr,
 		},
+		{
+			name: "blocked user",
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, _ *MockSessionCache) {
+				blocked := *user
+				blocked.Blocked = true
+				ts.On("ParseToken", accessToken).Return(regClaims, nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(&blocked, nil)
+			},
+			wantToken: "",
+			wantPlain: "",
+			wantErr:   apperror.UserBlockedErr,
+		},
 		{
 			name: "get refresh token id error",
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
@@ -83,6 +99,20 @@ func TestRefresh(t *testing.T) {
 			wantPlain: "",
 			wantErr:   customErr,
 		},
+		{
+			name: "refresh token of another user",
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
+				foreign := *refreshSession
+				foreign.UserID = int64(defaultUserId) + 1
+				ts.On("ParseToken", accessToken).Return(regClaims, nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
+				cs.On("GetSession", mock.Anything, refreshTokenId).Return(&foreign, nil)
+			},
+			wantToken: "",
+			wantPlain: "",
+			wantErr:   apperror.InvalidTokenErr,
+		},
 		{
 			name: "generate access token error",
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
@@ -90,7 +120,8 @@ func TestRefresh(t *testing.T) {
 				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).Return(refreshSession, nil)
-				ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User")).Return("", customErr)
+				ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
+				ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User"), familyID).Return("", customErr)
 			},
 			wantToken: "",
 			wantPlain: "",
@@ -103,7 +134,6 @@ func TestRefresh(t *testing.T) {
 				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).Return(refreshSession, nil)
-				ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User")).Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").Return("", "", customErr)
 			},
 			wantToken: "",
@@ -111,35 +141,62 @@ func TestRefresh(t *testing.T) {
 			wantErr:   customErr,
 		},
 		{
-			name: "set refresh token id error",
+			name: "rotate session error",
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
 				ts.On("ParseToken", accessToken).Return(regClaims, nil)
 				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).Return(refreshSession, nil)
-				ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User")).Return(accessToken, nil)
+				ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User"), mock.Anything).Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
-				cs.On("SetRefreshTokenId", mock.Anything, hashed, refreshTokenId, mock.Anything).Return(customErr)
+				cs.On("RotateSession", mock.Anything, refreshSession, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).Return(customErr)
 			},
 			wantToken: "",
 			wantPlain: "",
 			wantErr:   customErr,
 		},
 		{
-			name: "save session error",
+			name: "token consumed by concurrent refresh",
 			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
 				ts.On("ParseToken", accessToken).Return(regClaims, nil)
 				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
 				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
 				cs.On("GetSession", mock.Anything, refreshTokenId).Return(refreshSession, nil)
-				ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User")).Return(accessToken, nil)
+				ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User"), mock.Anything).Return(accessToken, nil)
 				ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
-				cs.On("SetRefreshTokenId", mock.Anything, hashed, refreshTokenId, mock.Anything).Return(nil)
-				cs.On("SaveSession", mock.Anything, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).Return(customErr)
+				cs.On("RotateSession", mock.Anything, refreshSession, mock.AnythingOfType("*cache.RefreshSession"), mock.Anything).
+					Return(cache.ErrRefreshTokenConsumed)
+				cs.On("RevokeFamily", mock.Anything, int64(defaultUserId), familyID).Return(nil)
 			},
 			wantToken: "",
 			wantPlain: "",
-			wantErr:   customErr,
+			wantErr:   apperror.RefreshTokenReusedErr,
+		},
+		{
+			name: "rotated token reused",
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
+				ts.On("ParseToken", accessToken).Return(regClaims, nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return("", cache.ErrNotFound)
+				cs.On("GetRotatedRefreshToken", mock.Anything, hashed).
+					Return(&cache.RotatedRefreshToken{UserID: int64(defaultUserId), FamilyID: familyID}, nil)
+				cs.On("RevokeFamily", mock.Anything, int64(defaultUserId), familyID).Return(nil)
+			},
+			wantToken: "",
+			wantPlain: "",
+			wantErr:   apperror.RefreshTokenReusedErr,
+		},
+		{
+			name: "unknown refresh token",
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache) {
+				ts.On("ParseToken", accessToken).Return(regClaims, nil)
+				repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return("", cache.ErrNotFound)
+				cs.On("GetRotatedRefreshToken", mock.Anything, hashed).Return(nil, cache.ErrNotFound)
+			},
+			wantToken: "",
+			wantPlain: "",
+			wantErr:   cache.ErrNotFound,
 		},
 	}
 
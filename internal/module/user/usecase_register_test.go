@@ -4,15 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRegister(t *testing.T) {
 	type testCase struct {
 		name       string
-		setupMocks func(*MockUserRepository)
+		setupMocks func(*MockUserRepository, *mocks.MockOneTimeTokenCache, *mocks.MockMailer)
 		wantID     int64
 		wantErr    error
 		wantErrMsg string
@@ -21,16 +28,33 @@ func TestRegister(t *testing.T) {
 	cases := []testCase{
 		{
 			name: "success",
-			setupMocks: func(repo *MockUserRepository) {
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer) {
+				repo.On("Exists", mock.Anything, defaultEmail).Return(false, nil)
+				repo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					args.Get(1).(*model.User).ID = int64(defaultUserId)
+				}).Return(nil)
+				tokens.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, time.Hour).Return(nil)
+				mailer.On("Send", mock.Anything, mock.MatchedBy(func(msg model.MailMessage) bool {
+					return msg.To == defaultEmail && strings.Contains(msg.Body, "https://app.test/verify?token=")
+				})).Return(nil)
+			},
+			wantID:  int64(defaultUserId),
+			wantErr: nil,
+		},
+		{
+			name: "send verification email error does not fail registration",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer) {
 				repo.On("Exists", mock.Anything, defaultEmail).Return(false, nil)
 				repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+				tokens.On("Save", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				mailer.On("Send", mock.Anything, mock.Anything).Return(customErr)
 			},
 			wantID:  0,
 			wantErr: nil,
 		},
 		{
 			name: "email already exists",
-			setupMocks: func(repo *MockUserRepository) {
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
 				repo.On("Exists", mock.Anything, defaultEmail).Return(true, nil)
 			},
 			wantID:     0,
@@ -38,7 +62,7 @@ func TestRegister(t *testing.T) {
 		},
 		{
 			name: "exists returns error",
-			setupMocks: func(repo *MockUserRepository) {
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
 				repo.On("Exists", mock.Anything, defaultEmail).Return(false, customErr)
 			},
 			wantID:     0,
@@ -46,7 +70,7 @@ func TestRegister(t *testing.T) {
 		},
 		{
 			name: "create returns error",
-			setupMocks: func(repo *MockUserRepository) {
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
 				repo.On("Exists", mock.Anything, defaultEmail).Return(false, nil)
 				repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("customer error"))
 			},
@@ -60,10 +84,15 @@ func TestRegister(t *testing.T) {
 			t.Parallel()
 
 			repo := new(MockUserRepository)
-			tc.setupMocks(repo)
+			tokens := new(mocks.MockOneTimeTokenCache)
+			mailer := new(mocks.MockMailer)
+			tc.setupMocks(repo, tokens, mailer)
 
 			uc := &Usecase{
-				Rep: repo,
+				Rep:         repo,
+				EmailTokens: tokens,
+				Mailer:      mailer,
+				Auth:        config.Auth{EmailVerificationTTL: time.Hour, VerifyEmailURL: "https://app.test/verify"},
 			}
 
 			gotID, err := uc.Register(context.Background(), defaultEmail, defaultUserName, defaultPassword)
@@ -79,6 +108,43 @@ func TestRegister(t *testing.T) {
 			}
 
 			repo.AssertExpectations(t)
+			tokens.AssertExpectations(t)
+			mailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRegisterPasswordPolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		password string
+		wantRule string
+	}{
+		{name: "too short", password: "Sh0rt!", wantRule: validator.RuleMinLength},
+		{name: "contains username", password: "My-" + defaultUserName + "-1", wantRule: validator.RulePersonalInfo},
+		{name: "contains email", password: "Pass-" + defaultEmail + "-1", wantRule: validator.RulePersonalInfo},
+		{name: "missing symbol", password: "Password123", wantRule: validator.RuleSymbol},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Пароль проверяется до обращения к БД
+			repo := new(MockUserRepository)
+			uc := &Usecase{
+				Rep:            repo,
+				PasswordPolicy: validator.PasswordPolicy{RequireLowercase: true, RequireUppercase: true, RequireDigit: true, RequireSymbol: true},
+			}
+
+			gotID, err := uc.Register(context.Background(), defaultEmail, defaultUserName, tc.password)
+
+			assert.Zero(t, gotID)
+			var policyErr *validator.PasswordPolicyError
+			require.ErrorAs(t, err, &policyErr)
+			require.Len(t, policyErr.Violations, 1)
+			assert.Equal(t, tc.wantRule, policyErr.Violations[0].Rule)
+			repo.AssertExpectations(t)
 		})
 	}
 }
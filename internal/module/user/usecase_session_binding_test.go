@@ -0,0 +1,270 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const (
+	chrome125 = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.0.0 Safari/537.36"
+	chrome126 = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+	firefox   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0"
+)
+
+func TestBindingAllows(t *testing.T) {
+	sess := &cache.RefreshSession{IP: "203.0.113.10", UserAgent: chrome125}
+	sess6 := &cache.RefreshSession{IP: "2001:db8:1:2::10", UserAgent: chrome125}
+
+	tests := []struct {
+		name   string
+		policy string
+		sess   *cache.RefreshSession
+		ip     string
+		ua     string
+		want   bool
+	}{
+		{name: "strict same", policy: SessionBindingStrict, sess: sess, ip: "203.0.113.10", ua: chrome125, want: true},
+		{name: "strict other ip", policy: SessionBindingStrict, sess: sess, ip: "203.0.113.11", ua: chrome125, want: false},
+		{name: "strict browser update", policy: SessionBindingStrict, sess: sess, ip: "203.0.113.10", ua: chrome126, want: false},
+		{name: "empty policy is strict", policy: "", sess: sess, ip: "203.0.113.11", ua: chrome125, want: false},
+		{name: "subnet same /24 and browser update", policy: SessionBindingSubnet, sess: sess, ip: "203.0.113.200", ua: chrome126, want: true},
+		{name: "subnet other /24", policy: SessionBindingSubnet, sess: sess, ip: "203.0.114.10", ua: chrome125, want: false},
+		{name: "subnet other browser", policy: SessionBindingSubnet, sess: sess, ip: "203.0.113.10", ua: firefox, want: false},
+		{name: "subnet same /64", policy: SessionBindingSubnet, sess: sess6, ip: "2001:db8:1:2::ffff", ua: chrome125, want: true},
+		{name: "subnet other /64", policy: SessionBindingSubnet, sess: sess6, ip: "2001:db8:1:3::10", ua: chrome125, want: false},
+		{name: "subnet ipv4 vs ipv6", policy: SessionBindingSubnet, sess: sess, ip: "2001:db8:1:2::10", ua: chrome125, want: false},
+		{name: "ua family ignores network", policy: SessionBindingUAFamily, sess: sess, ip: "198.51.100.1", ua: chrome126, want: true},
+		{name: "ua family other browser", policy: SessionBindingUAFamily, sess: sess, ip: "203.0.113.10", ua: firefox, want: false},
+		{name: "ua family unknown agent", policy: SessionBindingUAFamily, sess: &cache.RefreshSession{UserAgent: "custom"}, ua: "custom/2", want: false},
+		{name: "off", policy: SessionBindingOff, sess: sess, ip: "198.51.100.1", ua: "curl/8.5.0", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, bindingAllows(tt.policy, tt.sess, tt.ip, tt.ua))
+		})
+	}
+}
+
+func TestRefresh_SessionBinding(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+	regClaims := initRegisteredClaims()
+	hashed := hashRefreshToken(plainToken)
+
+	// Сессия создана с другого адреса из той же подсети
+	sessionFrom := func(ip string) *cache.RefreshSession {
+		sess := initRefreshSession()
+		sess.IP = ip
+		return sess
+	}
+
+	tests := []struct {
+		name       string
+		auth       config.Auth
+		sessionIP  string
+		rotates    bool
+		wantStatus int
+		wantErr    error
+	}{
+		{name: "strict rejects", auth: config.Auth{}, sessionIP: "127.0.0.2", wantStatus: http.StatusUnauthorized},
+		{name: "strict with step-up", auth: config.Auth{SessionStepUp: true}, sessionIP: "127.0.0.2", wantStatus: http.StatusUnauthorized, wantErr: apperror.StepUpRequiredErr},
+		{name: "subnet allows", auth: config.Auth{SessionBinding: SessionBindingSubnet}, sessionIP: "127.0.0.2", rotates: true, wantStatus: http.StatusOK},
+		{name: "subnet with step-up outside subnet", auth: config.Auth{SessionBinding: SessionBindingSubnet, SessionStepUp: true}, sessionIP: "10.0.0.1", wantStatus: http.StatusUnauthorized, wantErr: apperror.StepUpRequiredErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockUserRepository)
+			ts := new(mocks.MockTokenService)
+			cs := new(MockSessionCache)
+
+			ts.On("ParseToken", accessToken).Return(regClaims, nil)
+			repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(user, nil)
+			cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
+			cs.On("GetSession", mock.Anything, refreshTokenId).Return(sessionFrom(tt.sessionIP), nil)
+			if tt.rotates {
+				ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
+				ts.On("GenerateAccessToken", user, familyID).Return(accessToken, nil)
+				cs.On("RotateSession", mock.Anything, mock.Anything, mock.MatchedBy(func(s *cache.RefreshSession) bool {
+					return s.IP == clientIP
+				}), mock.Anything).Return(nil)
+			}
+
+			audit := new(mocks.MockAuditLogger)
+			audit.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+				return e.Action == constants.AuditSessionFingerprintMismatch && e.UserID == int64(defaultUserId) &&
+					e.IP == clientIP && e.Metadata["session_ip"] == tt.sessionIP && e.Metadata["allowed"] == tt.rotates
+			})).Return().Once()
+			if tt.rotates {
+				audit.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+					return e.Action == constants.AuditRefresh
+				})).Return().Once()
+			}
+
+			uc := Usecase{Rep: repo, TokenService: ts, SessionCache: cs, Auth: tt.auth, Audit: audit}
+			_, _, status, err := uc.Refresh(context.Background(), accessToken, plainToken, clientIP, clientUserAgent)
+
+			assert.Equal(t, tt.wantStatus, status)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.rotates:
+				assert.NoError(t, err)
+			default:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, apperror.StepUpRequiredErr)
+			}
+
+			cs.AssertExpectations(t)
+			ts.AssertExpectations(t)
+			audit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRefreshStepUp(t *testing.T) {
+	user, err := initUserWithPassword()
+	require.NoError(t, err)
+	user.Email = defaultEmail
+
+	mfaUser := *user
+	enabledAt := time.Now()
+	mfaUser.MFAEnabledAt = &enabledAt
+
+	hashed := hashRefreshToken(plainToken)
+	stepUpAuth := config.Auth{SessionStepUp: true}
+	newIP := "198.51.100.7"
+
+	// sessionFound Токен валиден, сессия жива, пользователь найден и не заблокирован
+	sessionFound := func(u *model.User, cs *MockSessionCache, repo *MockUserRepository, th *mocks.MockLoginThrottle) {
+		cs.On("GetRefreshTokenId", mock.Anything, hashed).Return(refreshTokenId, nil)
+		cs.On("GetSession", mock.Anything, refreshTokenId).Return(initRefreshSession(), nil)
+		repo.On("GetById", mock.Anything, int64(defaultUserId)).Return(u, nil)
+		th.On("Check", mock.Anything, defaultEmail, newIP).Return(time.Duration(0), nil)
+	}
+
+	rotates := func(ts *mocks.MockTokenService, cs *MockSessionCache, th *mocks.MockLoginThrottle) {
+		th.On("Reset", mock.Anything, defaultEmail).Return(nil)
+		ts.On("GenerateRefreshToken").Return(refreshTokenId, plainToken, nil)
+		ts.On("GenerateAccessToken", mock.AnythingOfType("*model.User"), familyID).Return(accessToken, nil)
+		cs.On("RotateSession", mock.Anything, mock.Anything, mock.MatchedBy(func(s *cache.RefreshSession) bool {
+			// Сессия остается в семействе, но получает новый отпечаток
+			return s.FamilyID == familyID && s.IP == newIP
+		}), mock.Anything).Return(nil)
+	}
+
+	tests := []struct {
+		name       string
+		auth       config.Auth
+		password   string
+		code       string
+		setupMocks func(*MockUserRepository, *mocks.MockTokenService, *MockSessionCache, *mocks.MockMFAUsecase, *mocks.MockLoginThrottle)
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name:     "disabled",
+			auth:     config.Auth{},
+			password: defaultPassword,
+			setupMocks: func(*MockUserRepository, *mocks.MockTokenService, *MockSessionCache, *mocks.MockMFAUsecase, *mocks.MockLoginThrottle) {
+			},
+			wantStatus: http.StatusNotFound,
+			wantErr:    apperror.StepUpNotEnabledErr,
+		},
+		{
+			name:     "unknown refresh token",
+			auth:     stepUpAuth,
+			password: defaultPassword,
+			setupMocks: func(_ *MockUserRepository, _ *mocks.MockTokenService, cs *MockSessionCache, _ *mocks.MockMFAUsecase, _ *mocks.MockLoginThrottle) {
+				cs.On("GetRefreshTokenId", mock.Anything, hashed).Return("", cache.ErrNotFound)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidTokenErr,
+		},
+		{
+			name:     "wrong password counts as failed login",
+			auth:     stepUpAuth,
+			password: "wrong-password",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, cs *MockSessionCache, _ *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				sessionFound(user, cs, repo, th)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, newIP).Return(time.Duration(0), nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.WrongPasswordErr,
+		},
+		{
+			name:     "wrong mfa code",
+			auth:     stepUpAuth,
+			password: defaultPassword,
+			code:     mfaCode,
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockTokenService, cs *MockSessionCache, m *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				sessionFound(&mfaUser, cs, repo, th)
+				m.On("VerifyCode", mock.Anything, int64(defaultUserId), mfaCode).Return(apperror.InvalidMFACodeErr)
+				th.On("RegisterFailure", mock.Anything, defaultEmail, newIP).Return(time.Duration(0), nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    apperror.InvalidMFACodeErr,
+		},
+		{
+			name:     "success",
+			auth:     stepUpAuth,
+			password: defaultPassword,
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache, _ *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				sessionFound(user, cs, repo, th)
+				rotates(ts, cs, th)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "success with mfa",
+			auth:     stepUpAuth,
+			password: defaultPassword,
+			code:     mfaCode,
+			setupMocks: func(repo *MockUserRepository, ts *mocks.MockTokenService, cs *MockSessionCache, m *mocks.MockMFAUsecase, th *mocks.MockLoginThrottle) {
+				sessionFound(&mfaUser, cs, repo, th)
+				m.On("VerifyCode", mock.Anything, int64(defaultUserId), mfaCode).Return(nil)
+				rotates(ts, cs, th)
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockUserRepository)
+			ts := new(mocks.MockTokenService)
+			cs := new(MockSessionCache)
+			mfa := new(mocks.MockMFAUsecase)
+			throttle := new(mocks.MockLoginThrottle)
+			tt.setupMocks(repo, ts, cs, mfa, throttle)
+
+			uc := Usecase{Rep: repo, TokenService: ts, SessionCache: cs, MFA: mfa, LoginThrottle: throttle, Auth: tt.auth}
+			_, _, status, err := uc.RefreshStepUp(context.Background(), plainToken, tt.password, tt.code, newIP, clientUserAgent)
+
+			assert.Equal(t, tt.wantStatus, status)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			ts.AssertExpectations(t)
+			cs.AssertExpectations(t)
+			mfa.AssertExpectations(t)
+			throttle.AssertExpectations(t)
+		})
+	}
+}
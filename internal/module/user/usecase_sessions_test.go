@@ -0,0 +1,141 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const chromeUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+func TestListSessions(t *testing.T) {
+	now := time.Now()
+	current := &cache.RefreshSession{
+		UserID: int64(defaultUserId), TokenID: refreshTokenId, FamilyID: familyID,
+		IP: clientIP, UserAgent: chromeUserAgent, CreatedAt: now.Add(-time.Hour), LastUsedAt: now.Add(-time.Minute),
+	}
+	other := &cache.RefreshSession{
+		UserID: int64(defaultUserId), TokenID: "otherTokenId", FamilyID: "otherFamilyId",
+		IP: "10.0.0.2", UserAgent: "curl/8.5.0", CreatedAt: now.Add(-2 * time.Hour), LastUsedAt: now,
+	}
+	legacy := &cache.RefreshSession{
+		UserID: int64(defaultUserId), TokenID: "legacyTokenId", LastUsedAt: now.Add(-time.Hour),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		cs := new(MockSessionCache)
+		cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).
+			Return([]*cache.RefreshSession{legacy, current, other}, nil)
+
+		uc := Usecase{SessionCache: cs}
+		sessions, err := uc.ListSessions(context.Background(), int64(defaultUserId), familyID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 3)
+
+		// Сортировка по последнему использованию, ID и текущая сессия - по семейству, а не по текущему refresh
+		assert.Equal(t, "otherFamilyId", sessions[0].ID)
+		assert.False(t, sessions[0].Current)
+		assert.Equal(t, familyID, sessions[1].ID)
+		assert.True(t, sessions[1].Current)
+		assert.Equal(t, "Chrome", sessions[1].Device.Browser)
+		assert.Equal(t, "Windows", sessions[1].Device.OS)
+		assert.Equal(t, current.CreatedAt, sessions[1].CreatedAt)
+		assert.Equal(t, "legacyTokenId", sessions[2].ID)
+
+		cs.AssertExpectations(t)
+	})
+
+	t.Run("cache error", func(t *testing.T) {
+		cs := new(MockSessionCache)
+		cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return(nil, customErr)
+
+		uc := Usecase{SessionCache: cs}
+		_, err := uc.ListSessions(context.Background(), int64(defaultUserId), refreshTokenId)
+		assert.ErrorIs(t, err, customErr)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	session := initRefreshSession()
+	legacy := &cache.RefreshSession{UserID: int64(defaultUserId), TokenID: "legacyTokenId", TokenHash: "legacyHash"}
+
+	tests := []struct {
+		name       string
+		id         string
+		setupMocks func(cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist)
+		wantErr    error
+	}{
+		{
+			name: "revokes family and access tokens of all rotations",
+			id:   familyID,
+			setupMocks: func(cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
+				cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return([]*cache.RefreshSession{session}, nil)
+				cs.On("RevokeFamily", mock.Anything, int64(defaultUserId), familyID).Return(nil)
+				dl.On("RevokeSession", mock.Anything, familyID).Return(nil)
+			},
+		},
+		{
+			name: "session without family",
+			id:   "legacyTokenId",
+			setupMocks: func(cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
+				cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return([]*cache.RefreshSession{legacy}, nil)
+				cs.On("DeleteSession", mock.Anything, int64(defaultUserId), "legacyTokenId").Return(nil)
+				cs.On("DeleteRefreshTokenId", mock.Anything, "legacyHash").Return(nil)
+				dl.On("RevokeSession", mock.Anything, "legacyTokenId").Return(nil)
+			},
+		},
+		{
+			name: "foreign or unknown session",
+			id:   "otherFamilyId",
+			setupMocks: func(cs *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return([]*cache.RefreshSession{session}, nil)
+			},
+			wantErr: apperror.SessionNotFoundErr,
+		},
+		{
+			name: "revoke family error",
+			id:   familyID,
+			setupMocks: func(cs *MockSessionCache, _ *mocks.MockAccessTokenDenylist) {
+				cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return([]*cache.RefreshSession{session}, nil)
+				cs.On("RevokeFamily", mock.Anything, int64(defaultUserId), familyID).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name: "denylist error",
+			id:   familyID,
+			setupMocks: func(cs *MockSessionCache, dl *mocks.MockAccessTokenDenylist) {
+				cs.On("ListUserSessions", mock.Anything, int64(defaultUserId)).Return([]*cache.RefreshSession{session}, nil)
+				cs.On("RevokeFamily", mock.Anything, int64(defaultUserId), familyID).Return(nil)
+				dl.On("RevokeSession", mock.Anything, familyID).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := new(MockSessionCache)
+			dl := new(mocks.MockAccessTokenDenylist)
+			tt.setupMocks(cs, dl)
+
+			uc := Usecase{SessionCache: cs, AccessDenylist: dl}
+			err := uc.RevokeSession(context.Background(), int64(defaultUserId), tt.id)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			cs.AssertExpectations(t)
+			dl.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package user
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/internal/config"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/constants"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+const verificationToken = "testVerificationToken"
+
+func TestVerifyEmail(t *testing.T) {
+	hashedToken := hasher.Sha256Hex(verificationToken)
+
+	cases := []struct {
+		name       string
+		setupMocks func(*MockUserRepository, *mocks.MockOneTimeTokenCache, *mocks.MockOneTimeTokenCache, *mocks.MockAuditLogger)
+		wantErr    error
+	}{
+		{
+			name: "success",
+			setupMocks: func(repo *MockUserRepository, tokens, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockAuditLogger) {
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("MarkEmailVerified", mock.Anything, int64(defaultUserId)).Return(nil)
+			},
+		},
+		{
+			name: "unknown or used token",
+			setupMocks: func(_ *MockUserRepository, tokens, changeTokens *mocks.MockOneTimeTokenCache, _ *mocks.MockAuditLogger) {
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+				changeTokens.On("Consume", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+			},
+			wantErr: apperror.InvalidTokenErr,
+		},
+		{
+			name: "cache error",
+			setupMocks: func(_ *MockUserRepository, tokens, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockAuditLogger) {
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(0), customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name: "repo error",
+			setupMocks: func(repo *MockUserRepository, tokens, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockAuditLogger) {
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("MarkEmailVerified", mock.Anything, int64(defaultUserId)).Return(customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name: "email change confirmed",
+			setupMocks: func(repo *MockUserRepository, tokens, changeTokens *mocks.MockOneTimeTokenCache, audit *mocks.MockAuditLogger) {
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+				changeTokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("ConfirmEmailChange", mock.Anything, int64(defaultUserId)).Return(nil)
+				audit.On("Log", mock.Anything, mock.MatchedBy(func(e model.AuditEvent) bool {
+					return e.Action == constants.AuditEmailChange
+				})).Return()
+			},
+		},
+		{
+			name: "email change cancelled",
+			setupMocks: func(repo *MockUserRepository, tokens, changeTokens *mocks.MockOneTimeTokenCache, _ *mocks.MockAuditLogger) {
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+				changeTokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("ConfirmEmailChange", mock.Anything, int64(defaultUserId)).Return(apperror.UserNotFoundErr)
+			},
+			wantErr: apperror.InvalidTokenErr,
+		},
+		{
+			name: "new email taken meanwhile",
+			setupMocks: func(repo *MockUserRepository, tokens, changeTokens *mocks.MockOneTimeTokenCache, _ *mocks.MockAuditLogger) {
+				tokens.On("Consume", mock.Anything, hashedToken).Return(int64(0), cache.ErrNotFound)
+				changeTokens.On("Consume", mock.Anything, hashedToken).Return(int64(defaultUserId), nil)
+				repo.On("ConfirmEmailChange", mock.Anything, int64(defaultUserId)).Return(apperror.ExistsEmailErr)
+			},
+			wantErr: apperror.ExistsEmailErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			tokens := new(mocks.MockOneTimeTokenCache)
+			changeTokens := new(mocks.MockOneTimeTokenCache)
+			audit := new(mocks.MockAuditLogger)
+			tc.setupMocks(repo, tokens, changeTokens, audit)
+
+			uc := &Usecase{Rep: repo, EmailTokens: tokens, EmailChangeTokens: changeTokens, Audit: audit}
+			err := uc.VerifyEmail(context.Background(), verificationToken)
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+			tokens.AssertExpectations(t)
+			changeTokens.AssertExpectations(t)
+			audit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	verifiedAt := time.Now()
+
+	cases := []struct {
+		name       string
+		setupMocks func(*MockUserRepository, *mocks.MockOneTimeTokenCache, *mocks.MockMailer)
+	}{
+		{
+			name: "sends new token",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(&model.User{ID: int64(defaultUserId), Email: defaultEmail}, nil)
+				// Запрос уже завершен, а задача продолжает работать
+				tokens.On("Save", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), int64(defaultUserId), mock.Anything, time.Hour).Return(nil)
+				mailer.On("Send", mock.Anything, mock.MatchedBy(func(msg model.MailMessage) bool {
+					return msg.To == defaultEmail
+				})).Return(nil)
+			},
+		},
+		{
+			name: "unknown email is not reported",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(nil, apperror.UserNotFoundErr)
+			},
+		},
+		{
+			name: "already verified",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(&model.User{ID: int64(defaultUserId), EmailVerifiedAt: &verifiedAt}, nil)
+			},
+		},
+		{
+			name: "repo error is only logged",
+			setupMocks: func(repo *MockUserRepository, _ *mocks.MockOneTimeTokenCache, _ *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(nil, customErr)
+			},
+		},
+		{
+			name: "mailer error is only logged",
+			setupMocks: func(repo *MockUserRepository, tokens *mocks.MockOneTimeTokenCache, mailer *mocks.MockMailer) {
+				repo.On("Get", mock.Anything, defaultEmail).Return(&model.User{ID: int64(defaultUserId), Email: defaultEmail}, nil)
+				tokens.On("Save", mock.Anything, int64(defaultUserId), mock.Anything, time.Hour).Return(nil)
+				mailer.On("Send", mock.Anything, mock.Anything).Return(customErr)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := new(MockUserRepository)
+			tokens := new(mocks.MockOneTimeTokenCache)
+			mailer := new(mocks.MockMailer)
+			tc.setupMocks(repo, tokens, mailer)
+
+			uc := &Usecase{
+				Rep:         repo,
+				EmailTokens: tokens,
+				Mailer:      mailer,
+				Auth:        config.Auth{EmailVerificationTTL: time.Hour},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			uc.ResendVerification(ctx, defaultEmail)
+			cancel()
+			uc.background.Wait()
+
+			repo.AssertExpectations(t)
+			tokens.AssertExpectations(t)
+			mailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTokenLink(t *testing.T) {
+	assert.Equal(t, "token", tokenLink("", "token"))
+	assert.Equal(t, "https://app.test/verify?lang=ru&token=abc", tokenLink("https://app.test/verify?lang=ru", "abc"))
+}
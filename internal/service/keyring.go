@@ -0,0 +1,172 @@
+package service
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SigningKey Ключ из связки. У ключей, оставленных только для проверки, PrivateKey == nil
+type SigningKey struct {
+	ID         string
+	Algorithm  string // RS256, ES256 или EdDSA, токен с другим alg этим ключом не проверяется
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	RetiredAt  time.Time // нулевое значение у активного ключа
+}
+
+// KeyRing Связка ключей: один активный для подписи и старые ключи для проверки,
+// пока не истекут все подписанные ими токены (retention после вывода из ротации)
+type KeyRing struct {
+	mu        sync.RWMutex
+	active    *SigningKey
+	keys      map[string]*SigningKey
+	retention time.Duration
+}
+
+func NewKeyRing(active *SigningKey, retention time.Duration) (*KeyRing, error) {
+	if err := validateSigningKey(active); err != nil {
+		return nil, err
+	}
+
+	return newKeyRing(active, retention), nil
+}
+
+func newKeyRing(active *SigningKey, retention time.Duration) *KeyRing {
+	return &KeyRing{
+		active:    active,
+		keys:      map[string]*SigningKey{active.ID: active},
+		retention: retention,
+	}
+}
+
+// AddRetired Добавляет ключ, который используется только для проверки подписи
+func (k *KeyRing) AddRetired(key *SigningKey) error {
+	if key == nil || key.PublicKey == nil {
+		return errors.New("retired key must contain public key")
+	}
+
+	if key.ID == "" {
+		return errors.New("key id is empty")
+	}
+
+	if err := ValidateKeyAlgorithm(key.Algorithm, key.PublicKey); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.keys[key.ID]; ok {
+		return fmt.Errorf("duplicate key id: %s", key.ID)
+	}
+
+	retired := *key
+	retired.PrivateKey = nil
+	if retired.RetiredAt.IsZero() {
+		retired.RetiredAt = time.Now()
+	}
+
+	k.keys[retired.ID] = &retired
+	return nil
+}
+
+// Rotate Делает новый ключ активным, а прежний оставляет только для проверки
+func (k *KeyRing) Rotate(next *SigningKey) error {
+	if err := validateSigningKey(next); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.keys[next.ID]; ok {
+		return fmt.Errorf("duplicate key id: %s", next.ID)
+	}
+
+	prev := *k.active
+	prev.PrivateKey = nil
+	prev.RetiredAt = time.Now()
+
+	k.keys[prev.ID] = &prev
+	k.keys[next.ID] = next
+	k.active = next
+	k.pruneLocked(time.Now())
+	return nil
+}
+
+func (k *KeyRing) Active() *SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// VerificationKey Ищет ключ по kid. Токены без kid (выпущенные до связки) проверяем активным ключом
+func (k *KeyRing) VerificationKey(kid string) (*SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" {
+		return k.active, nil
+	}
+
+	key, ok := k.keys[kid]
+	if !ok || k.expired(key, time.Now()) {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+
+	return key, nil
+}
+
+// PublicKeys Все ключи, которыми еще можно проверить токен. Активный идет первым
+func (k *KeyRing) PublicKeys() []*SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]*SigningKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		if key == k.active || k.expired(key, now) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	// Более свежие ключи выше
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].RetiredAt.After(keys[j].RetiredAt)
+	})
+
+	return append([]*SigningKey{k.active}, keys...)
+}
+
+func (k *KeyRing) expired(key *SigningKey, now time.Time) bool {
+	if key == k.active || key.RetiredAt.IsZero() {
+		return false
+	}
+
+	return now.After(key.RetiredAt.Add(k.retention))
+}
+
+func (k *KeyRing) pruneLocked(now time.Time) {
+	for id, key := range k.keys {
+		if k.expired(key, now) {
+			delete(k.keys, id)
+		}
+	}
+}
+
+func validateSigningKey(key *SigningKey) error {
+	if key == nil || key.PublicKey == nil {
+		return errors.New("signing key must contain public key")
+	}
+
+	if key.ID == "" {
+		return errors.New("key id is empty")
+	}
+
+	return ValidateKeyAlgorithm(key.Algorithm, key.PublicKey)
+}
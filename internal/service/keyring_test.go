@@ -0,0 +1,117 @@
+package service
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestSigningKey(t *testing.T, id string) *SigningKey {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &SigningKey{ID: id, Algorithm: AlgorithmRS256, PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}
+}
+
+func TestKeyRing_Rotate(t *testing.T) {
+	first := newTestSigningKey(t, "first")
+	second := newTestSigningKey(t, "second")
+
+	ring, err := NewKeyRing(first, time.Minute)
+	require.NoError(t, err)
+	tokenSvc := NewTokenServiceWithKeyRing(ring, time.Minute, TokenOptions{})
+
+	// Токен, выпущенный до ротации
+	oldToken, err := tokenSvc.GenerateAccessToken(initTestUser(), testSessionID)
+	require.NoError(t, err)
+
+	require.NoError(t, ring.Rotate(second))
+	assert.Equal(t, "second", ring.Active().ID)
+	assert.Nil(t, ring.keys["first"].PrivateKey, "retired key must not keep private key")
+
+	// Старый токен все еще проходит проверку
+	claims, err := tokenSvc.ParseToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.Subject)
+
+	// Новый токен подписан новым ключом
+	newToken, err := tokenSvc.GenerateAccessToken(initTestUser(), testSessionID)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &jwt.RegisteredClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "second", parsed.Header["kid"])
+
+	// Повторно тот же kid использовать нельзя
+	assert.Error(t, ring.Rotate(newTestSigningKey(t, "first")))
+}
+
+func TestKeyRing_RetiredKeyAgesOut(t *testing.T) {
+	active := newTestSigningKey(t, "active")
+	retired := newTestSigningKey(t, "retired")
+
+	ring, err := NewKeyRing(active, time.Minute)
+	require.NoError(t, err)
+
+	// Выведен из ротации давно, все его токены уже истекли
+	require.NoError(t, ring.AddRetired(&SigningKey{
+		ID:        retired.ID,
+		Algorithm: AlgorithmRS256,
+		PublicKey: retired.PublicKey,
+		RetiredAt: time.Now().Add(-time.Hour),
+	}))
+
+	_, err = ring.VerificationKey("retired")
+	assert.ErrorContains(t, err, "unknown key id")
+
+	keys := ring.PublicKeys()
+	require.Len(t, keys, 1)
+	assert.Equal(t, "active", keys[0].ID)
+}
+
+func TestKeyRing_VerificationKey(t *testing.T) {
+	active := newTestSigningKey(t, "active")
+	retired := newTestSigningKey(t, "retired")
+
+	ring, err := NewKeyRing(active, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, ring.AddRetired(retired))
+
+	tests := []struct {
+		name    string
+		kid     string
+		wantKey crypto.PublicKey
+		wantErr bool
+	}{
+		{name: "active key", kid: "active", wantKey: active.PublicKey},
+		{name: "retired key", kid: "retired", wantKey: retired.PublicKey},
+		{name: "token without kid", kid: "", wantKey: active.PublicKey},
+		{name: "unknown kid", kid: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, keyErr := ring.VerificationKey(tt.kid)
+			if tt.wantErr {
+				assert.Error(t, keyErr)
+				return
+			}
+
+			require.NoError(t, keyErr)
+			assert.Equal(t, tt.wantKey, key.PublicKey)
+		})
+	}
+}
+
+func TestNewKeyRing_Invalid(t *testing.T) {
+	_, err := NewKeyRing(nil, time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewKeyRing(&SigningKey{ID: "", PublicKey: newTestSigningKey(t, "x").PublicKey}, time.Minute)
+	assert.EqualError(t, err, "key id is empty")
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+)
+
+// Поддерживаемые алгоритмы подписи access-токенов
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// AlgorithmForKey Алгоритм по умолчанию для типа ключа
+func AlgorithmForKey(publicKey crypto.PublicKey) (string, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return AlgorithmRS256, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported ecdsa curve: %s", key.Curve.Params().Name)
+		}
+		return AlgorithmES256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+}
+
+// ValidateKeyAlgorithm Проверяет, что ключ подходит под алгоритм, иначе подпись упадет только при выпуске токена
+func ValidateKeyAlgorithm(algorithm string, publicKey crypto.PublicKey) error {
+	keyAlg, err := AlgorithmForKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	if keyAlg != algorithm {
+		return fmt.Errorf("key of type %T cannot be used with %s", publicKey, algorithm)
+	}
+
+	return nil
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgorithmES256:
+		return jwt.SigningMethodES256, nil
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+}
+
+// KeyThumbprint Вычисляет kid как JWK thumbprint по RFC 7638
+func KeyThumbprint(publicKey crypto.PublicKey) string {
+	var members any
+
+	// Порядок полей важен: лексикографический, без пробелов
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{E: encodeBigInt(big.NewInt(int64(key.E))), Kty: "RSA", N: encodeBigInt(key.N)}
+	case *ecdsa.PublicKey:
+		x, y := encodeECPoint(key)
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{Crv: key.Curve.Params().Name, Kty: "EC", X: x, Y: y}
+	case ed25519.PublicKey:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{Crv: "Ed25519", Kty: "OKP", X: base64.RawURLEncoding.EncodeToString(key)}
+	default:
+		return ""
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func publicJWK(key *SigningKey) model.JWK {
+	jwk := model.JWK{
+		Use: "sig",
+		Alg: key.Algorithm,
+		Kid: key.ID,
+	}
+
+	switch pub := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeBigInt(pub.N)
+		jwk.E = encodeBigInt(big.NewInt(int64(pub.E)))
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X, jwk.Y = encodeECPoint(pub)
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+// encodeECPoint Координаты дополняются нулями до размера кривой (RFC 7518, 6.2.1.2)
+func encodeECPoint(key *ecdsa.PublicKey) (string, string) {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	x := key.X.FillBytes(make([]byte, size))
+	y := key.Y.FillBytes(make([]byte, size))
+	return base64.RawURLEncoding.EncodeToString(x), base64.RawURLEncoding.EncodeToString(y)
+}
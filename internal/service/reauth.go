@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/cache"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+)
+
+// Reauthenticator Подтверждение текущим паролем перед опасными действиями: смена пароля и email, удаление аккаунта, MFA.
+// Попытки учитываются тем же счетчиком, что и вход, иначе украденный access токен дает неограниченный перебор пароля
+type Reauthenticator struct {
+	LoginThrottle  cache.LoginThrottle
+	PasswordPolicy validator.PasswordPolicy
+}
+
+func NewReauthenticator(loginThrottle cache.LoginThrottle, passwordPolicy validator.PasswordPolicy) *Reauthenticator {
+	return &Reauthenticator{LoginThrottle: loginThrottle, PasswordPolicy: passwordPolicy}
+}
+
+// Verify secondFactor может быть nil. Его apperror.InvalidMFACodeErr считается неудачной попыткой, как и неверный пароль.
+// При блокировке возвращает *apperror.RetryAfterError
+func (r *Reauthenticator) Verify(ctx context.Context, user *model.User, password, clientIP string, secondFactor func(ctx context.Context) error) error {
+	retryAfter, err := r.LoginThrottle.Check(ctx, user.Email, clientIP)
+	if err != nil {
+		return err
+	}
+
+	if retryAfter > 0 {
+		return &apperror.RetryAfterError{Err: apperror.TooManyLoginAttemptsErr, RetryAfter: retryAfter}
+	}
+
+	// Пароль длиннее политики установить нельзя, поэтому не хешируем его
+	if r.PasswordPolicy.TooLong(password) || hasher.Verify(user.Password, password) != nil {
+		r.registerFailure(ctx, user, clientIP)
+		return apperror.WrongPasswordErr
+	}
+
+	if secondFactor != nil {
+		err = secondFactor(ctx)
+		if errors.Is(err, apperror.InvalidMFACodeErr) {
+			r.registerFailure(ctx, user, clientIP)
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = r.LoginThrottle.Reset(ctx, user.Email); err != nil {
+		LoggerFromContext(ctx).Error("failed to reset login throttle", "error", err, "user_id", user.ID)
+	}
+
+	return nil
+}
+
+// registerFailure Ошибка счетчика не должна менять ответ, поэтому только логируем
+func (r *Reauthenticator) registerFailure(ctx context.Context, user *model.User, clientIP string) {
+	lgr := LoggerFromContext(ctx)
+	lgr.Warn("reauthentication failed", "event", "reauth_failed", "user_id", user.ID, "ip", clientIP)
+
+	lockout, err := r.LoginThrottle.RegisterFailure(ctx, user.Email, clientIP)
+	if err != nil {
+		lgr.Error("failed to register login failure", "error", err)
+		return
+	}
+
+	if lockout > 0 {
+		lgr.Warn("security event: login locked out", "event", "login_lockout", "user_id", user.ID, "ip", clientIP, "lockout", lockout)
+	}
+}
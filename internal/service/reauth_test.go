@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Elaman1/full-project-mock/internal/domain/apperror"
+	"github.com/Elaman1/full-project-mock/internal/domain/model"
+	"github.com/Elaman1/full-project-mock/internal/mocks"
+	"github.com/Elaman1/full-project-mock/pkg/hasher"
+	"github.com/Elaman1/full-project-mock/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReauthenticatorVerify(t *testing.T) {
+	const (
+		password = "Secret-Pass1"
+		email    = "user@test.com"
+		clientIP = "10.0.0.1"
+	)
+
+	hashed, err := hasher.HashPassword(password)
+	require.NoError(t, err)
+	user := &model.User{ID: 7, Email: email, Password: hashed}
+
+	// Хеш совпадает, но такой пароль нельзя установить: до argon2 не доходим
+	longPassword := strings.Repeat("a", validator.DefaultMaxPasswordLength+1)
+	longHashed, err := hasher.HashPassword(longPassword)
+	require.NoError(t, err)
+	longUser := &model.User{ID: 7, Email: email, Password: longHashed}
+
+	customErr := errors.New("custom error")
+
+	tests := []struct {
+		name         string
+		user         *model.User
+		password     string
+		secondFactor func(ctx context.Context) error
+		setup        func(th *mocks.MockLoginThrottle)
+		wantErr      error
+	}{
+		{
+			name:     "success resets counter",
+			user:     user,
+			password: password,
+			setup: func(th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, email, clientIP).Return(time.Duration(0), nil)
+				th.On("Reset", mock.Anything, email).Return(nil)
+			},
+		},
+		{
+			name:     "locked out before password check",
+			user:     user,
+			password: password,
+			setup: func(th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, email, clientIP).Return(90*time.Second, nil)
+			},
+			wantErr: apperror.TooManyLoginAttemptsErr,
+		},
+		{
+			name:     "wrong password is counted",
+			user:     user,
+			password: "wrong-password",
+			setup: func(th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, email, clientIP).Return(time.Duration(0), nil)
+				th.On("RegisterFailure", mock.Anything, email, clientIP).Return(time.Minute, nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:     "password longer than policy is counted",
+			user:     longUser,
+			password: longPassword,
+			setup: func(th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, email, clientIP).Return(time.Duration(0), nil)
+				th.On("RegisterFailure", mock.Anything, email, clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.WrongPasswordErr,
+		},
+		{
+			name:         "wrong second factor is counted",
+			user:         user,
+			password:     password,
+			secondFactor: func(context.Context) error { return apperror.InvalidMFACodeErr },
+			setup: func(th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, email, clientIP).Return(time.Duration(0), nil)
+				th.On("RegisterFailure", mock.Anything, email, clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.InvalidMFACodeErr,
+		},
+		{
+			name:         "second factor error is not counted",
+			user:         user,
+			password:     password,
+			secondFactor: func(context.Context) error { return apperror.MFANotEnabledErr },
+			setup: func(th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, email, clientIP).Return(time.Duration(0), nil)
+			},
+			wantErr: apperror.MFANotEnabledErr,
+		},
+		{
+			name:     "check error",
+			user:     user,
+			password: password,
+			setup: func(th *mocks.MockLoginThrottle) {
+				th.On("Check", mock.Anything, email, clientIP).Return(time.Duration(0), customErr)
+			},
+			wantErr: customErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := new(mocks.MockLoginThrottle)
+			tt.setup(throttle)
+
+			err := NewReauthenticator(throttle, validator.PasswordPolicy{}).Verify(context.Background(), tt.user, tt.password, clientIP, tt.secondFactor)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			throttle.AssertExpectations(t)
+		})
+	}
+
+	t.Run("lockout carries retry after", func(t *testing.T) {
+		throttle := new(mocks.MockLoginThrottle)
+		throttle.On("Check", mock.Anything, email, clientIP).Return(90*time.Second, nil)
+
+		err := NewReauthenticator(throttle, validator.PasswordPolicy{}).Verify(context.Background(), user, password, clientIP, nil)
+		assert.Equal(t, 90*time.Second, apperror.RetryAfter(err))
+	})
+}
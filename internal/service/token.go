@@ -1,8 +1,8 @@
 package service
 
 import (
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -10,37 +10,126 @@ import (
 	"github.com/Elaman1/full-project-mock/internal/domain/usecase"
 	"github.com/golang-jwt/jwt/v5"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type TokenService struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
-	accessTTL  time.Duration
+	keys      *KeyRing
+	accessTTL time.Duration
+	opts      TokenOptions
+	parser    *jwt.Parser
 }
 
-func NewTokenService(publicKey *rsa.PublicKey, privateKey *rsa.PrivateKey, ttl time.Duration) usecase.TokenService {
+// TokenOptions Необязательные параметры выпуска и проверки токенов
+type TokenOptions struct {
+	Issuer   string        // iss, при непустом значении проверяется в ParseToken
+	Audience []string      // aud, токен должен содержать хотя бы одно значение из списка
+	Leeway   time.Duration // допустимое расхождение часов для exp/nbf/iat
+}
+
+// NewTokenService Сервис с одним ключом, kid и алгоритм определяются по публичному ключу
+func NewTokenService(publicKey crypto.PublicKey, privateKey crypto.Signer, ttl time.Duration) usecase.TokenService {
+	algorithm, _ := AlgorithmForKey(publicKey)
+	active := &SigningKey{
+		ID:         KeyThumbprint(publicKey),
+		Algorithm:  algorithm,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+	}
+
+	return NewTokenServiceWithKeyRing(newKeyRing(active, ttl), ttl, TokenOptions{})
+}
+
+func NewTokenServiceWithKeyRing(keys *KeyRing, ttl time.Duration, opts TokenOptions) usecase.TokenService {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithLeeway(opts.Leeway),
+		jwt.WithIssuedAt(),
+	}
+
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+
 	return &TokenService{
-		privateKey: privateKey,
-		publicKey:  publicKey,
-		accessTTL:  ttl,
+		keys:      keys,
+		accessTTL: ttl,
+		opts:      opts,
+		parser:    jwt.NewParser(parserOpts...),
 	}
 }
 
-func (s *TokenService) GenerateAccessToken(user *model.User) (string, error) {
+func (s *TokenService) GenerateAccessToken(user *model.User, sessionID string) (string, error) {
 	if user == nil {
 		return "", errors.New("user is nil")
 	}
 
-	claims := jwt.RegisteredClaims{
-		Subject:   strconv.Itoa(int(user.ID)),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTTL)),
+	now := time.Now()
+	claims := model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(), // jti, по нему токен можно отозвать
+			Issuer:    s.opts.Issuer,
+			Subject:   strconv.Itoa(int(user.ID)),
+			Audience:  s.opts.Audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+		Role:       user.Role.Code,
+		SessionID:  sessionID,
+		Email:      user.Email,
+		IssuedAtMs: now.UnixMilli(),
+	}
+
+	return s.sign(claims)
+}
+
+func (s *TokenService) GenerateClientToken(clientID string, scopes []string) (string, time.Duration, error) {
+	if clientID == "" {
+		return "", 0, errors.New("client id is empty")
+	}
+
+	now := time.Now()
+	claims := model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    s.opts.Issuer,
+			Subject:   clientID,
+			Audience:  s.opts.Audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+		ClientID:   clientID,
+		Scope:      strings.Join(scopes, " "),
+		IssuedAtMs: now.UnixMilli(),
+	}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return token, s.accessTTL, nil
+}
+
+// sign Подписывает активным ключом, kid в заголовке нужен для проверки после ротации
+func (s *TokenService) sign(claims model.AccessClaims) (string, error) {
+	active := s.keys.Active()
+	if active.PrivateKey == nil {
+		return "", errors.New("active key has no private key")
+	}
+
+	method, err := signingMethod(active.Algorithm)
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	return token.SignedString(s.privateKey)
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.PrivateKey)
 }
 
 func (s *TokenService) GenerateRefreshToken() (tokenID, plainToken string, err error) {
@@ -54,26 +143,65 @@ func (s *TokenService) GenerateRefreshToken() (tokenID, plainToken string, err e
 	return
 }
 
-func (s *TokenService) ParseToken(tokenStr string) (jwt.RegisteredClaims, error) {
-	claims := &jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+func (s *TokenService) ParseToken(tokenStr string) (model.AccessClaims, error) {
+	claims := &model.AccessClaims{}
+	token, err := s.parser.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// Принимаем только алгоритм ключа, иначе возможна подмена alg
+		if token.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return s.publicKey, nil
+
+		return key.PublicKey, nil
 	})
 
 	if err != nil {
-		return jwt.RegisteredClaims{}, fmt.Errorf("failed to parse token: %w", err)
+		return model.AccessClaims{}, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if !token.Valid {
-		return jwt.RegisteredClaims{}, fmt.Errorf("token is invalid")
+		return model.AccessClaims{}, fmt.Errorf("token is invalid")
 	}
 
 	if claims.ExpiresAt == nil {
-		return jwt.RegisteredClaims{}, errors.New("expired at missing")
+		return model.AccessClaims{}, errors.New("expired at missing")
+	}
+
+	if !s.audienceAllowed(claims.Audience) {
+		return model.AccessClaims{}, errors.New("token has invalid audience")
 	}
 
 	return *claims, nil
 }
+
+func (s *TokenService) audienceAllowed(aud jwt.ClaimStrings) bool {
+	if len(s.opts.Audience) == 0 {
+		return true
+	}
+
+	for _, expected := range s.opts.Audience {
+		for _, got := range aud {
+			if got == expected {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// JWKS Публичные ключи для проверки токенов сторонними сервисами
+func (s *TokenService) JWKS() model.JWKSet {
+	keys := s.keys.PublicKeys()
+	set := model.JWKSet{Keys: make([]model.JWK, 0, len(keys))}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, publicJWK(key))
+	}
+
+	return set
+}
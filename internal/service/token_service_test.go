@@ -1,6 +1,10 @@
 package service
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"github.com/Elaman1/full-project-mock/internal/domain/model"
@@ -20,6 +24,12 @@ func generateTestKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PublicKey) {
 	return privateKey, &privateKey.PublicKey
 }
 
+const testSessionID = "test-session-id"
+
+func initTestUser() *model.User {
+	return &model.User{ID: 42, Email: "user@test.com", Role: model.UserRole{Code: "user"}}
+}
+
 func TestTokenService_ParseToken(t *testing.T) {
 	privateKey, publicKey := generateTestKeys(t)
 	tokenSvc := NewTokenService(publicKey, privateKey, time.Minute)
@@ -145,7 +155,7 @@ func TestTokenService_GenerateAccessToken_NilUser(t *testing.T) {
 	_, publicKey := generateTestKeys(t)
 	svc := NewTokenService(publicKey, nil, time.Minute)
 
-	token, err := svc.GenerateAccessToken(nil)
+	token, err := svc.GenerateAccessToken(nil, testSessionID)
 	assert.Empty(t, token)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "user is nil")
@@ -181,7 +191,7 @@ func TestTokenService_GenerateAccessToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tokenStr, err := tokenSvc.GenerateAccessToken(tt.user)
+			tokenStr, err := tokenSvc.GenerateAccessToken(tt.user, testSessionID)
 
 			if tt.expectErr {
 				assert.Error(t, err)
@@ -203,6 +213,14 @@ func TestTokenService_GenerateAccessToken(t *testing.T) {
 			require.True(t, ok)
 
 			assert.Equal(t, "42", claims.Subject)
+			assert.NotEmpty(t, claims.ID, "jti is required for revocation")
+			require.NotNil(t, claims.IssuedAt)
+
+			// iat_ms нужен denylist, чтобы отличить токены, выпущенные в одну секунду с отзывом
+			accessClaims, err := tokenSvc.ParseToken(tokenStr)
+			require.NoError(t, err)
+			require.NotZero(t, accessClaims.IssuedAtMs)
+			assert.Equal(t, claims.IssuedAt.Unix(), accessClaims.IssuedTime().Unix())
 
 			// Допуск ±2 секунды
 			expectedExp := now.Add(time.Minute)
@@ -212,6 +230,29 @@ func TestTokenService_GenerateAccessToken(t *testing.T) {
 	}
 }
 
+func TestTokenService_GenerateClientToken(t *testing.T) {
+	privateKey, publicKey := generateTestKeys(t)
+	tokenSvc := NewTokenService(publicKey, privateKey, time.Minute)
+
+	tokenStr, expiresIn, err := tokenSvc.GenerateClientToken("billing", []string{"users:read", "audit:read"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, expiresIn)
+
+	claims, err := tokenSvc.ParseToken(tokenStr)
+	require.NoError(t, err)
+
+	// Токен сервиса отличается от пользовательского: есть client_id и scope, нет роли и сессии
+	assert.Equal(t, "billing", claims.Subject)
+	assert.Equal(t, "billing", claims.ClientID)
+	assert.Equal(t, "users:read audit:read", claims.Scope)
+	assert.Empty(t, claims.Role)
+	assert.Empty(t, claims.SessionID)
+	assert.NotEmpty(t, claims.ID)
+
+	_, _, err = tokenSvc.GenerateClientToken("", nil)
+	assert.Error(t, err)
+}
+
 func TestTokenService_GenerateRefreshToken(t *testing.T) {
 	privateKey, publicKey := generateTestKeys(t)
 	tokenSvc := NewTokenService(publicKey, privateKey, time.Minute)
@@ -229,3 +270,208 @@ func TestTokenService_GenerateRefreshToken(t *testing.T) {
 	// Проверка base64 длины (32 байта → 43-44 символа без паддинга)
 	assert.GreaterOrEqual(t, len(plainToken), 43)
 }
+
+func TestTokenService_GenerateAccessToken_KeyID(t *testing.T) {
+	privateKey, publicKey := generateTestKeys(t)
+	tokenSvc := NewTokenService(publicKey, privateKey, time.Minute)
+
+	tokenStr, err := tokenSvc.GenerateAccessToken(initTestUser(), testSessionID)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(tokenStr, &jwt.RegisteredClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, KeyThumbprint(publicKey), parsed.Header["kid"])
+}
+
+func TestTokenService_ParseToken_UnknownKeyID(t *testing.T) {
+	privateKey, publicKey := generateTestKeys(t)
+	tokenSvc := NewTokenService(publicKey, privateKey, time.Minute)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	token.Header["kid"] = "unknown"
+	tokenStr, err := token.SignedString(privateKey)
+	require.NoError(t, err)
+
+	_, err = tokenSvc.ParseToken(tokenStr)
+	assert.ErrorContains(t, err, "unknown key id")
+}
+
+func TestTokenService_JWKS(t *testing.T) {
+	privateKey, publicKey := generateTestKeys(t)
+	tokenSvc := NewTokenService(publicKey, privateKey, time.Minute)
+
+	set := tokenSvc.JWKS()
+	require.Len(t, set.Keys, 1)
+
+	jwk := set.Keys[0]
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, "sig", jwk.Use)
+	assert.Equal(t, "RS256", jwk.Alg)
+	assert.Equal(t, KeyThumbprint(publicKey), jwk.Kid)
+	assert.Equal(t, "AQAB", jwk.E) // 65537
+	assert.NotEmpty(t, jwk.N)
+}
+
+func TestTokenService_Claims(t *testing.T) {
+	privateKey, publicKey := generateTestKeys(t)
+	active := &SigningKey{ID: "kid", Algorithm: AlgorithmRS256, PrivateKey: privateKey, PublicKey: publicKey}
+	opts := TokenOptions{Issuer: "auth-service", Audience: []string{"api"}, Leeway: 30 * time.Second}
+	tokenSvc := NewTokenServiceWithKeyRing(newKeyRing(active, time.Minute), time.Minute, opts)
+
+	tokenStr, err := tokenSvc.GenerateAccessToken(initTestUser(), testSessionID)
+	require.NoError(t, err)
+
+	claims, err := tokenSvc.ParseToken(tokenStr)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.Subject)
+	assert.Equal(t, "auth-service", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"api"}, claims.Audience)
+	assert.Equal(t, "user", claims.Role)
+	assert.Equal(t, testSessionID, claims.SessionID)
+	assert.Equal(t, "user@test.com", claims.Email)
+	assert.NotNil(t, claims.NotBefore)
+
+	sign := func(t *testing.T, claims model.AccessClaims) string {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "kid"
+		signed, signErr := token.SignedString(privateKey)
+		require.NoError(t, signErr)
+		return signed
+	}
+
+	now := time.Now()
+	base := func() model.AccessClaims {
+		return model.AccessClaims{RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "auth-service",
+			Audience:  jwt.ClaimStrings{"api"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(c *model.AccessClaims)
+		expectErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *model.AccessClaims) {},
+		},
+		{
+			name:      "wrong issuer",
+			modify:    func(c *model.AccessClaims) { c.Issuer = "other" },
+			expectErr: "failed to parse token",
+		},
+		{
+			name:      "wrong audience",
+			modify:    func(c *model.AccessClaims) { c.Audience = jwt.ClaimStrings{"other"} },
+			expectErr: "invalid audience",
+		},
+		{
+			name:   "expired within leeway",
+			modify: func(c *model.AccessClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second)) },
+		},
+		{
+			name:      "expired beyond leeway",
+			modify:    func(c *model.AccessClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) },
+			expectErr: "token is expired",
+		},
+		{
+			name:      "not valid yet",
+			modify:    func(c *model.AccessClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) },
+			expectErr: "token is not valid yet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := base()
+			tt.modify(&c)
+
+			_, parseErr := tokenSvc.ParseToken(sign(t, c))
+			if tt.expectErr == "" {
+				assert.NoError(t, parseErr)
+				return
+			}
+
+			assert.ErrorContains(t, parseErr, tt.expectErr)
+		})
+	}
+}
+
+func TestTokenService_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		signer  crypto.Signer
+		wantAlg string
+		wantKty string
+	}{
+		{name: "rsa", signer: rsaKey, wantAlg: "RS256", wantKty: "RSA"},
+		{name: "ecdsa p-256", signer: ecKey, wantAlg: "ES256", wantKty: "EC"},
+		{name: "ed25519", signer: edKey, wantAlg: "EdDSA", wantKty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenSvc := NewTokenService(tt.signer.Public(), tt.signer, time.Minute)
+
+			tokenStr, genErr := tokenSvc.GenerateAccessToken(initTestUser(), testSessionID)
+			require.NoError(t, genErr)
+
+			parsed, _, parseErr := jwt.NewParser().ParseUnverified(tokenStr, &model.AccessClaims{})
+			require.NoError(t, parseErr)
+			assert.Equal(t, tt.wantAlg, parsed.Header["alg"])
+
+			claims, parseErr := tokenSvc.ParseToken(tokenStr)
+			require.NoError(t, parseErr)
+			assert.Equal(t, "42", claims.Subject)
+
+			set := tokenSvc.JWKS()
+			require.Len(t, set.Keys, 1)
+			assert.Equal(t, tt.wantKty, set.Keys[0].Kty)
+			assert.Equal(t, tt.wantAlg, set.Keys[0].Alg)
+		})
+	}
+}
+
+func TestTokenService_ParseToken_RejectsOtherAlgorithm(t *testing.T) {
+	// Сервис настроен на ES256, токен подписан RSA-ключом с тем же kid
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tokenSvc := NewTokenService(ecKey.Public(), ecKey, time.Minute)
+
+	rsaKey, _ := generateTestKeys(t)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	token.Header["kid"] = KeyThumbprint(ecKey.Public())
+	tokenStr, err := token.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	_, err = tokenSvc.ParseToken(tokenStr)
+	assert.ErrorContains(t, err, "unexpected signing method")
+}
+
+func TestValidateKeyAlgorithm(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, rsaPub := generateTestKeys(t)
+
+	assert.NoError(t, ValidateKeyAlgorithm(AlgorithmRS256, rsaPub))
+	assert.Error(t, ValidateKeyAlgorithm(AlgorithmES256, rsaPub))
+	assert.ErrorContains(t, ValidateKeyAlgorithm(AlgorithmES256, ecKey.Public()), "unsupported ecdsa curve")
+}
@@ -0,0 +1,36 @@
+create table permissions
+(
+    id         serial
+        primary key,
+    code       text not null
+        unique,
+    name       text not null,
+    created_at timestamp default now()
+);
+
+alter table permissions
+    owner to postgres;
+
+create table role_permissions
+(
+    role_id       integer not null
+        references roles
+            on delete cascade,
+    permission_id integer not null
+        references permissions
+            on delete cascade,
+    primary key (role_id, permission_id)
+);
+
+alter table role_permissions
+    owner to postgres;
+
+insert into permissions (code, name)
+values ('users:read', 'Просмотр пользователей'),
+       ('users:manage', 'Управление пользователями'),
+       ('audit:read', 'Просмотр журнала аудита');
+
+-- Админу выдаем все права
+insert into role_permissions (role_id, permission_id)
+select 1, id
+from permissions;
@@ -0,0 +1,2 @@
+alter table users
+    add column email_verified_at timestamp;
@@ -0,0 +1,5 @@
+drop table if exists mfa_recovery_codes;
+
+alter table users
+    drop column mfa_enabled_at,
+    drop column mfa_secret;
@@ -0,0 +1,21 @@
+alter table users
+    add column mfa_secret     text,
+    add column mfa_enabled_at timestamp;
+
+create table mfa_recovery_codes
+(
+    id         serial
+        primary key,
+    user_id    integer not null
+        references users
+            on delete cascade,
+    code_hash  text    not null,
+    used_at    timestamp,
+    created_at timestamp default now()
+);
+
+alter table mfa_recovery_codes
+    owner to postgres;
+
+create index mfa_recovery_codes_user_id_idx
+    on mfa_recovery_codes (user_id);
@@ -0,0 +1,11 @@
+drop index if exists logs_action_created_at_idx;
+drop index if exists logs_user_id_created_at_idx;
+
+delete from logs where user_id is null;
+
+alter table logs
+    drop column metadata,
+    drop column trace_id,
+    drop column user_agent,
+    drop column ip,
+    alter column user_id set not null;
@@ -0,0 +1,13 @@
+-- Для неудачного входа с несуществующим email пользователя нет
+alter table logs
+    alter column user_id drop not null,
+    add column ip         text,
+    add column user_agent text,
+    add column trace_id   text,
+    add column metadata   jsonb not null default '{}';
+
+create index logs_user_id_created_at_idx
+    on logs (user_id, created_at);
+
+create index logs_action_created_at_idx
+    on logs (action, created_at);
@@ -0,0 +1 @@
+drop index if exists logs_ip_created_at_idx;
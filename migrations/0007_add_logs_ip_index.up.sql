@@ -0,0 +1,4 @@
+-- Поиск по IP в журнале аудита (/admin/audit?ip=)
+create index logs_ip_created_at_idx
+    on logs (ip, created_at)
+    where ip is not null;
@@ -0,0 +1,20 @@
+create table user_identities
+(
+    id         serial
+        primary key,
+    user_id    integer not null
+        references users
+            on delete cascade,
+    provider   text    not null,
+    subject    text    not null,
+    email      text,
+    created_at timestamp default now(),
+    constraint user_identities_provider_subject_key
+        unique (provider, subject)
+);
+
+alter table user_identities
+    owner to postgres;
+
+create index user_identities_user_id_idx
+    on user_identities (user_id);
@@ -0,0 +1,22 @@
+create table oauth_clients
+(
+    id          serial
+        primary key,
+    client_id   text                    not null
+        unique,
+    secret_hash varchar(64)             not null,
+    name        text                    not null,
+    scopes      text[]    default '{}'  not null,
+    disabled    boolean   default false not null,
+    created_at  timestamp default now()
+);
+
+alter table oauth_clients
+    owner to postgres;
+
+-- Клиенты заводятся вручную. Секрет - случайная строка не короче 32 байт, в таблицу попадает только SHA-256 (hex):
+--   secret=$(openssl rand -hex 32)
+--   printf '%s' "$secret" | sha256sum
+-- insert into oauth_clients (client_id, secret_hash, name, scopes)
+-- values ('billing', '<sha256 секрета>', 'Billing', '{users:read}');
+-- Шлюзу для /oauth/introspect нужен scope tokens:introspect, для отзыва токенов пользователей - tokens:revoke
@@ -0,0 +1,23 @@
+create table api_keys
+(
+    id           serial
+        primary key,
+    user_id      integer               not null
+        references users
+            on delete cascade,
+    name         text                  not null,
+    prefix       text                  not null,
+    key_hash     varchar(64)           not null
+        unique,
+    scopes       text[]  default '{}'  not null,
+    expires_at   timestamp,
+    last_used_at timestamp,
+    revoked_at   timestamp,
+    created_at   timestamp default now()
+);
+
+alter table api_keys
+    owner to postgres;
+
+create index api_keys_user_id_idx
+    on api_keys (user_id);
@@ -0,0 +1,4 @@
+drop index users_deleted_at_idx;
+
+alter table users
+    drop column deleted_at;
@@ -0,0 +1,7 @@
+-- Аккаунт, удаленный пользователем, хранится до очистки (account.deletion_grace_period)
+alter table users
+    add column deleted_at timestamp;
+
+create index users_deleted_at_idx
+    on users (deleted_at)
+    where deleted_at is not null;
@@ -0,0 +1,4 @@
+drop index users_email_lower_key;
+
+alter table users
+    drop column pending_email;
@@ -0,0 +1,7 @@
+-- Новый email из PATCH /auth/me, становится email только после подтверждения по ссылке
+alter table users
+    add column pending_email varchar(255);
+
+-- Проверка Exists перед UPDATE не защищает от гонки, поэтому email уникален и в БД
+create unique index users_email_lower_key
+    on users (lower(email));
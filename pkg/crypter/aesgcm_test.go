@@ -0,0 +1,36 @@
+package crypter
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAESGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	c, err := NewAESGCM(key)
+	require.NoError(t, err)
+
+	first, err := c.Encrypt("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	second, err := c.Encrypt("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	// Случайный nonce: одинаковый текст шифруется по-разному
+	assert.NotEqual(t, first, second)
+
+	plain, err := c.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", plain)
+
+	other, err := NewAESGCM(bytes.Repeat([]byte{8}, 32))
+	require.NoError(t, err)
+	_, err = other.Decrypt(first)
+	assert.Error(t, err)
+
+	_, err = c.Decrypt("AAAA")
+	assert.Error(t, err)
+
+	_, err = NewAESGCM([]byte("short"))
+	assert.Error(t, err)
+}
@@ -10,6 +10,9 @@ import (
 	"golang.org/x/crypto/argon2"
 )
 
+// ErrMismatchedPassword Пароль не совпадает с хешем
+var ErrMismatchedPassword = errors.New("логин или пароль неправильный")
+
 func HashPassword(password string) (string, error) {
 	salt := make([]byte, 16)
 	_, err := rand.Read(salt)
@@ -40,7 +43,7 @@ func Verify(hashedPwd, pwd string) error {
 
 	got := argon2.IDKey([]byte(pwd), salt, 1, 64*1024, 4, 32)
 	if subtle.ConstantTimeCompare(expected, got) != 1 {
-		return errors.New("логин или пароль неправильный")
+		return ErrMismatchedPassword
 	}
 	return nil
 }
@@ -0,0 +1,16 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// GenerateToken Случайный токен для ссылок из писем (base64url, без паддинга)
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,259 @@
+// Package oidc Клиент OpenID Connect для входа через внешнего провайдера:
+// authorization code flow с PKCE и проверкой ID-токена по JWKS провайдера
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResponseSize Ответы провайдера небольшие, больше не читаем
+const maxResponseSize = 1 << 20
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Config Эндпоинты, заданные явно, не запрашиваются через discovery
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // по умолчанию openid, email, profile
+	AuthURL      string
+	TokenURL     string
+	JWKSURL      string
+}
+
+// Claims Данные пользователя из проверенного ID-токена
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider struct {
+	cfg    Config
+	client *http.Client
+	keys   *keySet
+
+	mu        sync.Mutex
+	discovery *metadata
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewProvider Discovery выполняется при первом запросе, чтобы недоступный провайдер не мешал запуску сервиса
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+
+	p := &Provider{cfg: cfg, client: client}
+	p.keys = newKeySet(client, p.jwksURL)
+	return p
+}
+
+// NewPKCE Возвращает code_verifier и code_challenge (S256, RFC 7636)
+func NewPKCE() (string, string, error) {
+	verifier, err := RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomString n случайных байт в base64url, для state, nonce и code_verifier
+func RandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// AuthCodeURL Ссылка на страницу входа провайдера
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	endpoints, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(endpoints.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange Меняет code на токены и проверяет ID-токен: подпись, iss, aud, срок и nonce
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (Claims, error) {
+	endpoints, err := p.endpoints(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoints.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	status, err := p.doJSON(request, &token)
+	if err != nil {
+		return Claims{}, fmt.Errorf("token request: %w", err)
+	}
+
+	if status != http.StatusOK || token.Error != "" {
+		return Claims{}, fmt.Errorf("token request failed: status %d, %s %s", status, token.Error, token.ErrorDescription)
+	}
+
+	if token.IDToken == "" {
+		return Claims{}, errors.New("token response has no id_token")
+	}
+
+	return p.verifyIDToken(ctx, token.IDToken, endpoints.Issuer, nonce)
+}
+
+func (p *Provider) endpoints(ctx context.Context) (*metadata, error) {
+	if p.cfg.AuthURL != "" && p.cfg.TokenURL != "" && p.cfg.JWKSURL != "" {
+		return &metadata{
+			Issuer:                p.cfg.Issuer,
+			AuthorizationEndpoint: p.cfg.AuthURL,
+			TokenEndpoint:         p.cfg.TokenURL,
+			JWKSURI:               p.cfg.JWKSURL,
+		}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta metadata
+	status, err := p.doJSON(request, &meta)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery failed: status %d", status)
+	}
+
+	// OpenID Connect Discovery 1.0, 4.3: issuer в документе должен совпадать с тем, по которому его запросили
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery issuer mismatch: %s", meta.Issuer)
+	}
+
+	if p.cfg.AuthURL != "" {
+		meta.AuthorizationEndpoint = p.cfg.AuthURL
+	}
+
+	if p.cfg.TokenURL != "" {
+		meta.TokenEndpoint = p.cfg.TokenURL
+	}
+
+	if p.cfg.JWKSURL != "" {
+		meta.JWKSURI = p.cfg.JWKSURL
+	}
+
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("discovery document has no required endpoints")
+	}
+
+	p.discovery = &meta
+	return p.discovery, nil
+}
+
+func (p *Provider) jwksURL(ctx context.Context) (string, error) {
+	endpoints, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return endpoints.JWKSURI, nil
+}
+
+func (p *Provider) doJSON(request *http.Request, dst any) (int, error) {
+	return doJSON(p.client, request, dst)
+}
+
+func doJSON(client *http.Client, request *http.Request, dst any) (int, error) {
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return response.StatusCode, err
+	}
+
+	if err = json.Unmarshal(body, dst); err != nil && response.StatusCode == http.StatusOK {
+		return response.StatusCode, fmt.Errorf("decode response: %w", err)
+	}
+
+	return response.StatusCode, nil
+}
@@ -0,0 +1,155 @@
+package oidc_test
+
+import (
+	"context"
+	"github.com/Elaman1/full-project-mock/pkg/oidc"
+	"github.com/Elaman1/full-project-mock/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	clientID     = "test-client"
+	clientSecret = "test-secret"
+	redirectURL  = "https://app.example.com/oauth/callback"
+	nonce        = "test-nonce"
+)
+
+var identity = oidctest.Identity{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"}
+
+func newProvider(server *oidctest.Server) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       server.Issuer(),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	}, server.Client())
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	server := oidctest.NewServer(clientID, clientSecret)
+	defer server.Close()
+
+	link, err := newProvider(server).AuthCodeURL(context.Background(), "state", nonce, "challenge")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, clientID, query.Get("client_id"))
+	assert.Equal(t, redirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, nonce, query.Get("nonce"))
+	assert.Equal(t, "challenge", query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func TestExchange(t *testing.T) {
+	server := oidctest.NewServer(clientID, clientSecret)
+	defer server.Close()
+
+	verifier, challenge, err := oidc.NewPKCE()
+	require.NoError(t, err)
+	assert.Equal(t, oidctest.Challenge(verifier), challenge)
+
+	valid := func() jwt.MapClaims { return server.Claims(nonce, identity) }
+
+	cases := []struct {
+		name     string
+		claims   func() jwt.MapClaims
+		verifier string
+		nonce    string
+		wantErr  bool
+	}{
+		{name: "success", claims: valid, verifier: verifier, nonce: nonce},
+		{name: "wrong code verifier", claims: valid, verifier: "other", nonce: nonce, wantErr: true},
+		{name: "nonce mismatch", claims: valid, verifier: verifier, nonce: "other", wantErr: true},
+		{
+			name: "foreign audience",
+			claims: func() jwt.MapClaims {
+				c := valid()
+				c["aud"] = "other-client"
+				return c
+			},
+			verifier: verifier, nonce: nonce, wantErr: true,
+		},
+		{
+			name: "several audiences without azp",
+			claims: func() jwt.MapClaims {
+				c := valid()
+				c["aud"] = []string{clientID, "other-client"}
+				return c
+			},
+			verifier: verifier, nonce: nonce, wantErr: true,
+		},
+		{
+			name: "foreign issuer",
+			claims: func() jwt.MapClaims {
+				c := valid()
+				c["iss"] = "https://evil.example.com"
+				return c
+			},
+			verifier: verifier, nonce: nonce, wantErr: true,
+		},
+		{
+			name: "expired",
+			claims: func() jwt.MapClaims {
+				c := valid()
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+				return c
+			},
+			verifier: verifier, nonce: nonce, wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server.AddCodeWithClaims("code-"+tc.name, challenge, tc.claims())
+
+			claims, err := newProvider(server).Exchange(context.Background(), "code-"+tc.name, tc.verifier, tc.nonce)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, oidc.Claims{Subject: identity.Subject, Email: identity.Email, EmailVerified: true, Name: identity.Name}, claims)
+		})
+	}
+
+	t.Run("code is single use", func(t *testing.T) {
+		provider := newProvider(server)
+		server.AddCode("once", nonce, challenge, identity)
+
+		_, err := provider.Exchange(context.Background(), "once", verifier, nonce)
+		require.NoError(t, err)
+		_, err = provider.Exchange(context.Background(), "once", verifier, nonce)
+		assert.Error(t, err)
+	})
+}
+
+func TestExchange_RejectsSymmetricSignature(t *testing.T) {
+	server := oidctest.NewServer(clientID, clientSecret)
+	defer server.Close()
+
+	verifier, challenge, err := oidc.NewPKCE()
+	require.NoError(t, err)
+
+	// HS256 с client_secret мог бы подписать кто угодно, кто знает секрет
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, server.Claims(nonce, identity))
+	forged.Header["kid"] = "test-key"
+	idToken, err := forged.SignedString([]byte(clientSecret))
+	require.NoError(t, err)
+
+	server.AddCodeWithIDToken("code", challenge, idToken)
+	_, err = newProvider(server).Exchange(context.Background(), "code", verifier, nonce)
+	assert.ErrorContains(t, err, "signing method HS256 is invalid")
+}
@@ -0,0 +1,182 @@
+// Package oidctest Локальный OpenID-провайдер для тестов: discovery, JWKS и token endpoint с проверкой PKCE
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "test-key"
+
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type grant struct {
+	challenge string
+	claims    jwt.MapClaims
+	idToken   string // если задан, отдается вместо подписанных claims
+}
+
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+func NewServer(clientID, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{ClientID: clientID, ClientSecret: clientSecret, key: key, grants: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// AddCode Код, который token endpoint обменяет на ID-токен пользователя id.
+// codeChallenge - S256 от code_verifier, который клиент пришлет при обмене
+func (s *Server) AddCode(code, nonce, codeChallenge string, id Identity) {
+	s.AddCodeWithClaims(code, codeChallenge, s.Claims(nonce, id))
+}
+
+// AddCodeWithClaims Для проверки отказов: claims попадут в ID-токен как есть
+func (s *Server) AddCodeWithClaims(code, codeChallenge string, claims jwt.MapClaims) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants[code] = grant{challenge: codeChallenge, claims: claims}
+}
+
+// AddCodeWithIDToken Отдает idToken как есть, например подписанный чужим ключом
+func (s *Server) AddCodeWithIDToken(code, codeChallenge, idToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants[code] = grant{challenge: codeChallenge, idToken: idToken}
+}
+
+// Claims Корректные claims ID-токена для id
+func (s *Server) Claims(nonce string, id Identity) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            s.Issuer(),
+		"aud":            s.ClientID,
+		"sub":            id.Subject,
+		"email":          id.Email,
+		"email_verified": id.EmailVerified,
+		"name":           id.Name,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+// Challenge S256 code_challenge для verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (s *Server) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.Issuer(),
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != s.ClientID || clientSecret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	g, ok := s.grants[r.PostForm.Get("code")]
+	delete(s.grants, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	if !ok || Challenge(r.PostForm.Get("code_verifier")) != g.challenge {
+		writeError(w, "invalid_grant")
+		return
+	}
+
+	idToken := g.idToken
+	if idToken == "" {
+		var err error
+		if idToken, err = s.SignIDToken(g.claims); err != nil {
+			writeError(w, "server_error")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) SignIDToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	return token.SignedString(s.key)
+}
+
+func writeError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -1,14 +1,62 @@
 package req
 
-import "net/http"
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
 
+type clientIPKey struct{}
+
+// WithClientIP Кладет в контекст IP клиента, определенный ClientIPResolver
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey{}).(string)
+	return ip, ok && ip != ""
+}
+
+type userAgentKey struct{}
+
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// UserAgentFromContext Для мест, куда User-Agent не передается явно, например журнал аудита
+func UserAgentFromContext(ctx context.Context) string {
+	userAgent, _ := ctx.Value(userAgentKey{}).(string)
+	return userAgent
+}
+
+// GetClientMeta IP берется из контекста. Без ClientIPResolver заголовкам прокси не доверяем и берем адрес соединения
 func GetClientMeta(r *http.Request) (ip string, userAgent string) {
-	// Попытка получить из X-Forwarded-For (если за прокси)
-	ip = r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		ip = r.RemoteAddr
+	ip, ok := ClientIPFromContext(r.Context())
+	if !ok {
+		ip = RemoteIP(r)
 	}
 
 	userAgent = r.Header.Get("User-Agent")
 	return ip, userAgent
 }
+
+// RemoteIP Адрес соединения без порта: порт у каждого соединения свой, для лимитов по IP он не нужен
+func RemoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+// GetBearerToken Достает токен из заголовка Authorization: Bearer <token>
+func GetBearerToken(r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", false
+	}
+
+	return strings.TrimPrefix(authHeader, "Bearer "), true
+}
@@ -0,0 +1,135 @@
+package req
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPResolver Определяет IP клиента за доверенными прокси.
+// Заголовки прокси учитываются, только если соединение пришло от доверенного адреса
+type ClientIPResolver struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIPResolver Принимает CIDR или отдельные адреса. Пустой список - заголовки прокси игнорируются
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	trusted := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, value := range trustedProxies {
+		prefix, err := ParseTrustedProxy(value)
+		if err != nil {
+			return nil, err
+		}
+
+		trusted = append(trusted, prefix)
+	}
+
+	return &ClientIPResolver{trusted: trusted}, nil
+}
+
+// ParseTrustedProxy Адрес без маски считается сетью из одного адреса
+func ParseTrustedProxy(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+	}
+
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Resolve Идет по цепочке прокси справа налево и возвращает первый недоверенный адрес.
+// Левые записи может подделать сам клиент, поэтому им верим только через доверенные хопы
+func (c *ClientIPResolver) Resolve(r *http.Request) string {
+	remote := RemoteIP(r)
+	remoteAddr, err := netip.ParseAddr(remote)
+	if err != nil || !c.isTrusted(remoteAddr) {
+		return remote
+	}
+
+	ip := remoteAddr
+	hops := forwardedHops(r)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHop(hops[i])
+		if !ok {
+			// unknown, обфусцированный идентификатор или мусор: дальше цепочке верить нельзя
+			break
+		}
+
+		ip = hop
+		if !c.isTrusted(hop) {
+			break
+		}
+	}
+
+	return ip.String()
+}
+
+func (c *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardedHops Адреса из Forwarded (RFC 7239), а если его нет - из X-Forwarded-For. Порядок - от клиента к прокси
+func forwardedHops(r *http.Request) []string {
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		var hops []string
+		for _, element := range strings.Split(strings.Join(values, ","), ",") {
+			hops = append(hops, forwardedFor(element))
+		}
+
+		return hops
+	}
+
+	values := r.Header.Values("X-Forwarded-For")
+	if len(values) == 0 {
+		return nil
+	}
+
+	return strings.Split(strings.Join(values, ","), ",")
+}
+
+// forwardedFor Значение параметра for из элемента Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(key, "for") {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// parseHop Понимает 192.0.2.43, 192.0.2.43:47011, "[2001:db8::1]:4711" и 2001:db8::1
+func parseHop(hop string) (netip.Addr, bool) {
+	hop = strings.Trim(strings.TrimSpace(hop), `"`)
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(hop, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
@@ -0,0 +1,66 @@
+package req
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		forwarded    []string
+		expectedIP   string
+	}{
+		{name: "no proxy headers", remoteAddr: "203.0.113.5:4000", expectedIP: "203.0.113.5"},
+		{name: "untrusted remote spoofs header", remoteAddr: "203.0.113.5:4000", forwardedFor: []string{"1.2.3.4"}, expectedIP: "203.0.113.5"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.5"}, expectedIP: "203.0.113.5"},
+		{name: "client prepends fake hop", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"1.2.3.4, 203.0.113.5"}, expectedIP: "203.0.113.5"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.5, 192.168.1.10, 10.0.0.2"}, expectedIP: "203.0.113.5"},
+		{name: "several header lines", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"1.2.3.4", "203.0.113.5, 10.0.0.2"}, expectedIP: "203.0.113.5"},
+		{name: "all hops trusted", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"10.0.0.3, 10.0.0.2"}, expectedIP: "10.0.0.3"},
+		{name: "garbage hop stops walk", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.5, garbage, 10.0.0.2"}, expectedIP: "10.0.0.2"},
+		{name: "hop with port", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.5:5555"}, expectedIP: "203.0.113.5"},
+		{name: "trusted ipv6 proxy", remoteAddr: "[fd00::1]:4000", forwardedFor: []string{"2001:db8::7"}, expectedIP: "2001:db8::7"},
+		{name: "forwarded header", remoteAddr: "10.0.0.1:4000", forwarded: []string{`for=1.2.3.4, for="[2001:db8:cafe::17]:4711";proto=https`}, expectedIP: "2001:db8:cafe::17"},
+		{name: "forwarded takes precedence", remoteAddr: "10.0.0.1:4000", forwarded: []string{"for=198.51.100.7;by=10.0.0.1"}, forwardedFor: []string{"203.0.113.5"}, expectedIP: "198.51.100.7"},
+		{name: "forwarded obfuscated identifier", remoteAddr: "10.0.0.1:4000", forwarded: []string{"for=_hidden, for=10.0.0.2"}, expectedIP: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			for _, value := range tt.forwarded {
+				r.Header.Add("Forwarded", value)
+			}
+
+			assert.Equal(t, tt.expectedIP, resolver.Resolve(r))
+		})
+	}
+}
+
+func TestNewClientIPResolver(t *testing.T) {
+	_, err := NewClientIPResolver([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+
+	_, err = NewClientIPResolver([]string{"proxy.local"})
+	assert.Error(t, err)
+
+	resolver, err := NewClientIPResolver(nil)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:4000"
+	r.Header.Set("X-Forwarded-For", "203.0.113.5")
+	assert.Equal(t, "10.0.0.1", resolver.Resolve(r))
+}
@@ -0,0 +1,41 @@
+package req
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetClientMeta(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		contextIP    string
+		expectedIP   string
+	}{
+		{name: "remote addr with port", remoteAddr: "10.0.0.1:53211", expectedIP: "10.0.0.1"},
+		{name: "ipv6 remote addr", remoteAddr: "[::1]:8080", expectedIP: "::1"},
+		{name: "remote addr without port", remoteAddr: "127.0.0.1", expectedIP: "127.0.0.1"},
+		{name: "forwarded for is ignored without resolver", remoteAddr: "10.0.0.1:53211", forwardedFor: "203.0.113.5", expectedIP: "10.0.0.1"},
+		{name: "ip from context", remoteAddr: "10.0.0.1:53211", forwardedFor: "203.0.113.5", contextIP: "203.0.113.5", expectedIP: "203.0.113.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header.Set("User-Agent", "test-agent")
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.contextIP != "" {
+				r = r.WithContext(WithClientIP(r.Context(), tt.contextIP))
+			}
+
+			ip, ua := GetClientMeta(r)
+			assert.Equal(t, tt.expectedIP, ip)
+			assert.Equal(t, "test-agent", ua)
+		})
+	}
+}
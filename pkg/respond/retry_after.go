@@ -0,0 +1,18 @@
+package respond
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SetRetryAfter Заголовок в секундах, округляем вверх, чтобы клиент не пришел раньше времени. 0 - заголовок не ставится
+func SetRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		return
+	}
+
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
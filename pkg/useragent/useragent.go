@@ -0,0 +1,84 @@
+package useragent
+
+import "strings"
+
+// Device Грубое описание клиента для списка сессий. Точный разбор User-Agent здесь не нужен
+type Device struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Type    string `json:"type"` // desktop, mobile, tablet, bot или unknown
+}
+
+const unknown = "unknown"
+
+// Порядок важен: Edge и Opera содержат Chrome, Chrome содержит Safari
+var browsers = []struct {
+	token string
+	name  string
+}{
+	{"edg/", "Edge"},
+	{"opr/", "Opera"},
+	{"yabrowser/", "Yandex Browser"},
+	{"firefox/", "Firefox"},
+	{"chrome/", "Chrome"},
+	{"safari/", "Safari"},
+	{"curl/", "curl"},
+	{"postmanruntime/", "Postman"},
+	{"okhttp/", "OkHttp"},
+	{"go-http-client/", "Go HTTP client"},
+}
+
+// Android и iOS проверяются раньше Linux и macOS: их User-Agent содержит оба
+var systems = []struct {
+	token string
+	name  string
+}{
+	{"android", "Android"},
+	{"iphone", "iOS"},
+	{"ipad", "iPadOS"},
+	{"windows", "Windows"},
+	{"mac os x", "macOS"},
+	{"cros", "ChromeOS"},
+	{"linux", "Linux"},
+}
+
+func Parse(ua string) Device {
+	lower := strings.ToLower(ua)
+	if lower == "" {
+		return Device{Browser: unknown, OS: unknown, Type: unknown}
+	}
+
+	device := Device{Browser: unknown, OS: unknown}
+
+	for _, b := range browsers {
+		if strings.Contains(lower, b.token) {
+			device.Browser = b.name
+			break
+		}
+	}
+
+	for _, s := range systems {
+		if strings.Contains(lower, s.token) {
+			device.OS = s.name
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(lower, "bot") || strings.Contains(lower, "spider") || strings.Contains(lower, "crawl"):
+		device.Type = "bot"
+	case strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet"):
+		device.Type = "tablet"
+	case strings.Contains(lower, "mobi") || strings.Contains(lower, "iphone"):
+		device.Type = "mobile"
+	case device.OS == "Android":
+		// Android без Mobile в User-Agent - планшет
+		device.Type = "tablet"
+	case device.OS != unknown:
+		device.Type = "desktop"
+	default:
+		device.Type = unknown
+	}
+
+	return device
+}
@@ -0,0 +1,56 @@
+package useragent
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Device
+	}{
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want: Device{Browser: "Chrome", OS: "Windows", Type: "desktop"},
+		},
+		{
+			name: "edge is not chrome",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+			want: Device{Browser: "Edge", OS: "Windows", Type: "desktop"},
+		},
+		{
+			name: "safari on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want: Device{Browser: "Safari", OS: "iOS", Type: "mobile"},
+		},
+		{
+			name: "firefox on android",
+			ua:   "Mozilla/5.0 (Android 14; Mobile; rv:127.0) Gecko/127.0 Firefox/127.0",
+			want: Device{Browser: "Firefox", OS: "Android", Type: "mobile"},
+		},
+		{
+			name: "android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want: Device{Browser: "Chrome", OS: "Android", Type: "tablet"},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.5.0",
+			want: Device{Browser: "curl", OS: "unknown", Type: "unknown"},
+		},
+		{
+			name: "empty",
+			ua:   "",
+			want: Device{Browser: "unknown", OS: "unknown", Type: "unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.ua))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package validator
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// breachedRangeLength Длина префикса группы, как в k-anonymity API Have I Been Pwned
+	breachedRangeLength = 5
+	// minBreachedHashLength Запись короче совпадала бы со слишком многими паролями
+	minBreachedHashLength = 10
+)
+
+// BreachedPasswords Офлайн-список утекших паролей по SHA-1. Хеши разложены по группам по первым 5 символам,
+// пароль сравнивается только с суффиксами своей группы. Запись короче полного хеша считается префиксом:
+// под нее попадает любой пароль, хеш которого с нее начинается
+type BreachedPasswords struct {
+	ranges map[string][]string
+}
+
+// LoadBreachedPasswords Файл в формате выгрузки Have I Been Pwned: по SHA-1 (hex) на строку, после ":" может идти
+// число утечек. Пустые строки и строки с # пропускаются
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseBreachedPasswords(file)
+}
+
+func ParseBreachedPasswords(r io.Reader) (*BreachedPasswords, error) {
+	breached := &BreachedPasswords{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(entry, ":")
+		hash = strings.ToUpper(strings.TrimSpace(hash))
+		if len(hash) < minBreachedHashLength || len(hash) > sha1.Size*2 {
+			return nil, fmt.Errorf("line %d: hash must be %d to %d hex characters", line, minBreachedHashLength, sha1.Size*2)
+		}
+
+		if strings.Trim(hash, "0123456789ABCDEF") != "" {
+			return nil, fmt.Errorf("line %d: invalid hex hash", line)
+		}
+
+		prefix := hash[:breachedRangeLength]
+		breached.ranges[prefix] = append(breached.ranges[prefix], hash[breachedRangeLength:])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return breached, nil
+}
+
+func (b *BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	for _, suffix := range b.ranges[hash[:breachedRangeLength]] {
+		if strings.HasPrefix(hash[breachedRangeLength:], suffix) {
+			return true
+		}
+	}
+
+	return false
+}
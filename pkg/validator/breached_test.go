@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// SHA-1 от "password" - 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8, от "123456" - 7C4A8D09CA3762AF61E59520943DC26494F8941B
+func TestBreachedPasswords(t *testing.T) {
+	list := strings.Join([]string{
+		"# выгрузка утечек",
+		"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:3861493",
+		"",
+		"7C4A8D09CA3762", // префикс хеша
+	}, "\n")
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+
+	breached, err := LoadBreachedPasswords(path)
+	require.NoError(t, err)
+
+	assert.True(t, breached.Contains("password"))
+	assert.True(t, breached.Contains("123456"))
+	assert.False(t, breached.Contains("Password"))
+	assert.False(t, breached.Contains("correct horse battery"))
+}
+
+func TestParseBreachedPasswordsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		list string
+	}{
+		{"too short", "5BAA61E4C"},
+		{"too long", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD80"},
+		{"not hex", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FDZ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBreachedPasswords(strings.NewReader(tt.list))
+			assert.ErrorContains(t, err, "line 1")
+		})
+	}
+}
+
+func TestLoadBreachedPasswordsMissingFile(t *testing.T) {
+	_, err := LoadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
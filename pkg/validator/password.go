@@ -0,0 +1,162 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	DefaultMinPasswordLength = 8
+	DefaultMaxPasswordLength = 128
+	// minPersonalInfoLength Более короткие username и части email не ищем в пароле, иначе запрещено слишком много
+	minPersonalInfoLength = 3
+)
+
+// Правила политики пароля, приходят клиенту в violations[].rule
+const (
+	RuleMinLength    = "min_length"
+	RuleMaxLength    = "max_length"
+	RuleLowercase    = "lowercase"
+	RuleUppercase    = "uppercase"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RulePersonalInfo = "personal_info"
+	RuleBreached     = "breached"
+)
+
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError Все нарушенные правила сразу, чтобы клиент показал их вместе
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// PasswordPolicy Нулевые MinLength и MaxLength заменяются значениями по умолчанию, без Breached утечки не проверяются
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	Breached         *BreachedPasswords
+}
+
+// Validate personal - username, email и другие данные пользователя, которых не должно быть в пароле.
+// Возвращает *PasswordPolicyError
+func (p PasswordPolicy) Validate(password string, personal ...string) error {
+	minLength, maxLength := p.MinLength, p.maxLength()
+	if minLength == 0 {
+		minLength = DefaultMinPasswordLength
+	}
+
+	// Слишком длинный пароль дальше не проверяем и не хешируем
+	length := utf8.RuneCountInString(password)
+	if length > maxLength {
+		return &PasswordPolicyError{Violations: []PasswordViolation{
+			{Rule: RuleMaxLength, Message: fmt.Sprintf("password must be at most %d characters", maxLength)},
+		}}
+	}
+
+	var violations []PasswordViolation
+	if length < minLength {
+		violations = append(violations, PasswordViolation{Rule: RuleMinLength, Message: fmt.Sprintf("password must be at least %d characters", minLength)})
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, PasswordViolation{Rule: RuleLowercase, Message: "password must contain a lowercase letter"})
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, PasswordViolation{Rule: RuleUppercase, Message: "password must contain an uppercase letter"})
+	}
+
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, PasswordViolation{Rule: RuleDigit, Message: "password must contain a digit"})
+	}
+
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, PasswordViolation{Rule: RuleSymbol, Message: "password must contain a symbol"})
+	}
+
+	if containsPersonalInfo(password, personal) {
+		violations = append(violations, PasswordViolation{Rule: RulePersonalInfo, Message: "password must not contain username or email"})
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, PasswordViolation{Rule: RuleBreached, Message: "password appears in a known data breach"})
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// TooLong Такой пароль нельзя установить, поэтому при входе и подтверждении паролем он заведомо неверный
+// и хешировать его не нужно
+func (p PasswordPolicy) TooLong(password string) bool {
+	// Байтов не меньше, чем символов: короткую строку не считаем
+	if len(password) <= p.maxLength() {
+		return false
+	}
+
+	return utf8.RuneCountInString(password) > p.maxLength()
+}
+
+func (p PasswordPolicy) maxLength() int {
+	if p.MaxLength == 0 {
+		return DefaultMaxPasswordLength
+	}
+
+	return p.MaxLength
+}
+
+// containsPersonalInfo Без учета регистра. У email проверяем и адрес целиком, и часть до @
+func containsPersonalInfo(password string, personal []string) bool {
+	password = strings.ToLower(password)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		candidates := []string{value}
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			candidates = append(candidates, local)
+		}
+
+		for _, candidate := range candidates {
+			if utf8.RuneCountInString(candidate) >= minPersonalInfoLength && strings.Contains(password, candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	breached, err := ParseBreachedPasswords(strings.NewReader("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n"))
+	require.NoError(t, err)
+
+	strict := PasswordPolicy{RequireLowercase: true, RequireUppercase: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		personal []string
+		rules    []string
+	}{
+		{name: "empty", password: "", rules: []string{RuleMinLength}},
+		{name: "too short", password: "1234567", rules: []string{RuleMinLength}},
+		{name: "min length", password: "12345678"},
+		{name: "cyrillic counted by runes", password: "пароль12"},
+		{name: "max length", password: strings.Repeat("a", DefaultMaxPasswordLength)},
+		{name: "too long reports only length", policy: strict, password: strings.Repeat("a", DefaultMaxPasswordLength+1), rules: []string{RuleMaxLength}},
+		{name: "custom length", policy: PasswordPolicy{MinLength: 4, MaxLength: 6}, password: "12345"},
+		{name: "custom max", policy: PasswordPolicy{MinLength: 4, MaxLength: 6}, password: "1234567", rules: []string{RuleMaxLength}},
+		{name: "all classes", policy: strict, password: "Secret-Pass1"},
+		{name: "unicode classes", policy: strict, password: "Пароль пр1"},
+		{name: "missing classes", policy: strict, password: "abcdefgh", rules: []string{RuleUppercase, RuleDigit, RuleSymbol}},
+		{name: "contains username", password: "my-JohnDoe-pass", personal: []string{"johndoe", "x@test.com"}, rules: []string{RulePersonalInfo}},
+		{name: "contains email local part", password: "alice2024!", personal: []string{"bob", "Alice@test.com"}, rules: []string{RulePersonalInfo}},
+		{name: "short username ignored", password: "bobcat-secret", personal: []string{"bo"}},
+		{name: "breached", policy: PasswordPolicy{Breached: breached}, password: "password", rules: []string{RuleBreached}},
+		{name: "not breached", policy: PasswordPolicy{Breached: breached}, password: "correct horse battery"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.personal...)
+			if len(tt.rules) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			require.True(t, errors.As(err, &policyErr))
+			rules := make([]string, 0, len(policyErr.Violations))
+			for _, violation := range policyErr.Violations {
+				rules = append(rules, violation.Rule)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
+
+func TestPasswordPolicyErrorMessage(t *testing.T) {
+	err := PasswordPolicy{RequireDigit: true}.Validate("short")
+	assert.EqualError(t, err, "password must be at least 8 characters; password must contain a digit")
+}
+
+func TestPasswordPolicyTooLong(t *testing.T) {
+	assert.False(t, PasswordPolicy{}.TooLong(strings.Repeat("a", DefaultMaxPasswordLength)))
+	assert.True(t, PasswordPolicy{}.TooLong(strings.Repeat("a", DefaultMaxPasswordLength+1)))
+	// Длина считается в символах, а не в байтах
+	assert.False(t, PasswordPolicy{}.TooLong(strings.Repeat("п", DefaultMaxPasswordLength)))
+	assert.True(t, PasswordPolicy{MaxLength: 6}.TooLong("1234567"))
+}